@@ -1,6 +1,7 @@
 package app
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/deployithq/deployit/daemon/env"
@@ -336,7 +337,7 @@ func (a *App) Ports(e *env.Env) (int64, error) {
 	}
 
 	for _, container := range a.Containers {
-		ports, err := e.Containers.InspectContainers(&interfaces.Container{
+		ports, err := e.Containers.InspectContainers(context.Background(), &interfaces.Container{
 			CID: container.ID,
 		})
 
@@ -10,8 +10,60 @@ const (
 )
 
 type Env struct {
-	Log        interfaces.ILog
-	LDB        interfaces.ILDB
+	Log interfaces.ILog
+	LDB interfaces.ILDB
+
+	// Read-only replica of LDB read-only service views are served from, LDB is used if nil.
+	// Replica may lag behind, so services which are changed are read from LDB
+	ReadLDB    interfaces.ILDB
 	Containers interfaces.IContainers
+	Registry   interfaces.IRegistry
 	Port       int
+	Registries map[string]interfaces.AuthConfig
+
+	// Names service containers, <service>-<index> if nil
+	Namer interfaces.INamer
+
+	// Receives addresses of started replicas, traffic is not routed by daemon if nil
+	Balancer interfaces.IBalancer
+
+	// Keeps DNS records of started services pointing at DNSHost, records are not kept if nil
+	DNS     interfaces.IDNS
+	DNSHost string
+
+	// Gates deploys of vulnerable images, images are not scanned if nil
+	Scanner interfaces.IImageScanner
+
+	// Allows services to mount host resources like docker socket
+	AllowPrivilegedMounts bool
+
+	// Allows services to run deploy hooks on daemon host
+	AllowHostHooks bool
+
+	// Resources all running services may commit on host
+	Quota Quota
+
+	// Profile like dev or prod services are sized for by their profiles config
+	Profile string
+
+	// Bounds concurrent image pulls daemon-wide, unlimited if nil
+	Pulls chan struct{}
+
+	// Host lock is held, services are not changed without it
+	HostLocked bool
+}
+
+// Reader is db reads of service state are served from
+func (e *Env) Reader() interfaces.ILDB {
+	if e.ReadLDB != nil {
+		return e.ReadLDB
+	}
+
+	return e.LDB
+}
+
+// Limits of host resources, zero values are not limited
+type Quota struct {
+	Memory int64 // MB
+	CPUs   float64
 }
@@ -89,12 +89,60 @@ func (r Route) Init(env *env.Env) {
 	route.HandleFunc("/app/{name}", Handle(Handler{env, routes.RemoveAppHandler})).Methods("DELETE")
 
 	// service logic handler
+	route.HandleFunc("/service", Handle(Handler{env, routes.ListServicesHandler})).Methods("GET")
+	route.HandleFunc("/service", Handle(Handler{env, routes.ImportComposeHandler})).Methods("POST")
 	route.HandleFunc("/service/{name}", Handle(Handler{env, routes.CreateServiceHandler})).Methods("PUT")
+	route.HandleFunc("/service/{name}", Handle(Handler{env, routes.InspectServiceHandler})).Methods("GET")
 	route.HandleFunc("/service/{name}/logs", Handle(Handler{env, routes.LogsServiceHandler})).Methods("GET")
+	route.HandleFunc("/service/{name}/tags", Handle(Handler{env, routes.SetTagsServiceHandler})).Methods("PUT")
+	route.HandleFunc("/service/{name}/status", Handle(Handler{env, routes.StatusServiceHandler})).Methods("GET")
+	route.HandleFunc("/service/{name}/ready", Handle(Handler{env, routes.ReadyServiceHandler})).Methods("GET")
+	route.HandleFunc("/service/{name}/health", Handle(Handler{env, routes.HealthServiceHandler})).Methods("GET")
+	route.HandleFunc("/service/{name}/containers", Handle(Handler{env, routes.ContainersServiceHandler})).Methods("GET")
+	route.HandleFunc("/service/{name}/replica/{index}", Handle(Handler{env, routes.ReplicaServiceHandler})).Methods("GET")
+	route.HandleFunc("/service/{name}/changes/{container}", Handle(Handler{env, routes.ChangesServiceHandler})).Methods("GET")
+	route.HandleFunc("/service/{name}/compose", Handle(Handler{env, routes.ComposeServiceHandler})).Methods("GET")
+	route.HandleFunc("/service/{name}/exec/{container}", Handle(Handler{env, routes.ExecServiceHandler})).Methods("POST")
+	route.HandleFunc("/service/{name}/env", Handle(Handler{env, routes.SetEnvServiceHandler})).Methods("PUT")
+	route.HandleFunc("/service/{name}/env/{container}", Handle(Handler{env, routes.EnvServiceHandler})).Methods("GET")
+	route.HandleFunc("/service/{name}/config", Handle(Handler{env, routes.ConfigServiceHandler})).Methods("GET")
+	route.HandleFunc("/service/{name}/config/{field}", Handle(Handler{env, routes.SetConfigFieldServiceHandler})).Methods("PUT")
+	route.HandleFunc("/service/{name}/deploy", Handle(Handler{env, routes.DeployServiceHandler})).Methods("POST")
+	route.HandleFunc("/service/{name}/deploy/prepare", Handle(Handler{env, routes.PrepareDeployServiceHandler})).Methods("POST")
+	route.HandleFunc("/service/{name}/deploy/confirm", Handle(Handler{env, routes.ConfirmDeployServiceHandler})).Methods("POST")
+	route.HandleFunc("/service/{name}/deploy/cancel", Handle(Handler{env, routes.CancelDeployServiceHandler})).Methods("POST")
 	route.HandleFunc("/service/{name}/start", Handle(Handler{env, routes.StartServiceHandler})).Methods("POST")
 	route.HandleFunc("/service/{name}/stop", Handle(Handler{env, routes.StopServiceHandler})).Methods("POST")
 	route.HandleFunc("/service/{name}/restart", Handle(Handler{env, routes.RestartServiceHandler})).Methods("POST")
+	route.HandleFunc("/service/{name}/reload", Handle(Handler{env, routes.ReloadServiceHandler})).Methods("POST")
+	route.HandleFunc("/service/{name}/load", Handle(Handler{env, routes.LoadServiceHandler})).Methods("POST")
+	route.HandleFunc("/service/{name}/prepull", Handle(Handler{env, routes.PrePullServiceHandler})).Methods("POST")
+	route.HandleFunc("/service/{name}/clone", Handle(Handler{env, routes.CloneServiceHandler})).Methods("POST")
+	route.HandleFunc("/service/{name}/scale", Handle(Handler{env, routes.ScaleServiceHandler})).Methods("POST")
 	route.HandleFunc("/service/{name}", Handle(Handler{env, routes.RemoveServiceHandler})).Methods("DELETE")
+	route.HandleFunc("/service/{name}/action/{action}", Handle(Handler{env, routes.ActionServiceHandler})).Methods("POST")
+
+	// stored services against driver containers
+	route.HandleFunc("/conflicts", Handle(Handler{env, routes.ConflictsHandler})).Methods("GET", "POST")
+
+	// host ports reserved by services
+	route.HandleFunc("/ports", Handle(Handler{env, routes.ListPortsHandler})).Methods("GET")
+
+	// metrics in prometheus text format
+	route.HandleFunc("/metrics", Handle(Handler{env, routes.MetricsHandler})).Methods("GET")
+
+	// config files logic handler
+	route.HandleFunc("/configfile/{name}", Handle(Handler{env, routes.SaveConfigFileHandler})).Methods("PUT")
+	route.HandleFunc("/configfile/{name}", Handle(Handler{env, routes.GetConfigFileHandler})).Methods("GET")
+	route.HandleFunc("/configfile/{name}", Handle(Handler{env, routes.RemoveConfigFileHandler})).Methods("DELETE")
+
+	// stack logic handler
+	route.HandleFunc("/stack/{name}", Handle(Handler{env, routes.CreateStackHandler})).Methods("PUT")
+	route.HandleFunc("/stack/{name}", Handle(Handler{env, routes.StatusStackHandler})).Methods("GET")
+	route.HandleFunc("/stack/{name}/up", Handle(Handler{env, routes.UpStackHandler})).Methods("POST")
+	route.HandleFunc("/stack/{name}/down", Handle(Handler{env, routes.DownStackHandler})).Methods("POST")
+	route.HandleFunc("/stack/{name}/deploy", Handle(Handler{env, routes.DeployStackHandler})).Methods("POST")
+	route.HandleFunc("/stack/{name}", Handle(Handler{env, routes.RemoveStackHandler})).Methods("DELETE")
 
 	if err := http.ListenAndServe(":"+strconv.Itoa(env.Port), route); err != nil {
 		env.Log.Fatal("ListenAndServe: ", err)
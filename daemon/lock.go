@@ -0,0 +1,39 @@
+package daemon
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+const lockFile = ".deployit.lock"
+
+// Take exclusive lock of the host, so only one daemon manages its containers
+func lockHost(path string) (*os.File, error) {
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		file.Close()
+		if err == syscall.EWOULDBLOCK {
+			return nil, errors.New("host is managed by another daemon, lock " + path + " is held")
+		}
+
+		return nil, err
+	}
+
+	return file, nil
+}
+
+func unlockHost(file *os.File) error {
+
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_UN); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
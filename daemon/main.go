@@ -4,12 +4,32 @@ import (
 	"flag"
 	"fmt"
 	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/daemon/service"
+	"github.com/deployithq/deployit/drivers/dns"
 	"github.com/deployithq/deployit/drivers/docker"
+	"github.com/deployithq/deployit/drivers/interfaces"
 	"github.com/deployithq/deployit/drivers/localDB"
 	"github.com/deployithq/deployit/drivers/log"
+	"github.com/deployithq/deployit/drivers/redisDB"
+	"github.com/deployithq/deployit/drivers/registry"
+	"github.com/deployithq/deployit/drivers/upstream"
 	"github.com/deployithq/deployit/utils"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
 	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	reconcileInterval = 10 * time.Second
+	healthInterval    = 30 * time.Second
+	scheduleInterval  = 30 * time.Second
+	imageGCInterval   = time.Hour
+	shutdownTimeout   = 60 * time.Second
+	defaultMaxPulls   = 3
 )
 
 type DaemonCommand struct {
@@ -32,17 +52,52 @@ func (c *DaemonCommand) Run(args []string) int {
 		return 1
 	}
 
-	// Creating flags set
+	lock, err := lockHost(fmt.Sprintf("%s/%s", env.Default_root_path, lockFile))
+	if err != nil {
+		log.Fatal(err)
+		return 1
+	}
+
+	// Creating flags set, environment variables are defaults overridden by flags
 	cmdFlags := flag.NewFlagSet("daemon", flag.ContinueOnError)
 	cmdFlags.Usage = func() {
 		fmt.Print(c.Help())
 	}
 
-	cmdFlags.BoolVar(&c.Debug, "debug", false, "Enables debug mode")
-	if c.Debug == false {
-		if os.Getenv("DEPLOYIT_DEBUG") != "" {
-			c.Debug = true
-		}
+	cmdFlags.BoolVar(&c.Debug, "debug", os.Getenv("DEPLOYIT_DEBUG") != "", "Enables debug mode")
+
+	redisAddress := cmdFlags.String("redis", os.Getenv("DEPLOYIT_REDIS_ADDRESS"), "Redis address to store daemon data, local db is used if empty")
+	replicaAddress := cmdFlags.String("redis-replica", os.Getenv("DEPLOYIT_REDIS_REPLICA_ADDRESS"), "Redis read replica address to serve service reads from, --redis is used if empty")
+
+	// Proxy of registry digest lookups only, docker engine pulls images through its own proxy config
+	proxy := registry.Proxy{
+		HTTP:    envString("DEPLOYIT_DIGEST_HTTP_PROXY", os.Getenv("HTTP_PROXY")),
+		HTTPS:   envString("DEPLOYIT_DIGEST_HTTPS_PROXY", os.Getenv("HTTPS_PROXY")),
+		NoProxy: envString("DEPLOYIT_DIGEST_NO_PROXY", os.Getenv("NO_PROXY")),
+	}
+
+	cmdFlags.StringVar(&proxy.HTTP, "digest-http-proxy", proxy.HTTP, "Proxy of http registry digest lookups, image pulls use docker engine proxy config")
+	cmdFlags.StringVar(&proxy.HTTPS, "digest-https-proxy", proxy.HTTPS, "Proxy of https registry digest lookups, image pulls use docker engine proxy config")
+	cmdFlags.StringVar(&proxy.NoProxy, "digest-no-proxy", proxy.NoProxy, "Comma separated hosts registry digests are looked up directly")
+
+	allowPrivilegedMounts := cmdFlags.Bool("allow-privileged-mounts", os.Getenv("DEPLOYIT_ALLOW_PRIVILEGED_MOUNTS") != "", "Allows services to mount docker socket")
+	allowHostHooks := cmdFlags.Bool("allow-host-hooks", os.Getenv("DEPLOYIT_ALLOW_HOST_HOOKS") != "", "Allows services to run deploy hooks on daemon host")
+
+	quotaMemory := cmdFlags.Int64("quota-memory", envInt64("DEPLOYIT_QUOTA_MEMORY", 0), "Memory in MB running services may commit on host, 0 means unlimited")
+	quotaCPUs := cmdFlags.Float64("quota-cpus", envFloat("DEPLOYIT_QUOTA_CPUS", 0), "CPUs running services may commit on host, 0 means unlimited")
+
+	profile := cmdFlags.String("profile", os.Getenv("DEPLOYIT_PROFILE"), "Profile like dev or prod services resources are sized for")
+	maxPulls := cmdFlags.Int("max-pulls", int(envInt64("DEPLOYIT_MAX_PULLS", defaultMaxPulls)), "Maximum concurrent image pulls, 0 means unlimited")
+	containerName := cmdFlags.String("container-name", os.Getenv("DEPLOYIT_CONTAINER_NAME"), "Container name template with {{.Service}} and {{.Index}}, <service>-<index> if empty")
+	upstreamsDir := cmdFlags.String("upstreams-dir", os.Getenv("DEPLOYIT_UPSTREAMS_DIR"), "Directory of service upstream lists for reverse proxy, replicas are not registered if empty")
+	imageGCKeep := cmdFlags.Int("image-gc-keep", int(envInt64("DEPLOYIT_IMAGE_GC_KEEP", -1)), "Images of last deploys kept by hourly image collection, images are not collected if negative")
+	zoneFile := cmdFlags.String("dns-zone-file", os.Getenv("DEPLOYIT_DNS_ZONE_FILE"), "Zone file DNS records of started services are kept in, records are not kept if empty")
+	dnsHost := cmdFlags.String("dns-host", os.Getenv("DEPLOYIT_DNS_HOST"), "Host address DNS records point at, daemon hostname if empty")
+	port := cmdFlags.Int("port", int(envInt64("DEPLOYIT_DAEMON_PORT", 3000)), "Daemon port")
+
+	if err := cmdFlags.Parse(args); err != nil {
+		log.Error(err)
+		return 1
 	}
 
 	if c.Debug {
@@ -50,31 +105,190 @@ func (c *DaemonCommand) Run(args []string) int {
 		log.Debug("Debug mode enabled")
 	}
 
-	log.Info("Init local db")
-	ldb, _ := localDB.Init(env.Default_root_path)
+	var ldb interfaces.ILDB
+
+	if *redisAddress != "" {
+		log.Info("Init redis db")
+		rdb, err := redisDB.Init(*redisAddress, os.Getenv("DEPLOYIT_REDIS_PASSWORD"))
+		if err != nil {
+			log.Fatal(err)
+			return 1
+		}
+		ldb = rdb
+	} else {
+		log.Info("Init local db")
+		ldb, _ = localDB.Init(env.Default_root_path)
+	}
+
+	registries := make(map[string]interfaces.AuthConfig)
+
+	if data, err := ioutil.ReadFile(fmt.Sprintf("%s/registries.yaml", env.Default_root_path)); err == nil {
+		log.Info("Load registries credentials")
+		if err := yaml.Unmarshal(data, &registries); err != nil {
+			log.Fatal(err)
+			return 1
+		}
+	}
 
 	log.Info("Init daemon")
 
+	var readLDB interfaces.ILDB
+
+	if *replicaAddress != "" && *redisAddress != "" {
+		log.Info("Init redis read replica")
+		rdb, err := redisDB.Init(*replicaAddress, os.Getenv("DEPLOYIT_REDIS_PASSWORD"))
+		if err != nil {
+			log.Fatal(err)
+			return 1
+		}
+		readLDB = rdb
+	}
+
 	env := &env.Env{
 		LDB:        ldb,
+		ReadLDB:    readLDB,
 		Log:        log,
 		Containers: &docker.Containers{},
+		Registry:   &registry.Registry{Proxy: proxy},
+		Registries: registries,
+		HostLocked: true,
+		Port:       *port,
+		Profile:    *profile,
+		DNSHost:    *dnsHost,
+
+		AllowPrivilegedMounts: *allowPrivilegedMounts,
+		AllowHostHooks:        *allowHostHooks,
 	}
 
-	cmdFlags.IntVar(&env.Port, "port", 3000, "Daemon port")
-	if c.Debug == false {
-		if os.Getenv("DEPLOYIT_DAEMON_PORT") != "" {
-			env.Port, _ = strconv.Atoi(os.Getenv("DEPLOYIT_DAEMON_PORT"))
+	env.Quota.Memory = *quotaMemory
+	env.Quota.CPUs = *quotaCPUs
+
+	if *maxPulls > 0 {
+		env.Pulls = make(chan struct{}, *maxPulls)
+	}
+
+	env.Scanner = service.NoopScanner{}
+
+	if *upstreamsDir != "" {
+		env.Balancer = &upstream.Files{Dir: *upstreamsDir}
+	}
+
+	if *zoneFile != "" {
+		env.DNS = &dns.ZoneFile{Path: *zoneFile}
+
+		if env.DNSHost == "" {
+			env.DNSHost, _ = os.Hostname()
 		}
 	}
 
+	env.Namer = service.IndexNamer{}
+	if *containerName != "" {
+		env.Namer = service.TemplateNamer{Template: *containerName}
+	}
+
 	log.Info("Context inited")
 
+	if err := service.Migrate(env); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := service.RebuildIndex(env); err != nil {
+		log.Error(err)
+	}
+
+	go func() {
+		if err := service.RestoreAll(env); err != nil {
+			log.Error(err)
+		}
+
+		for range time.Tick(reconcileInterval) {
+			if err := service.ReconcileAll(env); err != nil {
+				log.Error(err)
+			}
+		}
+	}()
+
+	go func() {
+		for range time.Tick(healthInterval) {
+			if err := service.ReportHealthAll(env); err != nil {
+				log.Error(err)
+			}
+		}
+	}()
+
+	go func() {
+		for now := range time.Tick(scheduleInterval) {
+			if err := service.RestartScheduledAll(env, now); err != nil {
+				log.Error(err)
+			}
+		}
+	}()
+
+	if *imageGCKeep >= 0 {
+		go func() {
+			for range time.Tick(imageGCInterval) {
+				if _, err := service.GCImages(env, *imageGCKeep); err != nil {
+					log.Error(err)
+				}
+			}
+		}()
+	}
+
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+		<-signals
+
+		failed := false
+
+		if err := service.ShutdownAll(env, shutdownTimeout); err != nil {
+			log.Error(err)
+			failed = true
+		}
+
+		if err := unlockHost(lock); err != nil {
+			log.Error(err)
+		}
+
+		if failed {
+			os.Exit(1)
+		}
+
+		os.Exit(0)
+	}()
+
 	Route{}.Init(env)
 
 	return 0
 }
 
+// Environment variable or default if it is not set
+func envString(name, def string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+
+	return def
+}
+
+// Numeric environment variable or default if it is not set or invalid
+func envInt64(name string, def int64) int64 {
+	if n, err := strconv.ParseInt(os.Getenv(name), 10, 64); err == nil {
+		return n
+	}
+
+	return def
+}
+
+func envFloat(name string, def float64) float64 {
+	if n, err := strconv.ParseFloat(os.Getenv(name), 64); err == nil {
+		return n
+	}
+
+	return def
+}
+
 func (c *DaemonCommand) Help() string {
 	return ""
 }
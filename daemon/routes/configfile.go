@@ -0,0 +1,65 @@
+package routes
+
+import (
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/daemon/service"
+	"github.com/deployithq/deployit/errors"
+	"github.com/deployithq/deployit/utils"
+	"io/ioutil"
+	"net/http"
+)
+
+func SaveConfigFileHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	e.Log.Debug("Save config file handler ", name)
+
+	content, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		e.Log.Error(err)
+		return errors.InternalServerError()
+	}
+
+	f := service.ConfigFile{
+		Name:    name,
+		Content: content,
+	}
+
+	if err := f.Save(e); err != nil {
+		e.Log.Error(err)
+		return errors.ParamInvalid(`name`)
+	}
+
+	w.Write([]byte(``))
+
+	return nil
+}
+
+func GetConfigFileHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	e.Log.Debug("Get config file handler ", name)
+
+	f := service.ConfigFile{}
+	if err := f.Get(e, name); err != nil {
+		e.Log.Error(err)
+		return errors.Custom(http.StatusNotFound, "CONFIG_FILE_NOT_FOUND")
+	}
+
+	w.Write(f.Content)
+
+	return nil
+}
+
+func RemoveConfigFileHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	e.Log.Debug("Remove config file handler ", name)
+
+	f := service.ConfigFile{Name: name}
+	if err := f.Remove(e); err != nil {
+		e.Log.Error(err)
+		return errors.InternalServerError()
+	}
+
+	w.Write([]byte(``))
+
+	return nil
+}
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/daemon/service"
+	"github.com/deployithq/deployit/errors"
+	"net/http"
+)
+
+func MetricsHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	e.Log.Debug("Metrics handler")
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	if err := service.WriteMetrics(e, w); err != nil {
+		e.Log.Error(err)
+		return errors.InternalServerError()
+	}
+
+	return nil
+}
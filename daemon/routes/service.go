@@ -1,12 +1,20 @@
 package routes
 
 import (
-	"github.com/deployithq/deployit/daemon/service"
+	"encoding/json"
+	"fmt"
 	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/daemon/service"
+	"github.com/deployithq/deployit/drivers/interfaces"
 	"github.com/deployithq/deployit/errors"
 	"github.com/deployithq/deployit/utils"
+	"io"
+	"io/ioutil"
 	"net/http"
-	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
 )
 
 func CreateServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
@@ -22,21 +30,35 @@ func CreateServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) er
 		port, err := s.Ports(e)
 		if err != nil {
 			e.Log.Error(err)
-			return errors.InternalServerError()
+			return serviceError(err)
 		}
 
 		w.Write([]byte(fmt.Sprintf(`{"port":%d}`, port)))
 		return nil
 	}
 
-	if err := s.Create(e, name); err != nil {
+	// Config of request body is used instead of built-in one
+	config := service.Config{}
+	if err := json.NewDecoder(r.Body).Decode(&config); err != nil && err != io.EOF {
+		return errors.InvalidIncomingJSON()
+	}
+
+	if config.Image != `` {
+		created, err := service.CreateWithConfig(e, name, config)
+		if err != nil {
+			e.Log.Error(err)
+			return serviceError(err)
+		}
+
+		s = *created
+	} else if err := s.Create(e, name); err != nil {
 		e.Log.Error(err)
-		return errors.InternalServerError()
+		return serviceError(err)
 	}
 
 	if err := s.Pull(e); err != nil {
 		e.Log.Error(err)
-		return errors.InternalServerError()
+		return serviceError(err)
 	}
 
 	if len(s.Containers) > 0 {
@@ -48,13 +70,13 @@ func CreateServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) er
 
 	if err := s.Start(e); err != nil {
 		e.Log.Error(err)
-		return errors.InternalServerError()
+		return serviceError(err)
 	}
 
 	port, err := s.Ports(e)
 	if err != nil {
 		e.Log.Error(err)
-		return errors.InternalServerError()
+		return serviceError(err)
 	}
 
 	w.Write([]byte(fmt.Sprintf(`{"port":%d}`, port)))
@@ -62,25 +84,127 @@ func CreateServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) er
 	return nil
 }
 
-func StartServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+func DeployServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
 	name := utils.GetStringParamFromURL(`name`, r)
-	e.Log.Debug("Start service handler ", name)
+	e.Log.Debug("Deploy service handler ", name)
+
+	payload := struct {
+		Tag    string   `json:"tag"`
+		Tags   []string `json:"tags"`
+		By     string   `json:"by"`
+		Reason string   `json:"reason"`
+		Key    string   `json:"key"`
+	}{}
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil && err != io.EOF {
+		return errors.InvalidIncomingJSON()
+	}
+
+	if payload.Key == `` {
+		payload.Key = r.Header.Get(`Idempotency-Key`)
+	}
 
 	s := service.Service{}
 	if err := s.Get(e, name); err != nil {
 		e.Log.Error(err)
-		return errors.InternalServerError()
+		return serviceError(err)
 	}
 
-	if err := s.Start(e); err != nil {
+	result, err := s.DoWith(`deploy`, func() error {
+		return s.Deploy(e, payload.Tag, service.Annotation{By: payload.By, Reason: payload.Reason, Key: payload.Key})
+	})
+	if err != nil {
+		return writeResult(e, w, result, err)
+	}
+
+	if len(payload.Tags) > 0 {
+		if err := s.SetTags(e, payload.Tags); err != nil {
+			e.Log.Error(err)
+			return errors.ParamInvalid(`tags`)
+		}
+	}
+
+	port, err := s.Ports(e)
+	if err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	response, err := json.Marshal(struct {
+		*service.Result
+		Port  int64                `json:"port"`
+		Hooks []service.HookResult `json:"hooks,omitempty"`
+	}{result, port, s.HookResults})
+	if err != nil {
 		e.Log.Error(err)
 		return errors.InternalServerError()
 	}
 
+	w.Write(response)
+
+	return nil
+}
+
+func PrepareDeployServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	e.Log.Debug("Prepare deploy service handler ", name)
+
+	payload := struct {
+		Tag string `json:"tag"`
+	}{}
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil && err != io.EOF {
+		return errors.InvalidIncomingJSON()
+	}
+
+	s := service.Service{}
+	if err := s.Get(e, name); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	token, err := s.PrepareDeploy(e, payload.Tag)
+	if err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	w.Write([]byte(fmt.Sprintf(`{"token":%q}`, token)))
+
+	return nil
+}
+
+func ConfirmDeployServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	e.Log.Debug("Confirm deploy service handler ", name)
+
+	payload := struct {
+		Token string `json:"token"`
+	}{}
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		return errors.InvalidIncomingJSON()
+	}
+
+	if payload.Token == `` {
+		return errors.ParamInvalid(`token`)
+	}
+
+	s := service.Service{}
+	if err := s.Get(e, name); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	if err := s.ConfirmDeploy(e, payload.Token); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
 	port, err := s.Ports(e)
 	if err != nil {
 		e.Log.Error(err)
-		return errors.InternalServerError()
+		return serviceError(err)
 	}
 
 	w.Write([]byte(fmt.Sprintf(`{"port":%d}`, port)))
@@ -88,65 +212,211 @@ func StartServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) err
 	return nil
 }
 
+func CancelDeployServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	e.Log.Debug("Cancel deploy service handler ", name)
+
+	payload := struct {
+		ID string `json:"id"`
+	}{}
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil && err != io.EOF {
+		return errors.InvalidIncomingJSON()
+	}
+
+	s := service.Service{}
+	if err := s.Get(e, name); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	if err := s.CancelDeploy(e, payload.ID); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+
+	return nil
+}
+
+func StartServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	e.Log.Debug("Start service handler ", name)
+
+	note := service.Annotation{}
+	if err := json.NewDecoder(r.Body).Decode(&note); err != nil && err != io.EOF {
+		return errors.InvalidIncomingJSON()
+	}
+
+	s := service.Service{}
+	if err := s.Get(e, name); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	result, err := s.DoWith(`start`, func() error {
+		return s.StartWith(e, note)
+	})
+	return writePortResult(e, w, &s, result, err)
+}
+
 func StopServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
 	name := utils.GetStringParamFromURL(`name`, r)
 	e.Log.Debug("Stop service handler ", name)
 
 	s := service.Service{}
 	if err := s.Get(e, name); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	result, err := s.DoWith(`stop`, func() error {
+		return s.Stop(e)
+	})
+	return writeResult(e, w, result, err)
+}
+
+func ListPortsHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	e.Log.Debug("List ports handler")
+
+	reservations, err := service.ListReservations(e)
+	if err != nil {
 		e.Log.Error(err)
 		return errors.InternalServerError()
 	}
 
-	if err := s.Stop(e); err != nil {
+	response, err := json.Marshal(reservations)
+	if err != nil {
 		e.Log.Error(err)
 		return errors.InternalServerError()
 	}
 
-	w.Write([]byte(``))
+	w.Write(response)
 
 	return nil
 }
 
-func RestartServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
-	name := utils.GetStringParamFromURL(`name`, r)
-	e.Log.Debug("Restart service handler ", name)
+// Lists conflicts of stored services with driver, with fix=true they are resolved
+func ConflictsHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	e.Log.Debug("Conflicts handler")
 
-	s := service.Service{}
-	if err := s.Get(e, name); err != nil {
+	fix := r.Method == http.MethodPost && r.URL.Query().Get(`fix`) == `true`
+
+	report, err := service.ResolveConflictsWith(e, fix)
+	if err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	response, err := json.Marshal(report)
+	if err != nil {
+		e.Log.Error(err)
+		return errors.InternalServerError()
+	}
+
+	w.Write(response)
+
+	return nil
+}
+
+func ListServicesHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	e.Log.Debug("List services handler")
+
+	var (
+		summaries []service.Summary
+		err       error
+	)
+
+	// Label selector in key=value format
+	if label := r.URL.Query().Get(`label`); label != `` {
+		parts := strings.SplitN(label, "=", 2)
+		if len(parts) != 2 {
+			return errors.ParamInvalid(`label`)
+		}
+
+		summaries, err = service.ListByLabel(e, parts[0], parts[1])
+	} else if tag := r.URL.Query().Get(`tag`); tag != `` {
+		summaries, err = service.ListByTag(e, tag)
+	} else {
+		summaries, err = service.List(e)
+	}
+
+	if err != nil {
 		e.Log.Error(err)
 		return errors.InternalServerError()
 	}
 
-	if err := s.Restart(e); err != nil {
+	response, err := json.Marshal(summaries)
+	if err != nil {
 		e.Log.Error(err)
 		return errors.InternalServerError()
 	}
 
-	port, err := s.Ports(e)
+	w.Write(response)
+
+	return nil
+}
+
+// Creates services of docker-compose file in request body
+func ImportComposeHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	e.Log.Debug("Import compose handler")
+
+	data, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		e.Log.Error(err)
 		return errors.InternalServerError()
 	}
 
-	w.Write([]byte(fmt.Sprintf(`{"port":%d}`, port)))
+	services, err := service.ImportCompose(e, data)
+	if err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	names := []string{}
+	for _, s := range services {
+		names = append(names, s.Name)
+	}
+
+	response, err := json.Marshal(struct {
+		Services []string `json:"services"`
+	}{names})
+	if err != nil {
+		e.Log.Error(err)
+		return errors.InternalServerError()
+	}
+
+	w.Write(response)
 
 	return nil
 }
 
-func RemoveServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+func SetTagsServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
 	name := utils.GetStringParamFromURL(`name`, r)
-	e.Log.Debug("Remove service handler ", name)
+	e.Log.Debug("Set tags service handler ", name)
+
+	payload := struct {
+		Tags []string `json:"tags"`
+	}{}
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		return errors.InvalidIncomingJSON()
+	}
 
 	s := service.Service{}
 	if err := s.Get(e, name); err != nil {
 		e.Log.Error(err)
-		return errors.InternalServerError()
+		return serviceError(err)
+	}
+
+	if s.UUID == `` {
+		return serviceError(service.ErrServiceNotFound)
 	}
 
-	if err := s.Remove(e); err != nil {
+	if err := s.SetTags(e, payload.Tags); err != nil {
 		e.Log.Error(err)
-		return errors.InternalServerError()
+		return errors.ParamInvalid(`tags`)
 	}
 
 	w.Write([]byte(``))
@@ -154,11 +424,868 @@ func RemoveServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) er
 	return nil
 }
 
-func LogsServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+func StatusServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
 	name := utils.GetStringParamFromURL(`name`, r)
-	e.Log.Debug("Logs service handler ", name)
+	e.Log.Debug("Status service handler ", name)
+
+	s := service.Service{}
+	if err := s.Get(e, name); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	status, err := s.Status(e)
+	if err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	w.Write([]byte(fmt.Sprintf(`{"status":%q}`, status)))
+
+	return nil
+}
+
+func ReloadServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	e.Log.Debug("Reload service handler ", name)
+
+	s := service.Service{}
+	if err := s.Get(e, name); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	if err := s.Reload(e); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	w.Write([]byte(``))
+
+	return nil
+}
+
+func PrePullServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	e.Log.Debug("Pre-pull service handler ", name)
+
+	payload := struct {
+		Image string `json:"image"`
+	}{}
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || payload.Image == `` {
+		return errors.ParamInvalid(`image`)
+	}
+
+	s := service.Service{}
+	if err := s.Get(e, name); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	if err := s.PrePull(e, payload.Image); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
 
 	w.Write([]byte(``))
 
 	return nil
 }
+
+func LoadServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	e.Log.Debug("Load service handler ", name)
+
+	s := service.Service{}
+	if err := s.Get(e, name); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	if err := s.Load(e, r.Body); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	if len(s.Containers) > 0 {
+		if err := s.Remove(e); err != nil {
+			e.Log.Error(err)
+			return errors.InternalServerError()
+		}
+	}
+
+	if err := s.Start(e); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	port, err := s.Ports(e)
+	if err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	w.Write([]byte(fmt.Sprintf(`{"port":%d}`, port)))
+
+	return nil
+}
+
+func RestartServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	e.Log.Debug("Restart service handler ", name)
+
+	s := service.Service{}
+	if err := s.Get(e, name); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	pull := false
+	if value := r.URL.Query().Get(`pull`); value != `` {
+		var err error
+		if pull, err = strconv.ParseBool(value); err != nil {
+			return errors.ParamInvalid(`pull`)
+		}
+	}
+
+	result, err := s.DoWith(`restart`, func() error {
+		return s.RestartWith(e, pull)
+	})
+	return writePortResult(e, w, &s, result, err)
+}
+
+func ScaleServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	e.Log.Debug("Scale service handler ", name)
+
+	payload := struct {
+		Replicas int `json:"replicas"`
+		Step     int `json:"step"`
+		Pause    int `json:"pause"` // seconds
+	}{}
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		return errors.InvalidIncomingJSON()
+	}
+
+	if payload.Replicas < 1 {
+		return errors.ParamInvalid(`replicas`)
+	}
+
+	if payload.Pause < 0 {
+		return errors.ParamInvalid(`pause`)
+	}
+
+	s := service.Service{}
+	if err := s.Get(e, name); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	if err := s.Scale(e, payload.Replicas, payload.Step, time.Duration(payload.Pause)*time.Second); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	w.Write([]byte(``))
+
+	return nil
+}
+
+func CloneServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	e.Log.Debug("Clone service handler ", name)
+
+	payload := struct {
+		Name       string `json:"name"`
+		PortOffset int    `json:"port_offset"`
+	}{}
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		return errors.InvalidIncomingJSON()
+	}
+
+	s := service.Service{}
+	if err := s.Get(e, name); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	clone, err := s.Clone(e, payload.Name, payload.PortOffset)
+	if err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	response, err := json.Marshal(clone.Config.Ports)
+	if err != nil {
+		e.Log.Error(err)
+		return errors.InternalServerError()
+	}
+
+	w.Write([]byte(fmt.Sprintf(`{"ports":%s}`, response)))
+
+	return nil
+}
+
+func RemoveServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	e.Log.Debug("Remove service handler ", name)
+
+	s := service.Service{}
+	if err := s.Get(e, name); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	keepVolumes := r.URL.Query().Get(`keep_volumes`) == `true`
+
+	result, err := s.DoWith(`remove`, func() error {
+		return s.RemoveWith(e, keepVolumes)
+	})
+	return writeResult(e, w, result, err)
+}
+
+func ActionServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	action := utils.GetStringParamFromURL(`action`, r)
+	e.Log.Debug("Action service handler ", name, " ", action)
+
+	s := service.Service{}
+	if err := s.Get(e, name); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	result, err := s.Do(e, action)
+
+	return writeResult(e, w, result, err)
+}
+
+// Write result of action with status of its error, failure of some containers only is
+// reported as multi status
+func writeResult(e *env.Env, w http.ResponseWriter, result *service.Result, actionErr error) error {
+
+	status := http.StatusOK
+
+	if actionErr != nil {
+		e.Log.Error(actionErr)
+		status = resultStatus(result, actionErr)
+	}
+
+	response, err := json.Marshal(result)
+	if err != nil {
+		e.Log.Error(err)
+		return errors.InternalServerError()
+	}
+
+	w.WriteHeader(status)
+	w.Write(response)
+
+	return nil
+}
+
+func resultStatus(result *service.Result, err error) int {
+
+	if failed, ok := err.(service.ContainerErrors); ok {
+		if len(failed) < len(result.Containers) {
+			return http.StatusMultiStatus
+		}
+
+		return http.StatusInternalServerError
+	}
+
+	if status, ok := serviceError(err).(errors.Error); ok {
+		return status.Status()
+	}
+
+	return http.StatusInternalServerError
+}
+
+// Result of action which starts containers with port clients connect to
+func writePortResult(e *env.Env, w http.ResponseWriter, s *service.Service, result *service.Result, actionErr error) error {
+
+	if actionErr != nil {
+		return writeResult(e, w, result, actionErr)
+	}
+
+	port, err := s.Ports(e)
+	if err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	response, err := json.Marshal(struct {
+		*service.Result
+		Port int64 `json:"port"`
+	}{result, port})
+	if err != nil {
+		e.Log.Error(err)
+		return errors.InternalServerError()
+	}
+
+	w.Write(response)
+
+	return nil
+}
+
+func InspectServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	e.Log.Debug("Inspect service handler ", name)
+
+	s := service.Service{}
+	if err := s.Get(e, name); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	inspect := s.Inspect
+	if r.URL.Query().Get(`live`) == `false` {
+		inspect = s.InspectStored
+	}
+
+	report, err := inspect(e)
+	if err != nil {
+		e.Log.Error(err)
+		return errors.InternalServerError()
+	}
+
+	response, err := json.Marshal(report)
+	if err != nil {
+		e.Log.Error(err)
+		return errors.InternalServerError()
+	}
+
+	w.Write(response)
+
+	return nil
+}
+
+// Responds 200 when service is ready to receive traffic and 503 otherwise
+// Proxies health path, query path or readiness probe path, to the first healthy replica
+func HealthServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	e.Log.Debug("Health service handler ", name)
+
+	s := service.Service{}
+	if err := s.View(e, name); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	res, err := s.ServeHealth(e, r.URL.Query().Get(`path`))
+	if err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	if res.ContentType != `` {
+		w.Header().Set(`Content-Type`, res.ContentType)
+	}
+
+	w.Header().Set(`X-Container`, res.Container)
+	w.WriteHeader(res.Status)
+	w.Write(res.Body)
+
+	return nil
+}
+
+func ReadyServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	e.Log.Debug("Ready service handler ", name)
+
+	s := service.Service{}
+	if err := s.View(e, name); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	ready, err := s.Ready(e)
+	if err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	if !ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	response, err := json.Marshal(struct {
+		Ready bool `json:"ready"`
+	}{ready})
+	if err != nil {
+		e.Log.Error(err)
+		return errors.InternalServerError()
+	}
+
+	w.Write(response)
+
+	return nil
+}
+
+func EnvServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	id := utils.GetStringParamFromURL(`container`, r)
+	e.Log.Debug("Env service handler ", name, " ", id)
+
+	s := service.Service{}
+	if err := s.View(e, name); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	vars, err := s.EnvVars(e, id)
+	if err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	response, err := json.Marshal(vars)
+	if err != nil {
+		e.Log.Error(err)
+		return errors.InternalServerError()
+	}
+
+	w.Write(response)
+
+	return nil
+}
+
+func SetEnvServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	e.Log.Debug("Set env service handler ", name)
+
+	vars := make(map[string]string)
+	if err := json.NewDecoder(r.Body).Decode(&vars); err != nil {
+		return errors.InvalidIncomingJSON()
+	}
+
+	s := service.Service{}
+	if err := s.Get(e, name); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	if err := s.SetEnv(e, vars); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	w.Write([]byte(``))
+
+	return nil
+}
+
+func ReplicaServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	e.Log.Debug("Replica service handler ", name)
+
+	index, err := strconv.Atoi(utils.GetStringParamFromURL(`index`, r))
+	if err != nil {
+		return errors.ParamInvalid(`index`)
+	}
+
+	s := service.Service{}
+	if err := s.View(e, name); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	id, err := s.ContainerIDByIndex(e, index)
+	if err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	response, err := json.Marshal(struct {
+		ID string `json:"id"`
+	}{id})
+	if err != nil {
+		e.Log.Error(err)
+		return errors.InternalServerError()
+	}
+
+	w.Write(response)
+
+	return nil
+}
+
+// Runs command in service container streaming its output,
+// exit code is sent in X-Exit-Code trailer
+func ExecServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	id := utils.GetStringParamFromURL(`container`, r)
+	e.Log.Debug("Exec service handler ", name, " ", id)
+
+	opts := interfaces.ExecOptions{}
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		return errors.InvalidIncomingJSON()
+	}
+
+	s := service.Service{}
+	if err := s.Get(e, name); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Trailer", "X-Exit-Code")
+
+	opts.OutputStream, opts.ErrorStream = w, w
+
+	code, err := s.Exec(e, id, opts)
+	if err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	w.Header().Set("X-Exit-Code", strconv.Itoa(code))
+
+	return nil
+}
+
+func ComposeServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	e.Log.Debug("Compose service handler ", name)
+
+	s := service.Service{}
+	if err := s.View(e, name); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	compose, err := s.ExportCompose(e)
+	if err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	w.Header().Set("Content-Type", "application/x-yaml")
+	w.Write(compose)
+
+	return nil
+}
+
+func ChangesServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	id := utils.GetStringParamFromURL(`container`, r)
+	e.Log.Debug("Changes service handler ", name, " ", id)
+
+	s := service.Service{}
+	if err := s.View(e, name); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	changes, err := s.Changes(e, id)
+	if err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	response, err := json.Marshal(changes)
+	if err != nil {
+		e.Log.Error(err)
+		return errors.InternalServerError()
+	}
+
+	w.Write(response)
+
+	return nil
+}
+
+func ContainersServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	e.Log.Debug("Containers service handler ", name)
+
+	query := r.URL.Query()
+
+	limit, offset := 0, 0
+
+	if value := query.Get(`limit`); value != `` {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return errors.ParamInvalid(`limit`)
+		}
+		limit = n
+	}
+
+	if value := query.Get(`offset`); value != `` {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return errors.ParamInvalid(`offset`)
+		}
+		offset = n
+	}
+
+	s := service.Service{}
+	if err := s.Get(e, name); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	page, err := s.ListContainers(e, service.ContainerFilter{State: query.Get(`state`)}, limit, offset)
+	if err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	response, err := json.Marshal(page)
+	if err != nil {
+		e.Log.Error(err)
+		return errors.InternalServerError()
+	}
+
+	w.Write(response)
+
+	return nil
+}
+
+func ConfigServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	e.Log.Debug("Config service handler ", name)
+
+	s := service.Service{}
+	if err := s.View(e, name); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	if s.UUID == "" {
+		return serviceError(service.ErrServiceNotFound)
+	}
+
+	response, err := json.Marshal(s.GetConfig(e))
+	if err != nil {
+		e.Log.Error(err)
+		return errors.InternalServerError()
+	}
+
+	w.Write(response)
+
+	return nil
+}
+
+func SetConfigFieldServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	field := utils.GetStringParamFromURL(`field`, r)
+	e.Log.Debug("Set config field service handler ", name, " ", field)
+
+	var value interface{}
+	if err := json.NewDecoder(r.Body).Decode(&value); err != nil {
+		return errors.InvalidIncomingJSON()
+	}
+
+	s := service.Service{}
+	if err := s.Get(e, name); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	if err := s.SetConfigField(e, field, value); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	w.Write([]byte(``))
+
+	return nil
+}
+
+func LogsServiceHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	e.Log.Debug("Logs service handler ", name)
+
+	opts := service.LogsOptions{}
+
+	query := r.URL.Query()
+
+	var err error
+
+	if opts.Since, err = parseTime(query.Get(`since`)); err != nil {
+		return errors.ParamInvalid(`since`)
+	}
+
+	if opts.Until, err = parseTime(query.Get(`until`)); err != nil {
+		return errors.ParamInvalid(`until`)
+	}
+
+	if tail := query.Get(`tail`); tail != `` {
+		if opts.Tail, err = strconv.Atoi(tail); err != nil || opts.Tail < 0 {
+			return errors.ParamInvalid(`tail`)
+		}
+	}
+
+	if follow := query.Get(`follow`); follow != `` {
+		if opts.Follow, err = strconv.ParseBool(follow); err != nil {
+			return errors.ParamInvalid(`follow`)
+		}
+	}
+
+	// Following stops when client disconnects
+	opts.Context = r.Context()
+
+	// Lines of every container are prefixed with its replica when merged
+	if prefix := query.Get(`prefix`); prefix != `` {
+		if enabled, err := strconv.ParseBool(prefix); err != nil {
+			return errors.ParamInvalid(`prefix`)
+		} else if enabled {
+			opts.Prefix = service.DefaultLogsPrefix
+		}
+	}
+
+	if format := query.Get(`prefix_format`); format != `` {
+		if _, err := template.New(`prefix`).Parse(format); err != nil {
+			return errors.ParamInvalid(`prefix_format`)
+		}
+
+		opts.Prefix = format
+	}
+
+	s := service.Service{}
+	if err := s.View(e, name); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+	var out io.Writer = w
+	if flusher, ok := w.(http.Flusher); ok && opts.Follow {
+		out = flushWriter{w: w, flusher: flusher}
+	}
+
+	if err := s.Logs(e, out, opts); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	return nil
+}
+
+// Sends followed log lines to client as they are written
+type flushWriter struct {
+	w       io.Writer
+	flusher http.Flusher
+}
+
+func (fw flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	fw.flusher.Flush()
+	return n, err
+}
+
+// Accepts RFC3339 time or unix timestamp
+func parseTime(value string) (time.Time, error) {
+
+	if value == `` {
+		return time.Time{}, nil
+	}
+
+	if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(unix, 0), nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
+
+// Map service errors to http errors
+func serviceError(err error) error {
+	if missing, ok := err.(service.MissingEnvError); ok {
+		return errors.Custom(http.StatusBadRequest, "MISSING_REQUIRED_ENV: "+strings.Join(missing, ", "))
+	}
+
+	if _, ok := err.(service.QuotaError); ok {
+		return errors.Custom(http.StatusUnprocessableEntity, "OVER_QUOTA: "+err.Error())
+	}
+
+	if _, ok := err.(service.ComposeError); ok {
+		return errors.Custom(http.StatusBadRequest, "INVALID_COMPOSE: "+err.Error())
+	}
+
+	if _, ok := err.(service.ScanError); ok {
+		return errors.Custom(http.StatusUnprocessableEntity, "IMAGE_SCAN_FAILED: "+err.Error())
+	}
+
+	if invalid, ok := err.(service.ValidationError); ok {
+		return errors.Custom(http.StatusBadRequest, "INVALID_CONFIG: "+strings.TrimPrefix(invalid.Error(), "invalid config: "))
+	}
+
+	switch err {
+	case service.ErrServiceNotFound:
+		return errors.Custom(http.StatusNotFound, "SERVICE_NOT_FOUND")
+	case service.ErrContainerNotFound:
+		return errors.Custom(http.StatusNotFound, "CONTAINER_NOT_FOUND")
+	case service.ErrAlreadyExists:
+		return errors.ParamNotUnique(`name`)
+	case service.ErrInvalidName:
+		return errors.ParamInvalid(`name`)
+	case service.ErrDriverUnavailable:
+		return errors.Custom(http.StatusServiceUnavailable, "DRIVER_UNAVAILABLE")
+	case service.ErrInspectTimeout:
+		return errors.Custom(http.StatusGatewayTimeout, "INSPECT_TIMEOUT")
+	case service.ErrRemoveTimeout:
+		return errors.Custom(http.StatusGatewayTimeout, "REMOVE_TIMEOUT")
+	case service.ErrNoHealthyReplica:
+		return errors.Custom(http.StatusServiceUnavailable, "NO_HEALTHY_REPLICA")
+	case service.ErrPortReserved:
+		return errors.Custom(http.StatusConflict, "PORT_RESERVED")
+	case service.ErrImageNotFound:
+		return errors.Custom(http.StatusBadRequest, "IMAGE_NOT_FOUND")
+	case service.ErrTooManySessions:
+		return errors.Custom(http.StatusTooManyRequests, "TOO_MANY_SESSIONS")
+	case service.ErrInvalidIdempotencyKey:
+		return errors.ParamInvalid(`key`)
+	case service.ErrDeployInProgress:
+		return errors.Custom(http.StatusConflict, "DEPLOY_IN_PROGRESS")
+	case service.ErrNoDeployInProgress:
+		return errors.Custom(http.StatusNotFound, "NO_DEPLOY_IN_PROGRESS")
+	case service.ErrDeployCanceled:
+		return errors.Custom(http.StatusConflict, "DEPLOY_CANCELED")
+	case service.ErrNoPreviousDeploy:
+		return errors.Custom(http.StatusConflict, "NO_PREVIOUS_DEPLOY")
+	case service.ErrDeployTooSoon:
+		return errors.Custom(http.StatusTooManyRequests, "DEPLOY_TOO_SOON")
+	case service.ErrIndexOutOfRange:
+		return errors.ParamInvalid(`index`)
+	case service.ErrUnknownConfigField:
+		return errors.ParamInvalid(`field`)
+	case service.ErrConflict:
+		return errors.Custom(http.StatusConflict, "CONFLICT")
+	case service.ErrHostNotLocked:
+		return errors.Custom(http.StatusServiceUnavailable, "HOST_NOT_LOCKED")
+	case service.ErrInvalidReplicas:
+		return errors.ParamInvalid(`replicas`)
+	case service.ErrCanaryInProgress:
+		return errors.Custom(http.StatusConflict, "CANARY_IN_PROGRESS")
+	case service.ErrNoCanaryInProgress:
+		return errors.Custom(http.StatusNotFound, "NO_CANARY_IN_PROGRESS")
+	case service.ErrPreparedDeployNotFound:
+		return errors.Custom(http.StatusNotFound, "PREPARED_DEPLOY_NOT_FOUND")
+	case service.ErrNotSuspended:
+		return errors.Custom(http.StatusConflict, "NOT_SUSPENDED")
+	case service.ErrDockerSocketForbidden:
+		return errors.Custom(http.StatusForbidden, "DOCKER_SOCKET_FORBIDDEN")
+	case service.ErrHostHooksForbidden:
+		return errors.Custom(http.StatusForbidden, "HOST_HOOKS_FORBIDDEN")
+	case service.ErrImageRequired:
+		return errors.ParamInvalid(`image`)
+	case service.ErrEmptyExecCommand:
+		return errors.ParamInvalid(`cmd`)
+	case service.ErrInvalidLogsRange:
+		return errors.ParamInvalid(`until`)
+	case service.ErrNoLogSink:
+		return errors.Custom(http.StatusNotFound, "NO_LOG_SINK")
+	case service.ErrInvalidConfigFileName:
+		return errors.ParamInvalid(`name`)
+	case service.ErrStackNameRequired:
+		return errors.ParamInvalid(`stack`)
+	case service.ErrNoConfigFile:
+		return errors.Custom(http.StatusConflict, "NO_CONFIG_FILE")
+	case service.ErrUnknownAction:
+		return errors.Custom(http.StatusNotFound, "UNKNOWN_ACTION")
+	}
+
+	return errors.InternalServerError()
+}
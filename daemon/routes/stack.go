@@ -0,0 +1,143 @@
+package routes
+
+import (
+	"encoding/json"
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/daemon/service"
+	"github.com/deployithq/deployit/errors"
+	"github.com/deployithq/deployit/utils"
+	"net/http"
+)
+
+func CreateStackHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	e.Log.Debug("Create stack handler ", name)
+
+	payload := struct {
+		Services []string `json:"services"`
+	}{}
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		return errors.InvalidIncomingJSON()
+	}
+
+	if len(payload.Services) == 0 {
+		return errors.ParamInvalid(`services`)
+	}
+
+	st := service.Stack{
+		Name:     name,
+		Services: payload.Services,
+	}
+
+	if err := st.Save(e); err != nil {
+		e.Log.Error(err)
+		return errors.InternalServerError()
+	}
+
+	w.Write([]byte(``))
+
+	return nil
+}
+
+func UpStackHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	e.Log.Debug("Up stack handler ", name)
+
+	st := service.Stack{}
+	if err := st.Get(e, name); err != nil {
+		e.Log.Error(err)
+		return errors.InternalServerError()
+	}
+
+	if err := st.Up(e); err != nil {
+		e.Log.Error(err)
+		return errors.InternalServerError()
+	}
+
+	w.Write([]byte(``))
+
+	return nil
+}
+
+func DownStackHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	e.Log.Debug("Down stack handler ", name)
+
+	st := service.Stack{}
+	if err := st.Get(e, name); err != nil {
+		e.Log.Error(err)
+		return errors.InternalServerError()
+	}
+
+	if err := st.Down(e); err != nil {
+		e.Log.Error(err)
+		return errors.InternalServerError()
+	}
+
+	w.Write([]byte(``))
+
+	return nil
+}
+
+func DeployStackHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	e.Log.Debug("Deploy stack handler ", name)
+
+	st := service.Stack{}
+	if err := st.Get(e, name); err != nil {
+		e.Log.Error(err)
+		return errors.InternalServerError()
+	}
+
+	if err := st.Deploy(e); err != nil {
+		e.Log.Error(err)
+		return serviceError(err)
+	}
+
+	w.Write([]byte(``))
+
+	return nil
+}
+
+func StatusStackHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	e.Log.Debug("Status stack handler ", name)
+
+	st := service.Stack{}
+	if err := st.Get(e, name); err != nil {
+		e.Log.Error(err)
+		return errors.InternalServerError()
+	}
+
+	status, err := st.Status(e)
+	if err != nil {
+		e.Log.Error(err)
+		return errors.InternalServerError()
+	}
+
+	response, err := json.Marshal(status)
+	if err != nil {
+		e.Log.Error(err)
+		return errors.InternalServerError()
+	}
+
+	w.Write(response)
+
+	return nil
+}
+
+func RemoveStackHandler(e *env.Env, w http.ResponseWriter, r *http.Request) error {
+	name := utils.GetStringParamFromURL(`name`, r)
+	e.Log.Debug("Remove stack handler ", name)
+
+	st := service.Stack{Name: name}
+	if err := st.Remove(e); err != nil {
+		e.Log.Error(err)
+		return errors.InternalServerError()
+	}
+
+	w.Write([]byte(``))
+
+	return nil
+}
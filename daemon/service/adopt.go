@@ -0,0 +1,55 @@
+package service
+
+import (
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+)
+
+// Adopt running containers labeled with service name which service does not track,
+// like ones started by recovery scripts, replicas count grows to keep them
+func (s *Service) adopt(e *env.Env, containers map[string]interfaces.Container) error {
+
+	// Canary containers are tracked by canary, deploys record containers they start
+	if s.Canary != nil || s.Transition != "" || s.Suspended || s.Desired == DesiredStopped {
+		return nil
+	}
+
+	known := make(map[string]bool)
+	for id, container := range s.Containers {
+		known[id] = true
+		for _, sidecar := range container.Sidecars {
+			known[sidecar] = true
+		}
+	}
+
+	adopted := 0
+
+	for id, c := range containers {
+
+		if known[id] || !c.State.Running || c.Config.Labels[LabelService] != s.Name {
+			continue
+		}
+
+		e.Log.Info(`Adopt container `, id, ` into service `, s.Name)
+
+		if s.Containers == nil {
+			s.Containers = make(map[string]*Container)
+		}
+
+		container := &Container{ID: id, Index: s.freeIndex(), Image: c.Config.Image, CreatedAt: c.State.Started}
+		container.refresh(c)
+
+		s.Containers[id] = container
+		adopted++
+	}
+
+	if adopted == 0 {
+		return nil
+	}
+
+	if len(s.Containers) > s.replicas() {
+		s.Replicas = len(s.Containers)
+	}
+
+	return s.Update(e)
+}
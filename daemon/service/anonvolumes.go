@@ -0,0 +1,42 @@
+package service
+
+import (
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"regexp"
+)
+
+// Docker names anonymous volumes with random 64 hex digits
+var anonymousVolume = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// Binds of anonymous volumes of containers by replica index,
+// so recreated replicas get volumes of replicas they replace
+func (s *Service) anonymousVolumes(e *env.Env, containers map[string]*Container) map[int][]string {
+
+	volumes := make(map[int][]string)
+
+	for _, container := range containers {
+
+		c := &interfaces.Container{CID: container.ID}
+		if err := e.Containers.InspectContainer(c); err != nil {
+			e.Log.Error(err)
+			continue
+		}
+
+		for _, mount := range c.Mounts {
+			if mount.Type != interfaces.MountVolume || !anonymousVolume.MatchString(mount.Name) {
+				continue
+			}
+
+			volumes[container.Index] = append(volumes[container.Index], fmt.Sprintf("%s:%s", mount.Name, mount.Destination))
+		}
+	}
+
+	return volumes
+}
+
+// Remove replacing or replaced container, volumes are kept when they are preserved
+func (s *Service) removeReplaced(e *env.Env, container *Container) error {
+	return s.removeReplica(e, container, len(s.preserved) > 0)
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"github.com/satori/go.uuid"
+	"sync"
+	"time"
+)
+
+// ApprovalTimeout is the time prepared deploy waits for confirmation before it is cancelled
+var ApprovalTimeout = 30 * time.Minute
+
+type preparedDeploy struct {
+	service string
+	tag     string
+	digest  string
+	timer   *time.Timer
+}
+
+var prepared = struct {
+	sync.Mutex
+	deploys map[string]*preparedDeploy
+}{deploys: make(map[string]*preparedDeploy)}
+
+// PrepareDeploy resolves and pulls the image of tag without touching containers,
+// returned token should be passed to ConfirmDeploy to proceed
+func (s *Service) PrepareDeploy(e *env.Env, tag string) (string, error) {
+	e.Log.Info(`Prepare deploy of service `, s.Name)
+
+	if s.UUID == "" {
+		return "", ErrServiceNotFound
+	}
+
+	if errs := s.Config.Validate(); len(errs) > 0 {
+		return "", errs
+	}
+
+	if err := s.Config.checkMounts(e); err != nil {
+		return "", err
+	}
+
+	if tag == "" {
+		tag = s.Tag
+	}
+
+	digest, err := e.Registry.Digest(s.Config.Image, tag, s.registryAuth(e, s.Config.Image))
+	if err != nil {
+		e.Log.Error(err)
+		return "", err
+	}
+
+	if err := pullImage(context.Background(), e, interfaces.Image{
+		Name:             fmt.Sprintf("%s@%s", s.Config.Image, digest),
+		Auth:             s.registryAuth(e, s.Config.Image),
+		RequiredPlatform: s.Config.RequiredPlatform,
+	}); err != nil {
+		e.Log.Error(err)
+		return "", err
+	}
+
+	token := uuid.NewV4().String()
+
+	prepared.Lock()
+	defer prepared.Unlock()
+
+	prepared.deploys[token] = &preparedDeploy{
+		service: s.Name,
+		tag:     tag,
+		digest:  digest,
+		timer: time.AfterFunc(ApprovalTimeout, func() {
+			e.Log.Info(`Prepared deploy of service `, s.Name, ` is cancelled by timeout`)
+			CancelDeploy(token)
+		}),
+	}
+
+	return token, nil
+}
+
+// ConfirmDeploy replaces containers with the image prepared by PrepareDeploy
+func (s *Service) ConfirmDeploy(e *env.Env, token string) error {
+	e.Log.Info(`Confirm deploy of service `, s.Name)
+
+	if s.UUID == "" {
+		return ErrServiceNotFound
+	}
+
+	prepared.Lock()
+	p, ok := prepared.deploys[token]
+	if ok && p.service == s.Name {
+		p.timer.Stop()
+		delete(prepared.deploys, token)
+	}
+	prepared.Unlock()
+
+	if !ok || p.service != s.Name {
+		return ErrPreparedDeployNotFound
+	}
+
+	s.beginTransition(e, TransitionDeploying)
+	defer s.endTransition(e)
+
+	started := time.Now()
+	event := s.deployEvent(Annotation{})
+
+	prevTag, prevDigest := s.Tag, s.Digest
+	s.Tag, s.Digest = p.tag, p.digest
+
+	err := s.scanImage(e)
+	if err == nil {
+		err = s.replaceContainers(e)
+	}
+
+	if err != nil {
+		s.Tag, s.Digest = prevTag, prevDigest
+		s.Update(e)
+	} else {
+		s.recordHistory(false)
+		s.Update(e)
+	}
+
+	s.notifyDeploy(e, event, err, time.Since(started))
+
+	return err
+}
+
+// CancelDeploy drops prepared deploy, pulled image is kept
+func CancelDeploy(token string) {
+	prepared.Lock()
+	defer prepared.Unlock()
+
+	if p, ok := prepared.deploys[token]; ok {
+		p.timer.Stop()
+		delete(prepared.deploys, token)
+	}
+}
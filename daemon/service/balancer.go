@@ -0,0 +1,78 @@
+package service
+
+import (
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"sync"
+	"time"
+)
+
+const defaultDrainPeriod = 5
+
+// Containers registered in balancer, only they are drained before stop
+var balanced = struct {
+	sync.Mutex
+	ids map[string]bool
+}{ids: make(map[string]bool)}
+
+func (c *Config) drainPeriod() time.Duration {
+	if c.DrainPeriod > 0 {
+		return seconds(c.DrainPeriod)
+	}
+
+	return seconds(defaultDrainPeriod)
+}
+
+// Register started replica in balancer at its first published port
+func (s *Service) register(e *env.Env, id string) {
+
+	if e.Balancer == nil {
+		return
+	}
+
+	c := &interfaces.Container{CID: id}
+	if err := e.Containers.InspectContainer(c); err != nil {
+		e.Log.Error(err)
+		return
+	}
+
+	for _, port := range c.Ports {
+		if port.Host == 0 {
+			continue
+		}
+
+		if err := e.Balancer.Register(s.Name, id, fmt.Sprintf("127.0.0.1:%d", port.Host)); err != nil {
+			e.Log.Error(err)
+			return
+		}
+
+		balanced.Lock()
+		balanced.ids[id] = true
+		balanced.Unlock()
+
+		return
+	}
+}
+
+// Deregister replica from balancer and wait for in-flight requests to finish
+func (s *Service) drain(e *env.Env, id string) {
+
+	balanced.Lock()
+	registered := balanced.ids[id]
+	delete(balanced.ids, id)
+	balanced.Unlock()
+
+	if e.Balancer == nil || !registered {
+		return
+	}
+
+	e.Log.Info(`Drain container `, id, ` of service `, s.Name)
+
+	if err := e.Balancer.Deregister(s.Name, id); err != nil {
+		e.Log.Error(err)
+		return
+	}
+
+	time.Sleep(s.Config.drainPeriod())
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+)
+
+type Canary struct {
+	Tag        string   `json:"tag" yaml:"tag"`
+	Digest     string   `json:"digest" yaml:"digest"`
+	Containers []string `json:"containers" yaml:"containers"`
+}
+
+func (c *Canary) image(s *Service) string {
+	return fmt.Sprintf("%s@%s", s.Config.Image, c.Digest)
+}
+
+// StartCanary starts count replicas of new tag next to the current ones
+func (s *Service) StartCanary(e *env.Env, tag string, count int) error {
+	e.Log.Info(`Start canary of service `, s.Name)
+
+	if s.UUID == "" {
+		return ErrServiceNotFound
+	}
+
+	if s.Canary != nil {
+		return ErrCanaryInProgress
+	}
+
+	if count < 1 {
+		return ErrInvalidReplicas
+	}
+
+	digest, err := e.Registry.Digest(s.Config.Image, tag, s.registryAuth(e, s.Config.Image))
+	if err != nil {
+		e.Log.Error(err)
+		return err
+	}
+
+	canary := &Canary{Tag: tag, Digest: digest}
+
+	if err := pullImage(context.Background(), e, interfaces.Image{
+		Name:             canary.image(s),
+		Auth:             s.registryAuth(e, s.Config.Image),
+		RequiredPlatform: s.Config.RequiredPlatform,
+	}); err != nil {
+		e.Log.Error(err)
+		return err
+	}
+
+	s.Canary = canary
+
+	for i := 0; i < count; i++ {
+		id, err := s.createContainerFrom(e, canary.image(s))
+		if err != nil {
+			s.Update(e)
+			return err
+		}
+
+		canary.Containers = append(canary.Containers, id)
+	}
+
+	if err := s.Update(e); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Promote replaces remaining old image containers with canary image
+// and makes canary tag the service one
+func (s *Service) Promote(e *env.Env) error {
+	e.Log.Info(`Promote canary of service `, s.Name)
+
+	if s.UUID == "" {
+		return ErrServiceNotFound
+	}
+
+	if s.Canary == nil {
+		return ErrNoCanaryInProgress
+	}
+
+	canary := make(map[string]bool)
+	for _, id := range s.Canary.Containers {
+		canary[id] = true
+	}
+
+	old := []*Container{}
+	for id, container := range s.Containers {
+		if !canary[id] {
+			old = append(old, container)
+		}
+	}
+
+	s.Tag = s.Canary.Tag
+	s.Digest = s.Canary.Digest
+
+	for len(s.Containers)-len(old) < s.replicas() {
+		if _, err := s.createContainer(e); err != nil {
+			s.Update(e)
+			return err
+		}
+	}
+
+	for _, container := range old {
+		if err := s.removeContainer(e, container); err != nil {
+			e.Log.Error(err)
+			if !isNoSuchContainer(err) {
+				s.Update(e)
+				return err
+			}
+		}
+
+		delete(s.Containers, container.ID)
+	}
+
+	s.Canary = nil
+
+	if err := s.Update(e); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"github.com/deployithq/deployit/daemon/env"
+	"sync"
+)
+
+// In-flight deploys of this daemon by service name
+var deploys = struct {
+	sync.Mutex
+	m map[string]*inflight
+}{m: make(map[string]*inflight)}
+
+type inflight struct {
+	id     string
+	cancel chan struct{}
+	once   sync.Once
+}
+
+// Make deploy with current deploy id cancelable, returned func should be called when it ends
+func (s *Service) trackDeploy() func() {
+
+	deploy := &inflight{id: s.DeployID, cancel: make(chan struct{})}
+
+	deploys.Lock()
+	deploys.m[s.Name] = deploy
+	deploys.Unlock()
+
+	s.cancel = deploy.cancel
+
+	return func() {
+		deploys.Lock()
+		if deploys.m[s.Name] == deploy {
+			delete(deploys.m, s.Name)
+		}
+		deploys.Unlock()
+
+		s.cancel = nil
+	}
+}
+
+// CancelDeploy stops in-flight deploy with id, any deploy of service if id is empty.
+// Replicas started by deploy are removed and previous ones are kept running
+func (s *Service) CancelDeploy(e *env.Env, id string) error {
+	e = s.logEnv(e)
+	e.Log.Info(`Cancel deploy of service `, s.Name)
+
+	if s.UUID == "" {
+		return ErrServiceNotFound
+	}
+
+	deploys.Lock()
+	deploy, ok := deploys.m[s.Name]
+	deploys.Unlock()
+
+	if !ok || (id != "" && deploy.id != id) {
+		return ErrNoDeployInProgress
+	}
+
+	deploy.once.Do(func() { close(deploy.cancel) })
+
+	return nil
+}
+
+// ErrDeployCanceled once deploy was canceled
+func (s *Service) canceled() error {
+
+	select {
+	case <-s.cancel:
+		return ErrDeployCanceled
+	default:
+	}
+
+	return nil
+}
@@ -0,0 +1,21 @@
+package service
+
+import (
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+)
+
+// Changes lists files service container wrote to its own filesystem instead of volumes
+func (s *Service) Changes(e *env.Env, containerID string) ([]interfaces.FileChange, error) {
+	e.Log.Debug(`Changes of container `, containerID, ` of service `, s.Name)
+
+	if s.UUID == "" {
+		return nil, ErrServiceNotFound
+	}
+
+	if _, ok := s.Containers[containerID]; !ok {
+		return nil, ErrContainerNotFound
+	}
+
+	return e.Containers.Changes(&interfaces.Container{CID: containerID})
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/satori/go.uuid"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Clone stores stopped copy of service under new name, fixed host ports
+// are shifted by portOffset so clone does not collide with the original
+func (s *Service) Clone(e *env.Env, name string, portOffset int) (*Service, error) {
+	e.Log.Info(`Clone service `, s.Name, ` to `, name)
+
+	if s.UUID == "" {
+		return nil, ErrServiceNotFound
+	}
+
+	if !e.HostLocked {
+		return nil, ErrHostNotLocked
+	}
+
+	if !validName.MatchString(name) {
+		return nil, ErrInvalidName
+	}
+
+	existing := new(Service)
+	if err := e.LDB.Read(storageKey(name), existing); err == nil && existing.UUID != "" {
+		return nil, ErrAlreadyExists
+	}
+
+	// Deep copy, so clone does not share slices and maps with original
+	config := Config{}
+	data, err := json.Marshal(s.Config)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+
+	if config.Ports, err = remapPorts(config.Ports, portOffset); err != nil {
+		return nil, err
+	}
+
+	if errs := config.Validate(); len(errs) > 0 {
+		return nil, errs
+	}
+
+	clone := &Service{
+		UUID:       uuid.NewV4().String(),
+		Name:       name,
+		Tag:        s.Tag,
+		Digest:     s.Digest,
+		Replicas:   s.Replicas,
+		Config:     config,
+		Containers: make(map[string]*Container),
+		Desired:    DesiredStopped,
+		Version:    1,
+		Schema:     schemaVersion,
+	}
+
+	if err := e.LDB.Write(storageKey(name), clone); err != nil {
+		return nil, err
+	}
+
+	indexService(clone)
+
+	return clone, nil
+}
+
+// Shift fixed host ports by offset, shifted ports should be free on host
+func remapPorts(ports []string, offset int) ([]string, error) {
+
+	remapped := []string{}
+	errs := ValidationError{}
+
+	for i, port := range ports {
+
+		parts := strings.Split(port, ":")
+		if offset == 0 || len(parts) < 2 {
+			remapped = append(remapped, port)
+			continue
+		}
+
+		field := fmt.Sprintf("ports[%d]", i)
+
+		host, err := strconv.Atoi(parts[len(parts)-2])
+		if err != nil {
+			errs = append(errs, FieldError{field, fmt.Sprintf("invalid host port %q", port)})
+			continue
+		}
+
+		host += offset
+		if host < 1 || host > 65535 {
+			errs = append(errs, FieldError{field, fmt.Sprintf("host port %d is out of range", host)})
+			continue
+		}
+
+		if !portFree(host) {
+			errs = append(errs, FieldError{field, fmt.Sprintf("host port %d is taken", host)})
+			continue
+		}
+
+		parts[len(parts)-2] = strconv.Itoa(host)
+		remapped = append(remapped, strings.Join(parts, ":"))
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return remapped, nil
+}
+
+func portFree(port int) bool {
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return false
+	}
+
+	listener.Close()
+
+	return true
+}
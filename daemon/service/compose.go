@@ -0,0 +1,214 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"gopkg.in/yaml.v2"
+	"strings"
+)
+
+// Version of docker-compose file format services are exported in
+const composeVersion = `3.7`
+
+type composeFile struct {
+	Version  string                    `yaml:"version"`
+	Services map[string]composeService `yaml:"services"`
+}
+
+type composeService struct {
+	Image        string            `yaml:"image"`
+	Command      []string          `yaml:"command,omitempty"`
+	Hostname     string            `yaml:"hostname,omitempty"`
+	Environment  []string          `yaml:"environment,omitempty"`
+	Ports        []string          `yaml:"ports,omitempty"`
+	Volumes      []string          `yaml:"volumes,omitempty"`
+	Labels       map[string]string `yaml:"labels,omitempty"`
+	CapAdd       []string          `yaml:"cap_add,omitempty"`
+	CapDrop      []string          `yaml:"cap_drop,omitempty"`
+	Sysctls      map[string]string `yaml:"sysctls,omitempty"`
+	SecurityOpt  []string          `yaml:"security_opt,omitempty"`
+	Pid          string            `yaml:"pid,omitempty"`
+	Ipc          string            `yaml:"ipc,omitempty"`
+	CgroupParent string            `yaml:"cgroup_parent,omitempty"`
+	ShmSize      int64             `yaml:"shm_size,omitempty"`
+	Platform     string            `yaml:"platform,omitempty"`
+	Restart      string            `yaml:"restart,omitempty"`
+	StopGrace    string            `yaml:"stop_grace_period,omitempty"`
+	DependsOn    []string          `yaml:"depends_on,omitempty"`
+	Healthcheck  *composeHealth    `yaml:"healthcheck,omitempty"`
+	Deploy       composeDeploy     `yaml:"deploy"`
+}
+
+type composeHealth struct {
+	Test     []string `yaml:"test"`
+	Interval string   `yaml:"interval,omitempty"`
+	Timeout  string   `yaml:"timeout,omitempty"`
+}
+
+type composeDeploy struct {
+	Replicas  int              `yaml:"replicas"`
+	Resources composeResources `yaml:"resources,omitempty"`
+}
+
+type composeResources struct {
+	Limits composeLimits `yaml:"limits,omitempty"`
+}
+
+type composeLimits struct {
+	CPUs   string `yaml:"cpus,omitempty"`
+	Memory string `yaml:"memory,omitempty"`
+}
+
+// ExportCompose renders service config as docker-compose file with single service,
+// config fields compose has no equivalent of are listed in comment on top of it
+func (s *Service) ExportCompose(e *env.Env) ([]byte, error) {
+	e.Log.Info(`Export compose of service `, s.Name)
+
+	if s.UUID == "" {
+		return nil, ErrServiceNotFound
+	}
+
+	c := s.Config
+
+	service := composeService{
+		Image:        s.image(),
+		Command:      c.CMD,
+		Hostname:     c.Hostname,
+		Environment:  c.maskEnv(c.Env),
+		Ports:        c.Ports,
+		Volumes:      append(append([]string{}, c.Volumes...), c.Secrets...),
+		Labels:       c.Labels,
+		CapAdd:       c.CapAdd,
+		CapDrop:      c.CapDrop,
+		Sysctls:      c.Sysctls,
+		SecurityOpt:  c.SecurityOpt,
+		Pid:          c.PidMode,
+		Ipc:          c.IpcMode,
+		CgroupParent: c.CgroupParent,
+		ShmSize:      int64(c.ShmSize),
+		Platform:     c.RequiredPlatform,
+		Restart:      c.composeRestart(),
+		DependsOn:    c.DependsOn,
+		Healthcheck:  c.ReadinessProbe.composeHealth(),
+		Deploy:       composeDeploy{Replicas: s.replicas()},
+	}
+
+	if c.StopGracePeriod > 0 {
+		service.StopGrace = fmt.Sprintf("%ds", c.StopGracePeriod)
+	}
+
+	if c.Memory > 0 {
+		service.Deploy.Resources.Limits.Memory = fmt.Sprintf("%dM", c.Memory)
+	}
+
+	if c.CPUs > 0 {
+		service.Deploy.Resources.Limits.CPUs = fmt.Sprint(c.CPUs)
+	}
+
+	body, err := yaml.Marshal(composeFile{
+		Version:  composeVersion,
+		Services: map[string]composeService{s.Name: service},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bytes.Buffer{}
+
+	if skipped := c.notComposable(); len(skipped) > 0 {
+		buf.WriteString("# Not exported, compose has no equivalent: " + strings.Join(skipped, ", ") + "\n")
+	}
+
+	for i, variable := range service.Environment {
+		if variable != c.Env[i] {
+			buf.WriteString("# Secret env values are masked\n")
+			break
+		}
+	}
+
+	buf.Write(body)
+
+	return buf.Bytes(), nil
+}
+
+func (c *Config) composeRestart() string {
+
+	switch {
+	case c.AutoRemove:
+		return restartNo
+	case c.RestartPolicy == nil:
+		return restartAlways
+	case c.RestartPolicy.Name == restartOnFailure && c.RestartPolicy.MaxRetries > 0:
+		return fmt.Sprintf("%s:%d", restartOnFailure, c.RestartPolicy.MaxRetries)
+	}
+
+	return c.RestartPolicy.Name
+}
+
+// Readiness probe as healthcheck, curl and nc should be in image for it to pass
+func (p *Probe) composeHealth() *composeHealth {
+
+	if p == nil || p.Port == 0 {
+		return nil
+	}
+
+	health := &composeHealth{
+		Test: []string{"CMD", "nc", "-z", "localhost", fmt.Sprint(p.Port)},
+	}
+
+	if p.Type == probeHTTP {
+		health.Test = []string{"CMD", "curl", "-fs", fmt.Sprintf("http://localhost:%d%s", p.Port, p.Path)}
+	}
+
+	if p.Interval > 0 {
+		health.Interval = fmt.Sprintf("%ds", p.Interval)
+	}
+
+	if p.Timeout > 0 {
+		health.Timeout = fmt.Sprintf("%ds", p.Timeout)
+	}
+
+	return health
+}
+
+// Config fields which are set but can not be expressed in compose
+func (c *Config) notComposable() []string {
+
+	fields := []struct {
+		name string
+		set  bool
+	}{
+		{`blkio_weight`, c.BlkioWeight > 0},
+		{`blkio_device_read_bps`, len(c.BlkioDeviceReadBps) > 0},
+		{`blkio_device_write_bps`, len(c.BlkioDeviceWriteBps) > 0},
+		{`image_tarball`, c.ImageTarball != ""},
+		{`registry_auth`, c.RegistryAuth != nil},
+		{`placement`, len(c.Placement.Constraints) > 0},
+		{`source`, c.Source != nil},
+		{`sidecars`, len(c.Sidecars) > 0},
+		{`init_containers`, len(c.InitContainers) > 0},
+		{`config_files`, len(c.ConfigFiles) > 0},
+		{`webhooks`, len(c.Webhooks.OnSuccess)+len(c.Webhooks.OnFailure) > 0},
+		{`pre_deploy_host`, len(c.PreDeployHost) > 0},
+		{`post_deploy_host`, len(c.PostDeployHost) > 0},
+		{`log_sink`, c.LogSink != nil},
+		{`restart_schedule`, c.RestartSchedule != ""},
+		{`restart_jitter`, c.RestartJitter > 0},
+		{`restart_on_unhealthy`, c.RestartOnUnhealthy},
+		{`reload_signal`, c.ReloadSignal != ""},
+		{`stop_escalation`, len(c.StopEscalation) > 0},
+		{`scan_threshold`, c.ScanThreshold != ""},
+		{`profiles`, len(c.Profiles) > 0},
+		{`restart_policy.exit_codes`, c.RestartPolicy != nil && len(c.RestartPolicy.ExitCodes) > 0},
+	}
+
+	skipped := []string{}
+	for _, field := range fields {
+		if field.set {
+			skipped = append(skipped, field.name)
+		}
+	}
+
+	return skipped
+}
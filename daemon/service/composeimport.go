@@ -0,0 +1,276 @@
+package service
+
+import (
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"gopkg.in/yaml.v2"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Compose service keys mapped to service config, others are skipped with warning
+var composeKeys = map[string]bool{
+	`image`: true, `command`: true, `hostname`: true, `environment`: true, `ports`: true,
+	`volumes`: true, `labels`: true, `cap_add`: true, `cap_drop`: true, `sysctls`: true,
+	`security_opt`: true, `pid`: true, `ipc`: true, `cgroup_parent`: true, `shm_size`: true,
+	`platform`: true, `restart`: true, `stop_grace_period`: true, `depends_on`: true, `deploy`: true,
+}
+
+var composeDeployKeys = map[string]bool{`replicas`: true, `resources`: true}
+
+// Compose value given either as list or as map, maps are converted to key=value items
+type composeList []string
+
+func (l *composeList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+
+	list := []string{}
+	if err := unmarshal(&list); err == nil {
+		*l = list
+		return nil
+	}
+
+	values := map[string]interface{}{}
+	if err := unmarshal(&values); err != nil {
+		return err
+	}
+
+	for key, value := range values {
+		if value == nil {
+			list = append(list, key)
+			continue
+		}
+
+		list = append(list, fmt.Sprintf("%s=%v", key, value))
+	}
+
+	sort.Strings(list)
+	*l = list
+
+	return nil
+}
+
+// Map of key=value items, form of labels and sysctls
+func (l composeList) toMap() map[string]string {
+
+	if len(l) == 0 {
+		return nil
+	}
+
+	values := make(map[string]string)
+	for _, item := range l {
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) == 1 {
+			parts = append(parts, "")
+		}
+
+		values[parts[0]] = parts[1]
+	}
+
+	return values
+}
+
+// Compose command given either as shell string or as list
+type composeCommand []string
+
+func (c *composeCommand) UnmarshalYAML(unmarshal func(interface{}) error) error {
+
+	var command string
+	if err := unmarshal(&command); err == nil {
+		*c = strings.Fields(command)
+		return nil
+	}
+
+	list := []string{}
+	if err := unmarshal(&list); err != nil {
+		return err
+	}
+
+	*c = list
+
+	return nil
+}
+
+type composeImport struct {
+	Image           string         `yaml:"image"`
+	Command         composeCommand `yaml:"command"`
+	Hostname        string         `yaml:"hostname"`
+	Environment     composeList    `yaml:"environment"`
+	Ports           []string       `yaml:"ports"`
+	Volumes         []string       `yaml:"volumes"`
+	Labels          composeList    `yaml:"labels"`
+	CapAdd          []string       `yaml:"cap_add"`
+	CapDrop         []string       `yaml:"cap_drop"`
+	Sysctls         composeList    `yaml:"sysctls"`
+	SecurityOpt     []string       `yaml:"security_opt"`
+	Pid             string         `yaml:"pid"`
+	Ipc             string         `yaml:"ipc"`
+	CgroupParent    string         `yaml:"cgroup_parent"`
+	ShmSize         ByteSize       `yaml:"shm_size"`
+	Platform        string         `yaml:"platform"`
+	Restart         string         `yaml:"restart"`
+	StopGracePeriod string         `yaml:"stop_grace_period"`
+	DependsOn       composeList    `yaml:"depends_on"`
+	Deploy          struct {
+		Replicas  int `yaml:"replicas"`
+		Resources struct {
+			Limits struct {
+				CPUs   string   `yaml:"cpus"`
+				Memory ByteSize `yaml:"memory"`
+			} `yaml:"limits"`
+		} `yaml:"resources"`
+	} `yaml:"deploy"`
+}
+
+func (c *composeImport) config() (Config, error) {
+
+	config := Config{
+		Image:            c.Image,
+		CMD:              c.Command,
+		Hostname:         c.Hostname,
+		Env:              c.Environment,
+		Ports:            c.Ports,
+		Volumes:          c.Volumes,
+		Labels:           c.Labels.toMap(),
+		CapAdd:           c.CapAdd,
+		CapDrop:          c.CapDrop,
+		Sysctls:          c.Sysctls.toMap(),
+		SecurityOpt:      c.SecurityOpt,
+		PidMode:          c.Pid,
+		IpcMode:          c.Ipc,
+		CgroupParent:     c.CgroupParent,
+		ShmSize:          c.ShmSize,
+		RequiredPlatform: c.Platform,
+		Memory:           int64(c.Deploy.Resources.Limits.Memory) / (1 << 20),
+	}
+
+	// Long form of depends_on is map of service names to conditions
+	for _, dependency := range c.DependsOn {
+		config.DependsOn = append(config.DependsOn, strings.SplitN(dependency, "=", 2)[0])
+	}
+
+	if c.Restart != "" {
+		parts := strings.SplitN(c.Restart, ":", 2)
+		config.RestartPolicy = &RestartPolicy{Name: parts[0]}
+
+		if len(parts) == 2 {
+			retries, err := strconv.Atoi(parts[1])
+			if err != nil {
+				return config, fmt.Errorf("invalid restart %q", c.Restart)
+			}
+
+			config.RestartPolicy.MaxRetries = retries
+		}
+	}
+
+	if cpus := c.Deploy.Resources.Limits.CPUs; cpus != "" {
+		n, err := strconv.ParseFloat(cpus, 64)
+		if err != nil {
+			return config, fmt.Errorf("invalid deploy.resources.limits.cpus %q", cpus)
+		}
+
+		config.CPUs = n
+	}
+
+	if c.StopGracePeriod != "" {
+		period, err := time.ParseDuration(c.StopGracePeriod)
+		if err != nil {
+			return config, fmt.Errorf("invalid stop_grace_period %q", c.StopGracePeriod)
+		}
+
+		config.StopGracePeriod = int(period.Seconds())
+	}
+
+	return config, nil
+}
+
+// ImportCompose creates stopped service for every service of docker-compose file,
+// services are created in name order and ones created before failure are returned with error
+func ImportCompose(e *env.Env, data []byte) ([]*Service, error) {
+	e.Log.Info(`Import compose file`)
+
+	file := struct {
+		Services map[string]composeImport `yaml:"services"`
+	}{}
+
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, ComposeError(err.Error())
+	}
+
+	if len(file.Services) == 0 {
+		return nil, ComposeError("compose file has no services")
+	}
+
+	raw := struct {
+		Services map[string]map[string]interface{} `yaml:"services"`
+	}{}
+
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, ComposeError(err.Error())
+	}
+
+	names := []string{}
+	for name := range file.Services {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	services := []*Service{}
+
+	for _, name := range names {
+
+		warnComposeKeys(e, name, raw.Services[name])
+
+		compose := file.Services[name]
+		if compose.Image == "" {
+			return services, ComposeError(fmt.Sprintf("compose service %s: image is required, build is not supported", name))
+		}
+
+		config, err := compose.config()
+		if err != nil {
+			return services, ComposeError(fmt.Sprintf("compose service %s: %s", name, err))
+		}
+
+		s, err := CreateWithConfig(e, name, config)
+		if err != nil {
+			return services, err
+		}
+
+		if compose.Deploy.Replicas > 1 {
+			if err := s.SetReplicas(e, compose.Deploy.Replicas); err != nil {
+				return services, err
+			}
+		}
+
+		services = append(services, s)
+	}
+
+	return services, nil
+}
+
+func warnComposeKeys(e *env.Env, name string, keys map[string]interface{}) {
+
+	for key, value := range keys {
+		if !composeKeys[key] {
+			e.Log.Info(`Compose key `, key, ` of service `, name, ` is not supported, skipped`)
+			continue
+		}
+
+		if key != `deploy` {
+			continue
+		}
+
+		deploy, ok := value.(map[interface{}]interface{})
+		if !ok {
+			continue
+		}
+
+		for key := range deploy {
+			if !composeDeployKeys[fmt.Sprint(key)] {
+				e.Log.Info(`Compose key deploy.`, key, ` of service `, name, ` is not supported, skipped`)
+			}
+		}
+	}
+}
@@ -1,19 +1,174 @@
 package service
 
 import (
-	"github.com/deployithq/deployit/daemon/env"
 	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"time"
 )
 
 type Config struct {
-	Env     []string  `json:"env" yaml:"env"`
-	Ports   []string  `json:"ports" yaml:"ports"`
-	Volumes []string  `json:"volumes" yaml:"volumes"`
-	CMD     []string  `json:"cmd" yaml:"cmd"`
-	Memory  int64     `json:"memory" yaml:"memory"`
-	Image   string    `json:"image" yaml:"image"`
+	Env     []string          `json:"env" yaml:"env"`
+	Ports   []string          `json:"ports" yaml:"ports"`
+	Volumes []string          `json:"volumes" yaml:"volumes"`
+	CMD     []string          `json:"cmd" yaml:"cmd"`
+	Memory  int64             `json:"memory" yaml:"memory"`
+	CPUs    float64           `json:"cpus" yaml:"cpus"`
+	Image   string            `json:"image" yaml:"image"`
+	CapAdd  []string          `json:"cap_add" yaml:"cap_add"`
+	CapDrop []string          `json:"cap_drop" yaml:"cap_drop"`
+	Sysctls map[string]string `json:"sysctls" yaml:"sysctls"`
+
+	// Size of /dev/shm, like 1g, docker default 64m is used if empty
+	ShmSize ByteSize `json:"shm_size" yaml:"shm_size"`
+
+	// Block IO weight 10-1000 and per device bytes per second limits
+	BlkioWeight         int64                   `json:"blkio_weight" yaml:"blkio_weight"`
+	BlkioDeviceReadBps  []interfaces.BlockLimit `json:"blkio_device_read_bps" yaml:"blkio_device_read_bps"`
+	BlkioDeviceWriteBps []interfaces.BlockLimit `json:"blkio_device_write_bps" yaml:"blkio_device_write_bps"`
+
+	// Container hostname, replica index is available as {{.Index}}
+	Hostname string `json:"hostname" yaml:"hostname"`
+
+	// Platform like linux/arm64 pulled image must be built for, deploy fails if it is not.
+	// Docker pulls image variant of its host platform, other variants are not selected
+	RequiredPlatform string `json:"required_platform" yaml:"required_platform"`
+
+	// Former name of RequiredPlatform, only read from records stored before schema 3
+	Platform string `json:"platform,omitempty" yaml:"platform,omitempty"`
+
+	// Credentials of image registry, daemon registries credentials are used if empty
+	RegistryAuth *RegistryAuth `json:"registry_auth" yaml:"registry_auth"`
+
+	// Host path of image tarball in docker save format, image is loaded from it instead of pulled
+	ImageTarball string `json:"image_tarball" yaml:"image_tarball"`
+
+	// Namespaces sharing, host or container:<id>
+	PidMode string `json:"pid_mode" yaml:"pid_mode"`
+	IpcMode string `json:"ipc_mode" yaml:"ipc_mode"`
+
+	// Cgroup containers are placed under, like /tenants/acme or acme.slice with systemd driver
+	CgroupParent string `json:"cgroup_parent" yaml:"cgroup_parent"`
+
+	// Security options like seccomp=/path/profile.json or apparmor=profile
+	SecurityOpt []string `json:"security_opt" yaml:"security_opt"`
+
+	Placement interfaces.Placement `json:"placement" yaml:"placement"`
+
+	// Labels services are selected by, also set on containers
+	Labels map[string]string `json:"labels" yaml:"labels,omitempty"`
+
+	// Build image from git repository on deploy instead of pulling it
+	Source *Source `json:"source,omitempty" yaml:"source,omitempty"`
+
+	Sidecars []Sidecar `json:"sidecars" yaml:"sidecars"`
+
+	// Run to completion in order before main containers are started
+	InitContainers []ContainerSpec `json:"init_containers" yaml:"init_containers"`
+
+	// Replicas which should pass readiness for service to be ready, all if not set
+	MinReady int `json:"min_ready" yaml:"min_ready"`
+
+	// Env key patterns masked on inspection, like "*_PASSWORD"
+	SecretEnv []string `json:"secret_env" yaml:"secret_env,omitempty"`
+
+	// Env variables service can not run without, checked on create and deploy
+	RequiredEnv []string `json:"required_env" yaml:"required_env"`
+
+	// Secret or config files mounted read-only as host:container,
+	// service is reloaded when their content changes
+	Secrets []string `json:"secrets" yaml:"secrets"`
+
+	// Config files stored in daemon mounted read-only as name:container
+	ConfigFiles []string `json:"config_files" yaml:"config_files"`
+
+	// Names of services which should be started before this one
+	DependsOn []string `json:"depends_on" yaml:"depends_on"`
+
+	ReadinessProbe *Probe `json:"readiness_probe,omitempty" yaml:"readiness_probe,omitempty"`
+
+	// Restart running containers failing readiness probe for unhealthy timeout seconds, 60 if empty
+	RestartOnUnhealthy bool `json:"restart_on_unhealthy" yaml:"restart_on_unhealthy"`
+	UnhealthyTimeout   int  `json:"unhealthy_timeout" yaml:"unhealthy_timeout"`
+
+	Webhooks Webhooks `json:"webhooks" yaml:"webhooks"`
+
+	// Mount docker socket read-only, requires privileged mounts allowed by daemon
+	DockerSocket bool `json:"docker_socket" yaml:"docker_socket"`
+
+	Timeouts Timeouts `json:"timeouts" yaml:"timeouts"`
+
+	// Commands run on daemon host before and after deploy, like reload of host proxy,
+	// failed pre deploy hook aborts deploy. Requires host hooks allowed by daemon
+	PreDeployHost  []string `json:"pre_deploy_host" yaml:"pre_deploy_host,omitempty"`
+	PostDeployHost []string `json:"post_deploy_host" yaml:"post_deploy_host,omitempty"`
+
+	LogSink *LogSink `json:"log_sink,omitempty" yaml:"log_sink,omitempty"`
+
+	// Replicas started by Start, 1 if empty. Replicas set by scaling override it until it is changed
+	Scale int `json:"scale" yaml:"scale"`
+
+	// Seconds driver waits for graceful stop before container is killed, 30 if empty
+	StopGracePeriod int `json:"stop_grace_period" yaml:"stop_grace_period"`
+
+	// Signals sent in order to stop container instead of driver stop, it is killed
+	// when it is still running after the last step. Grace period is not used with it
+	StopEscalation []StopStep `json:"stop_escalation" yaml:"stop_escalation,omitempty"`
+
+	// Seconds in-flight requests are waited for after replica is deregistered from balancer, 5 if empty
+	DrainPeriod int `json:"drain_period" yaml:"drain_period"`
+
+	// Concurrent exec sessions allowed into service containers, unlimited if empty
+	MaxExecSessions int `json:"max_exec_sessions" yaml:"max_exec_sessions"`
+
+	// Seconds which should pass between deploys of service, not limited if 0
+	MinDeployInterval int `json:"min_deploy_interval" yaml:"min_deploy_interval"`
+
+	// Signal like SIGHUP containers reload config on, service is restarted instead if empty
+	ReloadSignal string `json:"reload_signal" yaml:"reload_signal,omitempty"`
+
+	// Deploy is aborted if daemon scanner finds vulnerabilities of this severity
+	// or higher: low, medium, high or critical. Images are not scanned if empty
+	ScanThreshold string `json:"scan_threshold" yaml:"scan_threshold,omitempty"`
+
+	// Single replica is restarted by starting its replacement first, in place if it fails
+	SurgeRestart bool `json:"surge_restart" yaml:"surge_restart"`
+
+	// Cron expression of periodic rolling restart, like "0 3 * * *"
+	RestartSchedule string `json:"restart_schedule" yaml:"restart_schedule,omitempty"`
+
+	// Seconds of window scheduled restart is randomly delayed within,
+	// replicas of rolling restart are spread over it too. Restarts are not delayed if 0
+	RestartJitter int `json:"restart_jitter" yaml:"restart_jitter"`
+
+	// Driver restart policy, containers are always restarted if it is not set
+	RestartPolicy *RestartPolicy `json:"restart_policy,omitempty" yaml:"restart_policy,omitempty"`
+
+	// Anonymous volumes of containers are attached to containers recreating them
+	// instead of being removed, so data like warm cache survives config changes
+	PreserveAnonymousVolumes bool `json:"preserve_anonymous_volumes" yaml:"preserve_anonymous_volumes"`
+
+	// Containers of one-shot jobs are removed by driver when they exit
+	AutoRemove bool `json:"auto_remove" yaml:"auto_remove"`
+
+	// Log level of daemon operations with service, passed to containers as DEPLOYIT_LOG_LEVEL
+	LogLevel string `json:"log_level" yaml:"log_level,omitempty"`
+
+	// Resources of daemon profiles like dev or prod, base memory, cpus and replicas
+	// are used on daemons without profile or with profile not listed here
+	Profiles map[string]Resources `json:"profiles" yaml:"profiles,omitempty"`
+
+	// Profile resources were sized for, set when profile is applied
+	Profile string `json:"profile" yaml:"profile,omitempty"`
 }
 
+const defaultStopGracePeriod = 30
+
+// Time driver is given to kill container after grace period before it is killed by daemon
+const stopKillMargin = 5 * time.Second
+
+const dockerSocket = `/var/run/docker.sock`
+
 var configs map[string]*Config
 
 func init() {
@@ -59,5 +214,31 @@ func (c *Config) Get(e *env.Env, name string) error {
 		*c = *val
 	}
 
+	c.applyProfile(e.Profile)
+
+	return nil
+}
+
+// Docker socket grants control over the host, reject it unless daemon allows it
+func (c *Config) checkMounts(e *env.Env) error {
+
+	if !c.DockerSocket {
+		return nil
+	}
+
+	if !e.AllowPrivilegedMounts {
+		return ErrDockerSocketForbidden
+	}
+
+	e.Log.Info(`Warning: docker socket is mounted into service, it grants control over the host`)
+
 	return nil
 }
+
+func (c *Config) stopGracePeriod() time.Duration {
+	if c.StopGracePeriod <= 0 {
+		return defaultStopGracePeriod * time.Second
+	}
+
+	return time.Duration(c.StopGracePeriod) * time.Second
+}
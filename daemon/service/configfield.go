@@ -0,0 +1,97 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/deployithq/deployit/daemon/env"
+	"reflect"
+	"strings"
+)
+
+var ErrUnknownConfigField = errors.New("unknown config field")
+
+// Attempts to apply field edit when service is changed concurrently
+const configFieldRetries = 3
+
+// GetConfig returns current config of service
+func (s *Service) GetConfig(e *env.Env) Config {
+	return s.Config
+}
+
+// SetConfigField validates and stores a single config field named by its json name,
+// the field is applied to the latest stored config so concurrent edits of other fields are kept
+func (s *Service) SetConfigField(e *env.Env, field string, value interface{}) error {
+	e.Log.Info(`Set config field `, field, ` of service `, s.Name)
+
+	if s.UUID == "" {
+		return ErrServiceNotFound
+	}
+
+	if !configField(field) {
+		return ErrUnknownConfigField
+	}
+
+	var (
+		config *Config
+		err    error
+	)
+
+	for i := 0; i < configFieldRetries; i++ {
+
+		if err = s.Get(e, s.Name); err != nil {
+			return err
+		}
+
+		if config, err = setConfigField(s.Config, field, value); err != nil {
+			return err
+		}
+
+		err = s.UpdateConfig(e, config)
+		if err != ErrConflict {
+			return err
+		}
+	}
+
+	return err
+}
+
+// Field with given json name exists in Config
+func configField(name string) bool {
+
+	t := reflect.TypeOf(Config{})
+
+	for i := 0; i < t.NumField(); i++ {
+		if strings.Split(t.Field(i).Tag.Get(`json`), `,`)[0] == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Copy of config with field replaced by value, value is converted through json
+func setConfigField(config Config, field string, value interface{}) (*Config, error) {
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	fields[field] = value
+
+	if data, err = json.Marshal(fields); err != nil {
+		return nil, err
+	}
+
+	updated := new(Config)
+	if err := json.Unmarshal(data, updated); err != nil {
+		return nil, ValidationError{FieldError{field, err.Error()}}
+	}
+
+	return updated, nil
+}
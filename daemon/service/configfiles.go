@@ -0,0 +1,103 @@
+package service
+
+import (
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const configFilePrefix = `configfiles`
+
+// Directory config files are written to before they are mounted into containers
+var ConfigFilesPath = filepath.Join(os.TempDir(), "deployit", "configfiles")
+
+// ConfigFile is file content stored in db and mounted into service containers
+type ConfigFile struct {
+	Name    string `json:"name" yaml:"name"`
+	Content []byte `json:"content" yaml:"content"`
+}
+
+func configFileKey(name string) string {
+	return fmt.Sprintf("%s/%s", configFilePrefix, name)
+}
+
+func (f *ConfigFile) Get(e *env.Env, name string) error {
+	e.Log.Info(`Get config file `, name)
+
+	if err := e.LDB.Read(configFileKey(name), f); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (f *ConfigFile) Save(e *env.Env) error {
+	e.Log.Info(`Save config file `, f.Name)
+
+	if !validName.MatchString(f.Name) {
+		return ErrInvalidConfigFileName
+	}
+
+	if err := e.LDB.Write(configFileKey(f.Name), f); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (f *ConfigFile) Remove(e *env.Env) error {
+	e.Log.Info(`Remove config file `, f.Name)
+
+	if err := e.LDB.Remove(configFileKey(f.Name)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Service) configFilesDir() string {
+	return filepath.Join(ConfigFilesPath, s.Name)
+}
+
+// Write config files referenced by service and return read-only binds of them
+func (s *Service) writeConfigFiles(e *env.Env) ([]string, error) {
+
+	binds := []string{}
+
+	if len(s.Config.ConfigFiles) == 0 {
+		return binds, nil
+	}
+
+	dir := s.configFilesDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	for _, mount := range s.Config.ConfigFiles {
+
+		parts := strings.SplitN(mount, ":", 2)
+
+		file := ConfigFile{}
+		if err := file.Get(e, parts[0]); err != nil {
+			return nil, fmt.Errorf("config file %q: %s", parts[0], err)
+		}
+
+		path := filepath.Join(dir, file.Name)
+		if err := ioutil.WriteFile(path, file.Content, 0644); err != nil {
+			return nil, err
+		}
+
+		binds = append(binds, fmt.Sprintf("%s:%s:ro", path, parts[1]))
+	}
+
+	return binds, nil
+}
+
+func (s *Service) removeConfigFiles(e *env.Env) {
+	if err := os.RemoveAll(s.configFilesDir()); err != nil {
+		e.Log.Error(err)
+	}
+}
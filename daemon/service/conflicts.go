@@ -0,0 +1,200 @@
+package service
+
+import (
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"sort"
+	"time"
+)
+
+// Kinds of conflicts between stored records and driver
+const (
+	ConflictGhost    = `ghost`
+	ConflictOrphan   = `orphan`
+	ConflictMismatch = `mismatch`
+)
+
+// Conflict is container stored records and containers driver disagree about
+type Conflict struct {
+	Kind      string `json:"kind"`
+	Service   string `json:"service"`
+	Container string `json:"container"`
+	Detail    string `json:"detail,omitempty"`
+	Fixed     bool   `json:"fixed"`
+}
+
+// ConflictReport lists ghosts recorded in db which driver does not have, orphans
+// labeled with service name which no service tracks, and mismatches whose records
+// and driver state differ
+type ConflictReport struct {
+	Ghosts     []Conflict `json:"ghosts"`
+	Orphans    []Conflict `json:"orphans"`
+	Mismatches []Conflict `json:"mismatches"`
+	Checked    time.Time  `json:"checked"`
+}
+
+// ResolveConflicts compares stored containers of every service with driver ones without changing them
+func ResolveConflicts(e *env.Env) (ConflictReport, error) {
+	return ResolveConflictsWith(e, false)
+}
+
+// ResolveConflictsWith reports conflicts, with fix ghost records are cleared, orphans are removed
+// and recorded images are updated. Services with deploy in progress are skipped
+func ResolveConflictsWith(e *env.Env, fix bool) (ConflictReport, error) {
+	e.Log.Info(`Resolve conflicts of services with driver`)
+
+	report := ConflictReport{
+		Ghosts:     []Conflict{},
+		Orphans:    []Conflict{},
+		Mismatches: []Conflict{},
+		Checked:    time.Now().UTC(),
+	}
+
+	if fix && !e.HostLocked {
+		return report, ErrHostNotLocked
+	}
+
+	if err := checkDriver(e); err != nil {
+		return report, err
+	}
+
+	services, err := list(e)
+	if err != nil {
+		return report, err
+	}
+
+	containers, err := e.Containers.ListContainers()
+	if err != nil {
+		return report, err
+	}
+
+	tracked := make(map[string]string)
+	deploying := make(map[string]bool)
+
+	for _, s := range services {
+
+		changed := false
+
+		for _, id := range s.trackedIDs() {
+			tracked[id] = s.Name
+		}
+
+		if s.Transition != "" {
+			deploying[s.Name] = true
+			continue
+		}
+
+		for _, container := range s.sortedContainers() {
+
+			c, ok := containers[container.ID]
+			if !ok {
+				// Jobs are removed by driver when they exit
+				if s.Config.AutoRemove {
+					continue
+				}
+
+				conflict := Conflict{Kind: ConflictGhost, Service: s.Name, Container: container.ID}
+				if fix {
+					s.forgetContainer(e, container.ID)
+					conflict.Fixed, changed = true, true
+				}
+
+				report.Ghosts = append(report.Ghosts, conflict)
+				continue
+			}
+
+			if owner := c.Config.Labels[LabelService]; owner != "" && owner != s.Name {
+				report.Mismatches = append(report.Mismatches, Conflict{
+					Kind:      ConflictMismatch,
+					Service:   s.Name,
+					Container: container.ID,
+					Detail:    fmt.Sprintf("container is labeled with service %s", owner),
+				})
+			}
+
+			if image := c.Config.Image; image != "" && container.Image != "" && image != container.Image {
+				conflict := Conflict{
+					Kind:      ConflictMismatch,
+					Service:   s.Name,
+					Container: container.ID,
+					Detail:    fmt.Sprintf("recorded image %s, driver runs %s", container.Image, image),
+				}
+
+				if fix {
+					container.Image = image
+					conflict.Fixed, changed = true, true
+				}
+
+				report.Mismatches = append(report.Mismatches, conflict)
+			}
+
+			for name, id := range container.Sidecars {
+				if _, ok := containers[id]; ok {
+					continue
+				}
+
+				conflict := Conflict{Kind: ConflictGhost, Service: s.Name, Container: id, Detail: fmt.Sprintf("sidecar %s", name)}
+				if fix {
+					delete(container.Sidecars, name)
+					conflict.Fixed, changed = true, true
+				}
+
+				report.Ghosts = append(report.Ghosts, conflict)
+			}
+		}
+
+		if changed {
+			if err := s.Update(e); err != nil {
+				e.Log.Error(err)
+			}
+		}
+	}
+
+	ids := []string{}
+	for id := range containers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+
+		owner := containers[id].Config.Labels[LabelService]
+		if owner == "" || tracked[id] != "" || deploying[owner] {
+			continue
+		}
+
+		conflict := Conflict{Kind: ConflictOrphan, Service: owner, Container: id}
+		if fix {
+			if err := e.Containers.RemoveContainer(&interfaces.Container{CID: id}); err != nil {
+				e.Log.Error(err)
+				conflict.Detail = err.Error()
+			} else {
+				conflict.Fixed = true
+			}
+		}
+
+		report.Orphans = append(report.Orphans, conflict)
+	}
+
+	return report, nil
+}
+
+// Ids of all containers service tracks: replicas, their sidecars and canaries
+func (s *Service) trackedIDs() []string {
+
+	ids := []string{}
+
+	for id, container := range s.Containers {
+		ids = append(ids, id)
+		for _, sidecar := range container.Sidecars {
+			ids = append(ids, sidecar)
+		}
+	}
+
+	if s.Canary != nil {
+		ids = append(ids, s.Canary.Containers...)
+	}
+
+	return ids
+}
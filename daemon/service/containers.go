@@ -0,0 +1,130 @@
+package service
+
+import (
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"sort"
+	"strings"
+)
+
+// Container states used by filter
+const (
+	StateRunning    = `running`
+	StatePaused     = `paused`
+	StateRestarting = `restarting`
+	StateExited     = `exited`
+	StateMissing    = `missing`
+)
+
+// ContainerFilter selects service containers, empty fields match any
+type ContainerFilter struct {
+	State string `json:"state"`
+}
+
+// Page of service containers and total count of matched ones
+type ContainerPage struct {
+	Containers []interfaces.Container `json:"containers"`
+	Total      int                    `json:"total"`
+	Limit      int                    `json:"limit"`
+	Offset     int                    `json:"offset"`
+}
+
+func containerState(c interfaces.Container) string {
+	switch {
+	case c.State.Paused:
+		return StatePaused
+	case c.State.Restarting:
+		return StateRestarting
+	case c.State.Running:
+		return StateRunning
+	}
+
+	return StateExited
+}
+
+// Update container record from driver state, true if it was changed
+func (c *Container) refresh(inspected interfaces.Container) bool {
+
+	prev := *c
+
+	if c.Name == "" {
+		c.Name = strings.TrimPrefix(inspected.Name, "/")
+	}
+
+	c.RestartCount = inspected.State.Restarts
+	c.HealthState = containerState(inspected)
+
+	if inspected.State.Running && inspected.State.Health != nil {
+		c.HealthState = inspected.State.Health.Status
+	}
+
+	if !inspected.State.Running && !inspected.State.Finished.IsZero() {
+		c.LastExitCode = inspected.State.ExitCode
+	}
+
+	return c.Name != prev.Name || c.RestartCount != prev.RestartCount ||
+		c.HealthState != prev.HealthState || c.LastExitCode != prev.LastExitCode
+}
+
+// ListContainers returns page of service containers matching filter, ordered by replica index,
+// all containers after offset are returned if limit is not positive
+func (s *Service) ListContainers(e *env.Env, filter ContainerFilter, limit, offset int) (*ContainerPage, error) {
+	e.Log.Debug(`List containers of service `, s.Name)
+
+	if s.UUID == "" {
+		return nil, ErrServiceNotFound
+	}
+
+	records := []*Container{}
+	for _, container := range s.Containers {
+		records = append(records, container)
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Index != records[j].Index {
+			return records[i].Index < records[j].Index
+		}
+		return records[i].ID < records[j].ID
+	})
+
+	matched := []interfaces.Container{}
+
+	for _, container := range records {
+
+		c := interfaces.Container{CID: container.ID}
+		state := StateMissing
+
+		if err := e.Containers.InspectContainer(&c); err == nil {
+			state = containerState(c)
+		} else if !isNoSuchContainer(err) {
+			return nil, err
+		}
+
+		if filter.State != "" && filter.State != state {
+			continue
+		}
+
+		c.Config.Env = s.Config.maskEnv(c.Config.Env)
+		matched = append(matched, c)
+	}
+
+	page := &ContainerPage{
+		Containers: []interfaces.Container{},
+		Total:      len(matched),
+		Limit:      limit,
+		Offset:     offset,
+	}
+
+	if offset < 0 || offset >= len(matched) {
+		return page, nil
+	}
+
+	end := len(matched)
+	if limit > 0 && offset+limit < end {
+		end = offset + limit
+	}
+
+	page.Containers = matched[offset:end]
+
+	return page, nil
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"time"
+)
+
+// Deploy resolves tag to the digest it currently points to, pulls the pinned image
+// and replaces service containers, so all replicas run identical image even if tag moves
+func (s *Service) Deploy(e *env.Env, tag string, note Annotation) error {
+	return s.DeployWithProgress(e, tag, note, nil)
+}
+
+// DeployWithProgress deploys like Deploy and sends progress events,
+// channel should be read until deploy returns
+func (s *Service) DeployWithProgress(e *env.Env, tag string, note Annotation, progress chan<- ProgressEvent) error {
+	e = s.logEnv(e)
+	e.Log.Info(`Deploy service `, s.Name)
+
+	s.progress = progress
+	defer func() { s.progress = nil }()
+
+	if s.UUID != "" {
+		s.beginTransition(e, TransitionDeploying)
+		defer s.endTransition(e)
+	}
+
+	started := time.Now()
+	event := s.deployEvent(note)
+
+	var err error
+	if note.Key != "" {
+		err = s.deployOnce(e, note.Key, func() error {
+			return s.deploy(e, tag, note)
+		})
+	} else {
+		err = s.deploy(e, tag, note)
+	}
+
+	if err != nil {
+		s.report(ProgressEvent{Stage: StageFailed, Message: err.Error()})
+	} else {
+		s.report(ProgressEvent{Stage: StageDone})
+	}
+
+	elapsed := time.Since(started)
+
+	observeMetric(metricDeployDuration, s.Name, elapsed)
+	if err != nil {
+		countMetric(metricDeployFailures, s.Name)
+	}
+
+	s.notifyDeploy(e, event, err, elapsed)
+
+	return err
+}
+
+func (s *Service) deploy(e *env.Env, tag string, note Annotation) error {
+
+	if s.UUID == "" {
+		return ErrServiceNotFound
+	}
+
+	if err := s.Config.checkRequiredEnv(); err != nil {
+		return err
+	}
+
+	if err := checkDriver(e); err != nil {
+		return err
+	}
+
+	if interval := seconds(s.Config.MinDeployInterval); interval > 0 && time.Since(s.LastDeploy) < interval {
+		return ErrDeployTooSoon
+	}
+
+	s.LastDeploy = time.Now()
+
+	s.annotate(note)
+	s.stampDeploy()
+
+	defer s.trackDeploy()()
+
+	s.HookResults = nil
+	prevDigest := s.Digest
+
+	if err := s.runHostHook(e, HookPreDeploy, s.Config.PreDeployHost); err != nil {
+		return err
+	}
+
+	var err error
+	if s.Config.Source != nil {
+		err = s.deploySource(e, tag)
+	} else {
+		err = s.deployImage(e, tag)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// Containers are already replaced, failed post deploy hook is only reported
+	if err := s.runHostHook(e, HookPostDeploy, s.Config.PostDeployHost); err != nil {
+		e.Log.Error(err)
+	}
+
+	// Aliases belong to the previous deploy
+	if s.Digest == "" || s.Digest != prevDigest {
+		s.Tags = nil
+	}
+
+	s.recordDeployedImage()
+	s.recordHistory(false)
+
+	if err := s.Update(e); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Deploy image pinned to digest tag points to
+func (s *Service) deployImage(e *env.Env, tag string) error {
+
+	if tag == "" {
+		tag = s.Tag
+	}
+
+	digest, err := e.Registry.Digest(s.Config.Image, tag, s.registryAuth(e, s.Config.Image))
+	if err != nil {
+		e.Log.Error(err)
+		return err
+	}
+
+	e.Log.Info(`Resolved `, s.Config.Image, `:`, tag, ` to `, digest)
+
+	prevTag, prevDigest := s.Tag, s.Digest
+	s.Tag, s.Digest = tag, digest
+
+	if err := runPhase(`pull`, seconds(s.Config.Timeouts.Pull), func(ctx context.Context) error {
+		return s.pullImages(ctx, e)
+	}); err != nil {
+		e.Log.Error(err)
+		s.Tag, s.Digest = prevTag, prevDigest
+		return err
+	}
+
+	if err := s.scanImage(e); err != nil {
+		e.Log.Error(err)
+		s.Tag, s.Digest = prevTag, prevDigest
+		return err
+	}
+
+	if err := s.Update(e); err != nil {
+		s.Tag, s.Digest = prevTag, prevDigest
+		return err
+	}
+
+	if err := s.replaceContainers(e); err != nil {
+		s.Tag, s.Digest = prevTag, prevDigest
+		s.Update(e)
+		return err
+	}
+
+	return nil
+}
+
+// Start new containers from current config and remove old ones after new are ready,
+// old containers are kept if new ones fail
+func (s *Service) replaceContainers(e *env.Env) error {
+
+	if err := s.runInitContainers(e); err != nil {
+		return err
+	}
+
+	old := s.Containers
+	s.Containers = make(map[string]*Container)
+
+	if s.Config.PreserveAnonymousVolumes {
+		s.preserved = s.anonymousVolumes(e, old)
+		defer func() { s.preserved = nil }()
+	}
+
+	// New containers can not bind fixed or previously allocated host ports held by old ones,
+	// they are started on temporary ports and handed real ports later
+	handoff := (s.Config.fixedPorts() || len(s.HostPorts) > 0) && len(old) > 0
+	s.handoff = handoff
+
+	rollback := func(err error) error {
+		e.Log.Error(err)
+		s.handoff = false
+
+		for _, container := range s.Containers {
+			if err := s.removeReplaced(e, container); err != nil {
+				e.Log.Error(err)
+			}
+		}
+
+		s.Containers = old
+
+		return err
+	}
+
+	for len(s.Containers) < s.replicas() {
+		s.report(ProgressEvent{
+			Stage:   StageStarting,
+			Message: fmt.Sprintf("starting replica %d/%d", len(s.Containers)+1, s.replicas()),
+			Current: len(s.Containers) + 1,
+			Total:   s.replicas(),
+		})
+
+		if err := s.canceled(); err != nil {
+			return rollback(err)
+		}
+
+		if err := s.startReplica(e); err != nil {
+			return rollback(err)
+		}
+	}
+
+	if err := s.waitReady(e); err != nil {
+		return rollback(err)
+	}
+
+	if err := s.canceled(); err != nil {
+		return rollback(err)
+	}
+
+	s.report(ProgressEvent{Stage: StageHealthy})
+
+	s.handoff = false
+
+	for _, container := range old {
+		if err := s.removeReplaced(e, container); err != nil {
+			e.Log.Error(err)
+			if !isNoSuchContainer(err) {
+				s.Containers[container.ID] = container
+			}
+		}
+	}
+
+	if handoff {
+		if err := s.handoffPorts(e); err != nil {
+			s.Update(e)
+			return err
+		}
+	}
+
+	if err := s.Update(e); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Start and record one replica within start timeout, driver requests are canceled on timeout
+// and replica started anyway is removed
+func (s *Service) startReplica(e *env.Env) error {
+
+	started := make(chan *Container, 1)
+	timedOut := make(chan bool, 1)
+	index := s.freeIndex()
+
+	err := runPhase(`start`, seconds(s.Config.Timeouts.Start), func(ctx context.Context) error {
+		container, err := s.newContainer(ctx, e, s.image(), index)
+		timedOut <- ctx.Err() == context.DeadlineExceeded
+		started <- container
+		return err
+	})
+
+	// Service is changed only after canceled start returns, so it is never changed concurrently
+	container := <-started
+	if container == nil {
+		return err
+	}
+
+	if <-timedOut {
+		if err := s.removeReplaced(e, container); err != nil {
+			e.Log.Error(err)
+		}
+		return err
+	}
+
+	s.Containers[container.ID] = container
+
+	return err
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+)
+
+// Config fields containers are created from, fields which only drive deploys like
+// hooks, approval, notifications or scale do not change containers
+type containerSpec struct {
+	Image        string                         `json:"image"`
+	Env          []string                       `json:"env"`
+	LogLevel     string                         `json:"log_level"`
+	Ports        []string                       `json:"ports"`
+	Volumes      []string                       `json:"volumes"`
+	Secrets      []string                       `json:"secrets"`
+	ConfigFiles  []string                       `json:"config_files"`
+	DockerSocket bool                           `json:"docker_socket"`
+	Memory       int64                          `json:"memory"`
+	CPUs         float64                        `json:"cpus"`
+	Hostname     string                         `json:"hostname"`
+	Platform     string                         `json:"required_platform"`
+	Labels       map[string]string              `json:"labels"`
+	Placement    interfaces.Placement           `json:"placement"`
+	Sidecars     []Sidecar                      `json:"sidecars"`
+	Restart      interfaces.RestartPolicyConfig `json:"restart"`
+	CapAdd       []string                       `json:"cap_add"`
+	CapDrop      []string                       `json:"cap_drop"`
+	Sysctls      map[string]string              `json:"sysctls"`
+	PidMode      string                         `json:"pid_mode"`
+	IpcMode      string                         `json:"ipc_mode"`
+	AutoRemove   bool                           `json:"auto_remove"`
+	ShmSize      ByteSize                       `json:"shm_size"`
+	SecurityOpt  []string                       `json:"security_opt"`
+	CgroupParent string                         `json:"cgroup_parent"`
+
+	BlkioWeight         int64                   `json:"blkio_weight"`
+	BlkioDeviceReadBps  []interfaces.BlockLimit `json:"blkio_device_read_bps"`
+	BlkioDeviceWriteBps []interfaces.BlockLimit `json:"blkio_device_write_bps"`
+}
+
+// Checksum of image and config fields containers are created from
+func (s *Service) configChecksum() (string, error) {
+
+	c := s.Config
+
+	data, err := json.Marshal(containerSpec{
+		Image:        s.image(),
+		Env:          c.Env,
+		LogLevel:     c.LogLevel,
+		Ports:        c.Ports,
+		Volumes:      c.Volumes,
+		Secrets:      c.Secrets,
+		ConfigFiles:  c.ConfigFiles,
+		DockerSocket: c.DockerSocket,
+		Memory:       c.Memory,
+		CPUs:         c.CPUs,
+		Hostname:     c.Hostname,
+		Platform:     c.RequiredPlatform,
+		Labels:       c.Labels,
+		Placement:    c.Placement,
+		Sidecars:     c.Sidecars,
+		Restart:      c.driverRestartPolicy(),
+		CapAdd:       c.CapAdd,
+		CapDrop:      c.CapDrop,
+		Sysctls:      c.Sysctls,
+		PidMode:      c.PidMode,
+		IpcMode:      c.IpcMode,
+		AutoRemove:   c.AutoRemove,
+		ShmSize:      c.ShmSize,
+		SecurityOpt:  c.SecurityOpt,
+		CgroupParent: c.CgroupParent,
+
+		BlkioWeight:         c.BlkioWeight,
+		BlkioDeviceReadBps:  c.BlkioDeviceReadBps,
+		BlkioDeviceWriteBps: c.BlkioDeviceWriteBps,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", sha256.Sum256(data)), nil
+}
+
+// Diff returns ids of service containers created from other image or config
+// than the current one, containers created without config label are treated as changed
+func (s *Service) Diff(e *env.Env) ([]string, error) {
+	e.Log.Debug(`Diff service `, s.Name)
+
+	if s.UUID == "" {
+		return nil, ErrServiceNotFound
+	}
+
+	checksum, err := s.configChecksum()
+	if err != nil {
+		return nil, err
+	}
+
+	changed := []string{}
+
+	for _, container := range s.Containers {
+
+		c := &interfaces.Container{CID: container.ID}
+		if err := e.Containers.InspectContainer(c); err != nil {
+			if isNoSuchContainer(err) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		if c.Config.Labels[LabelConfig] != checksum {
+			changed = append(changed, container.ID)
+		}
+	}
+
+	return changed, nil
+}
+
+// Ids of service containers created from other image than the one local tag points to,
+// tag moves when it is pulled after new image was pushed
+func (s *Service) outdatedImage(e *env.Env) ([]string, error) {
+
+	image := &interfaces.Image{Name: s.image()}
+	if err := e.Containers.InspectImage(image); err != nil {
+		return nil, err
+	}
+
+	outdated := []string{}
+
+	for _, container := range s.Containers {
+
+		c := &interfaces.Container{CID: container.ID}
+		if err := e.Containers.InspectContainer(c); err != nil {
+			if isNoSuchContainer(err) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		if c.ImageID != image.ID {
+			outdated = append(outdated, container.ID)
+		}
+	}
+
+	return outdated, nil
+}
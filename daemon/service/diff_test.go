@@ -0,0 +1,83 @@
+package service
+
+import (
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// Driver returning labels containers were created with
+type labeledContainers struct {
+	interfaces.IContainers
+	labels map[string]map[string]string
+}
+
+func (c labeledContainers) InspectContainer(container *interfaces.Container) error {
+	container.Config.Labels = c.labels[container.CID]
+	return nil
+}
+
+func TestDiff(t *testing.T) {
+
+	base := Service{
+		UUID:   "uuid-web",
+		Name:   "web",
+		Config: Config{Image: "nginx", Env: []string{"A=1"}, Ports: []string{"80"}},
+	}
+
+	tests := []struct {
+		name    string
+		change  func(s *Service)
+		changed bool
+	}{
+		{"same config", func(s *Service) {}, false},
+		{"scale", func(s *Service) { s.Config.Scale = 3 }, false},
+		{"host hooks", func(s *Service) { s.Config.PreDeployHost = []string{"true"} }, false},
+		{"webhooks", func(s *Service) { s.Config.Webhooks.OnSuccess = []string{"http://hooks"} }, false},
+		{"secret env", func(s *Service) { s.Config.SecretEnv = []string{"A"} }, false},
+		{"timeouts", func(s *Service) { s.Config.Timeouts.Pull = 10 }, false},
+		{"env", func(s *Service) { s.Config.Env = []string{"A=2"} }, true},
+		{"ports", func(s *Service) { s.Config.Ports = []string{"8080"} }, true},
+		{"memory", func(s *Service) { s.Config.Memory = 256 }, true},
+		{"image", func(s *Service) { s.Digest = "sha256:1" }, true},
+	}
+
+	for _, tt := range tests {
+
+		created := base
+		checksum, err := created.configChecksum()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		s := base
+		s.Config.Env = append([]string{}, base.Config.Env...)
+		s.Config.Ports = append([]string{}, base.Config.Ports...)
+		s.Containers = map[string]*Container{"c1": {ID: "c1"}, "c2": {ID: "c2"}}
+		tt.change(&s)
+
+		e := testEnv(t)
+		e.Containers = labeledContainers{labels: map[string]map[string]string{
+			"c1": {LabelConfig: checksum},
+			"c2": {},
+		}}
+
+		changed, err := s.Diff(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Container without config label is always changed
+		want := []string{"c2"}
+		if tt.changed {
+			want = []string{"c1", "c2"}
+		}
+
+		sort.Strings(changed)
+
+		if !reflect.DeepEqual(changed, want) {
+			t.Errorf("%s: Diff() = %v, want %v", tt.name, changed, want)
+		}
+	}
+}
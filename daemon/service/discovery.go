@@ -0,0 +1,99 @@
+package service
+
+import (
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"regexp"
+	"strconv"
+)
+
+// Reference to host port of other service in env, like ${service.db.port}
+// or ${service.db.port.5432} for a specific container port
+var serviceRef = regexp.MustCompile(`\$\{service\.([a-zA-Z0-9][a-zA-Z0-9_.-]*?)\.port(?:\.([0-9]+))?\}`)
+
+// Names of services referenced in env values
+func (c *Config) referencedServices() []string {
+
+	names := []string{}
+	seen := make(map[string]bool)
+
+	for _, variable := range c.Env {
+		for _, match := range serviceRef.FindAllStringSubmatch(variable, -1) {
+			if !seen[match[1]] {
+				names = append(names, match[1])
+				seen[match[1]] = true
+			}
+		}
+	}
+
+	return names
+}
+
+// Substitute references to other services with their published host ports,
+// referenced services should be started before, see DependsOn
+func resolveServiceRefs(e *env.Env, vars []string) ([]string, error) {
+
+	resolved := []string{}
+
+	for _, variable := range vars {
+
+		var failed error
+
+		value := serviceRef.ReplaceAllStringFunc(variable, func(ref string) string {
+
+			match := serviceRef.FindStringSubmatch(ref)
+
+			var containerPort int64
+			if match[2] != "" {
+				containerPort, _ = strconv.ParseInt(match[2], 10, 64)
+			}
+
+			port, err := servicePort(e, match[1], containerPort)
+			if err != nil {
+				failed = err
+				return ref
+			}
+
+			return strconv.FormatInt(port, 10)
+		})
+
+		if failed != nil {
+			return nil, failed
+		}
+
+		resolved = append(resolved, value)
+	}
+
+	return resolved, nil
+}
+
+// Host port of service container published for container port, any port if it is 0
+func servicePort(e *env.Env, name string, containerPort int64) (int64, error) {
+
+	s := new(Service)
+	if err := s.Get(e, name); err != nil || s.UUID == "" {
+		return 0, fmt.Errorf("service %s referenced in env is not found", name)
+	}
+
+	for _, container := range s.Containers {
+
+		c := &interfaces.Container{CID: container.ID}
+		if err := e.Containers.InspectContainer(c); err != nil {
+			e.Log.Error(err)
+			continue
+		}
+
+		if !c.State.Running {
+			continue
+		}
+
+		for _, port := range c.Ports {
+			if port.Host != 0 && (containerPort == 0 || port.Container == containerPort) {
+				return port.Host, nil
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("service %s referenced in env is not started or has no published port", name)
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"sort"
+)
+
+// Point DNS record of service at daemon host and host port of its lowest index replica,
+// failures are only logged as service is already running
+func (s *Service) upsertDNS(e *env.Env) {
+
+	if e.DNS == nil {
+		return
+	}
+
+	target := e.DNSHost
+
+	for _, container := range s.sortedContainers() {
+
+		c := &interfaces.Container{CID: container.ID}
+		if err := e.Containers.InspectContainer(c); err != nil {
+			e.Log.Error(err)
+			continue
+		}
+
+		ports := []int64{}
+		for _, port := range c.Ports {
+			if port.Host != 0 {
+				ports = append(ports, port.Host)
+			}
+		}
+
+		if len(ports) == 0 {
+			continue
+		}
+
+		sort.Slice(ports, func(i, j int) bool { return ports[i] < ports[j] })
+		target = fmt.Sprintf("%s:%d", e.DNSHost, ports[0])
+
+		break
+	}
+
+	e.Log.Info(`Point DNS record of service `, s.Name, ` at `, target)
+
+	if err := e.DNS.Upsert(s.Name, target); err != nil {
+		e.Log.Error(err)
+	}
+}
+
+// Remove DNS record of service before it is stopped, so clients stop resolving it first
+func (s *Service) deleteDNS(e *env.Env) {
+
+	if e.DNS == nil {
+		return
+	}
+
+	e.Log.Info(`Remove DNS record of service `, s.Name)
+
+	if err := e.DNS.Delete(s.Name); err != nil {
+		e.Log.Error(err)
+	}
+}
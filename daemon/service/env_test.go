@@ -0,0 +1,30 @@
+package service
+
+import (
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/localDB"
+	"testing"
+)
+
+type nopLog struct{}
+
+func (nopLog) Debug(...interface{})          {}
+func (nopLog) Debugf(string, ...interface{}) {}
+func (nopLog) Info(...interface{})           {}
+func (nopLog) Infof(string, ...interface{})  {}
+func (nopLog) Error(...interface{})          {}
+func (nopLog) Errorf(string, ...interface{}) {}
+func (nopLog) Fatal(...interface{})          {}
+func (nopLog) Fatalf(string, ...interface{}) {}
+func (nopLog) SetDebugLevel()                {}
+
+// Env with local db in temporary directory and host lock held
+func testEnv(t *testing.T) *env.Env {
+
+	ldb, err := localDB.Init(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &env.Env{Log: nopLog{}, LDB: ldb, HostLocked: true}
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"path"
+	"sort"
+	"strings"
+)
+
+const maskedValue = `******`
+
+// Env keys masked when service does not configure own patterns
+var defaultSecretEnv = []string{`*_PASSWORD`, `*_KEY`, `*_SECRET`, `*_TOKEN`}
+
+// EnvVars returns actual environment of service container,
+// values of keys matching secret patterns are masked
+func (s *Service) EnvVars(e *env.Env, containerID string) (map[string]string, error) {
+	e.Log.Debug(`Env of container `, containerID, ` of service `, s.Name)
+
+	if s.UUID == "" {
+		return nil, ErrServiceNotFound
+	}
+
+	if _, ok := s.Containers[containerID]; !ok {
+		return nil, ErrContainerNotFound
+	}
+
+	c := &interfaces.Container{CID: containerID}
+	if err := e.Containers.InspectContainer(c); err != nil {
+		return nil, err
+	}
+
+	vars := make(map[string]string)
+
+	for _, variable := range c.Config.Env {
+		parts := strings.SplitN(variable, "=", 2)
+
+		value := ""
+		if len(parts) == 2 {
+			value = parts[1]
+		}
+
+		if s.Config.secretEnv(parts[0]) {
+			value = maskedValue
+		}
+
+		vars[parts[0]] = value
+	}
+
+	return vars, nil
+}
+
+// SetEnv merges variables into service env, empty value removes variable,
+// containers of running service are recreated with new env and nothing is done if env is not changed
+func (s *Service) SetEnv(e *env.Env, vars map[string]string) error {
+	e = s.logEnv(e)
+	e.Log.Info(`Set env of service `, s.Name)
+
+	if s.UUID == "" {
+		return ErrServiceNotFound
+	}
+
+	// Env variables can not contain NUL, so joined envs are equal only when variables are equal and in the same order
+	merged := mergeEnv(s.Config.Env, vars)
+	if strings.Join(merged, "\x00") == strings.Join(s.Config.Env, "\x00") {
+		e.Log.Info(`Env of service `, s.Name, ` is not changed`)
+		return nil
+	}
+
+	prev := s.Config
+
+	config := s.Config
+	config.Env = merged
+
+	if err := s.UpdateConfig(e, &config); err != nil {
+		return err
+	}
+
+	// Stopped service gets new env when it is restarted
+	if s.Desired == DesiredStopped || len(s.Containers) == 0 {
+		return nil
+	}
+
+	if err := checkDriver(e); err != nil {
+		return err
+	}
+
+	if err := s.replaceContainers(e); err != nil {
+		s.Config = prev
+		if err := s.Update(e); err != nil {
+			e.Log.Error(err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Env with changed variables replaced in place, removed ones dropped and new ones appended in key order
+func mergeEnv(current []string, vars map[string]string) []string {
+
+	merged := []string{}
+	seen := make(map[string]bool)
+
+	for _, variable := range current {
+		key := strings.SplitN(variable, "=", 2)[0]
+
+		value, ok := vars[key]
+		seen[key] = ok
+
+		switch {
+		case !ok:
+			merged = append(merged, variable)
+		case value != "":
+			merged = append(merged, key+"="+value)
+		}
+	}
+
+	keys := []string{}
+	for key, value := range vars {
+		if !seen[key] && value != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		merged = append(merged, key+"="+vars[key])
+	}
+
+	return merged
+}
+
+// Copy of container env with secret values masked
+func (c *Config) maskEnv(vars []string) []string {
+
+	masked := []string{}
+
+	for _, variable := range vars {
+		if key := strings.SplitN(variable, "=", 2)[0]; c.secretEnv(key) {
+			variable = key + "=" + maskedValue
+		}
+
+		masked = append(masked, variable)
+	}
+
+	return masked
+}
+
+// Env key matches one of secret patterns, matching is case insensitive
+func (c *Config) secretEnv(key string) bool {
+
+	patterns := c.SecretEnv
+	if len(patterns) == 0 {
+		patterns = defaultSecretEnv
+	}
+
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToUpper(pattern), strings.ToUpper(key)); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Required variables which are set neither in env nor by secret file named after them
+func (c *Config) missingEnv() []string {
+
+	set := make(map[string]bool)
+	for _, variable := range c.Env {
+		set[strings.SplitN(variable, "=", 2)[0]] = true
+	}
+
+	for _, secret := range c.Secrets {
+		if parts := strings.Split(secret, ":"); len(parts) > 1 {
+			set[path.Base(parts[1])] = true
+		}
+	}
+
+	missing := []string{}
+	for _, key := range c.RequiredEnv {
+		if !set[key] {
+			missing = append(missing, key)
+		}
+	}
+
+	return missing
+}
+
+// Fail before any container is started when required variables are not set
+func (c *Config) checkRequiredEnv() error {
+	if missing := c.missingEnv(); len(missing) > 0 {
+		return MissingEnvError(missing)
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetEnvRequired(t *testing.T) {
+
+	tests := []struct {
+		name string
+		vars map[string]string
+		err  bool
+		env  []string
+	}{
+		{"required removed", map[string]string{"DB_URL": ""}, true, []string{"DB_URL=postgres://db", "MODE=prod"}},
+		{"required changed", map[string]string{"DB_URL": "postgres://other"}, false, []string{"DB_URL=postgres://other", "MODE=prod"}},
+		{"optional removed", map[string]string{"MODE": ""}, false, []string{"DB_URL=postgres://db"}},
+	}
+
+	for _, tt := range tests {
+
+		e := testEnv(t)
+
+		// Stopped service only stores env, so driver is not needed
+		s := &Service{
+			UUID:    "uuid-web",
+			Name:    "web",
+			Version: 1,
+			Desired: DesiredStopped,
+			Config: Config{
+				Image:       "nginx",
+				Env:         []string{"DB_URL=postgres://db", "MODE=prod"},
+				RequiredEnv: []string{"DB_URL"},
+			},
+		}
+
+		if err := e.LDB.Write(storageKey(s.Name), s); err != nil {
+			t.Fatal(err)
+		}
+
+		err := s.SetEnv(e, tt.vars)
+		if _, missing := err.(MissingEnvError); missing != tt.err {
+			t.Errorf("%s: SetEnv() = %v, want missing env error %v", tt.name, err, tt.err)
+		}
+
+		stored := new(Service)
+		if err := stored.Get(e, s.Name); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(stored.Config.Env, tt.env) {
+			t.Errorf("%s: stored env = %v, want %v", tt.name, stored.Config.Env, tt.env)
+		}
+	}
+}
+
+func TestMergeEnv(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		current []string
+		vars    map[string]string
+		want    []string
+	}{
+		{"nothing set", []string{"A=1"}, map[string]string{}, []string{"A=1"}},
+		{"changed in place", []string{"A=1", "B=2", "C=3"}, map[string]string{"B": "20"}, []string{"A=1", "B=20", "C=3"}},
+		{"empty removes", []string{"A=1", "B=2"}, map[string]string{"A": ""}, []string{"B=2"}},
+		{"new in key order", []string{"A=1"}, map[string]string{"D": "4", "C": "3"}, []string{"A=1", "C=3", "D=4"}},
+		{"removing missing", []string{"A=1"}, map[string]string{"Z": ""}, []string{"A=1"}},
+		{"value with equals", []string{"A=x=y"}, map[string]string{"A": "u=v"}, []string{"A=u=v"}},
+		{"variable without value", []string{"FLAG"}, map[string]string{"FLAG": "1"}, []string{"FLAG=1"}},
+		{"empty env", nil, map[string]string{"A": "1"}, []string{"A=1"}},
+	}
+
+	for _, tt := range tests {
+		if got := mergeEnv(tt.current, tt.vars); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: mergeEnv() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
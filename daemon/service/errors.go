@@ -0,0 +1,120 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrServiceNotFound   = errors.New("service not found")
+	ErrContainerNotFound = errors.New("container not found")
+	ErrAlreadyExists     = errors.New("service already exists")
+	ErrInvalidName       = errors.New("invalid service name")
+	ErrHostNotLocked     = errors.New("host lock is not held, services can not be changed")
+	ErrIndexOutOfRange   = errors.New("replica index out of range")
+	ErrDeployTooSoon     = errors.New("service was deployed too recently, retry later")
+	ErrDriverUnavailable = errors.New("containers driver is unavailable")
+	ErrInspectTimeout    = errors.New("containers inspection timed out")
+	ErrRemoveTimeout     = errors.New("removed container still exists")
+	ErrTooManySessions   = errors.New("too many exec sessions into service")
+	ErrImageNotFound     = interfaces.ErrImageNotFound
+	ErrPortReserved      = errors.New("host port is reserved by other service")
+	ErrNoHealthyReplica  = errors.New("no replica answered health check")
+
+	ErrInvalidIdempotencyKey = errors.New("invalid idempotency key")
+	ErrDeployInProgress      = errors.New("deploy with the same key is in progress")
+	ErrNoDeployInProgress    = errors.New("no deploy of service is in progress")
+	ErrDeployCanceled        = errors.New("deploy was canceled")
+	ErrNoPreviousDeploy      = errors.New("service has no previous deploy to roll back to")
+
+	ErrConflict               = errors.New("service was changed concurrently, reload it and retry")
+	ErrInvalidReplicas        = errors.New("replicas count should be positive")
+	ErrCanaryInProgress       = errors.New("canary is already in progress")
+	ErrNoCanaryInProgress     = errors.New("no canary in progress")
+	ErrPreparedDeployNotFound = errors.New("prepared deploy not found or expired")
+	ErrNotSuspended           = errors.New("service is not suspended")
+	ErrDockerSocketForbidden  = errors.New("docker socket mount is not allowed by daemon")
+	ErrHostHooksForbidden     = errors.New("host hooks are not allowed by daemon")
+	ErrImageRequired          = errors.New("image is required")
+	ErrEmptyExecCommand       = errors.New("exec command is empty")
+	ErrInvalidLogsRange       = errors.New("logs until should be after since")
+	ErrNoLogSink              = errors.New("service has no log sink")
+	ErrInvalidConfigFileName  = errors.New("invalid config file name")
+	ErrStackNameRequired      = errors.New("stack name is required")
+	ErrNoConfigFile           = errors.New("service has no config file")
+	ErrUnknownAction          = errors.New("unknown action")
+)
+
+// ContainerErrors is aggregate error of operation applied to many containers
+type ContainerErrors map[string]error
+
+func (c ContainerErrors) Error() string {
+
+	messages := []string{}
+	for id, err := range c {
+		messages = append(messages, fmt.Sprintf("%s: %s", id, err))
+	}
+
+	sort.Strings(messages)
+
+	return fmt.Sprintf("%d containers failed: %s", len(c), strings.Join(messages, "; "))
+}
+
+// RecordErrors is aggregate error of stored records which can not be read, keyed by storage key
+type RecordErrors map[string]error
+
+func (r RecordErrors) Error() string {
+
+	messages := []string{}
+	for key, err := range r {
+		messages = append(messages, fmt.Sprintf("%s: %s", key, err))
+	}
+
+	sort.Strings(messages)
+
+	return fmt.Sprintf("%d records can not be read: %s", len(r), strings.Join(messages, "; "))
+}
+
+// MissingEnvError lists required env variables which are not set in service config
+type MissingEnvError []string
+
+func (m MissingEnvError) Error() string {
+	return fmt.Sprintf("missing required env: %s", strings.Join(m, ", "))
+}
+
+// QuotaError is host resource which running services would commit over daemon quota
+type QuotaError struct {
+	Resource  string
+	Requested float64
+	Committed float64
+	Quota     float64
+}
+
+func (q QuotaError) Error() string {
+	return fmt.Sprintf("%s quota exceeded: %g requested, %g of %g committed", q.Resource, q.Requested, q.Committed, q.Quota)
+}
+
+// ComposeError is docker-compose file which can not be imported
+type ComposeError string
+
+func (c ComposeError) Error() string {
+	return string(c)
+}
+
+// Service name is used as storage key, so it is limited to safe characters
+var validName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// Fail fast with clear error when containers driver does not respond
+func checkDriver(e *env.Env) error {
+	if err := e.Containers.Ping(); err != nil {
+		e.Log.Error(err)
+		return ErrDriverUnavailable
+	}
+
+	return nil
+}
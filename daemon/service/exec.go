@@ -0,0 +1,72 @@
+package service
+
+import (
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"sync"
+)
+
+// Exec sessions open into every service
+var sessions = struct {
+	sync.Mutex
+	open map[string]int
+}{open: make(map[string]int)}
+
+// Take exec session slot of service, false when limit is reached
+func (s *Service) acquireSession() bool {
+	sessions.Lock()
+	defer sessions.Unlock()
+
+	if limit := s.Config.MaxExecSessions; limit > 0 && sessions.open[s.Name] >= limit {
+		return false
+	}
+
+	sessions.open[s.Name]++
+
+	return true
+}
+
+func (s *Service) releaseSession() {
+	sessions.Lock()
+	defer sessions.Unlock()
+
+	if sessions.open[s.Name]--; sessions.open[s.Name] <= 0 {
+		delete(sessions.open, s.Name)
+	}
+}
+
+// Exec runs command in service container and returns its exit code,
+// sessions over max_exec_sessions are rejected with ErrTooManySessions
+func (s *Service) Exec(e *env.Env, containerID string, opts interfaces.ExecOptions) (int, error) {
+	e = s.logEnv(e)
+	e.Log.Info(`Exec in container `, containerID, ` of service `, s.Name)
+
+	if s.UUID == "" {
+		return 0, ErrServiceNotFound
+	}
+
+	if _, ok := s.Containers[containerID]; !ok {
+		return 0, ErrContainerNotFound
+	}
+
+	if len(opts.Cmd) == 0 {
+		return 0, ErrEmptyExecCommand
+	}
+
+	if !s.acquireSession() {
+		return 0, ErrTooManySessions
+	}
+	defer s.releaseSession()
+
+	if err := checkDriver(e); err != nil {
+		return 0, err
+	}
+
+	code, err := e.Containers.Exec(&interfaces.Container{CID: containerID}, opts)
+	if err != nil {
+		e.Log.Error(err)
+		return 0, err
+	}
+
+	return code, nil
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"time"
+)
+
+// Exits kept in service record, older ones are dropped
+const maxExits = 20
+
+// Why container stopped running
+const (
+	ExitStopped = `stopped`
+	ExitRemoved = `removed`
+	ExitCrashed = `exited`
+)
+
+// Exit is recorded state of container when it stopped running,
+// it is kept after container is removed
+type Exit struct {
+	Container string    `json:"container" yaml:"container"`
+	Index     int       `json:"index" yaml:"index"`
+	Reason    string    `json:"reason" yaml:"reason"`
+	ExitCode  int       `json:"exit_code" yaml:"exit_code"`
+	OOMKilled bool      `json:"oom_killed" yaml:"oom_killed"`
+	Error     string    `json:"error,omitempty" yaml:"error,omitempty"`
+	Finished  time.Time `json:"finished" yaml:"finished"`
+}
+
+// Container state as exit record, running is true if container did not stop yet
+func inspectExit(e *env.Env, container *Container, reason string) (exit Exit, running bool, err error) {
+
+	c := &interfaces.Container{CID: container.ID}
+	if err := e.Containers.InspectContainer(c); err != nil {
+		return exit, false, err
+	}
+
+	exit = Exit{
+		Container: container.ID,
+		Index:     container.Index,
+		Reason:    reason,
+		ExitCode:  c.State.ExitCode,
+		OOMKilled: c.State.OOMKilled,
+		Error:     c.State.Error,
+		Finished:  c.State.Finished,
+	}
+
+	return exit, c.State.Running, nil
+}
+
+// Append exit unless the same exit of container is recorded, false if it is
+func (s *Service) recordExit(exit Exit) bool {
+
+	for _, recorded := range s.Exits {
+		if recorded.Container == exit.Container && recorded.Finished.Equal(exit.Finished) {
+			return false
+		}
+	}
+
+	s.Exits = append(s.Exits, exit)
+	if len(s.Exits) > maxExits {
+		s.Exits = s.Exits[len(s.Exits)-maxExits:]
+	}
+
+	return true
+}
+
+// Record state of container before it is removed, running container exits now
+func (s *Service) recordRemoval(e *env.Env, container *Container) {
+
+	exit, running, err := inspectExit(e, container, ExitRemoved)
+	if err != nil {
+		return
+	}
+
+	if running {
+		exit.Finished = time.Now().UTC()
+	}
+
+	s.recordExit(exit)
+}
+
+// Record exits containers made on their own, including ones restarted by driver since,
+// true if new exits were found
+func (s *Service) recordCrashes(e *env.Env) bool {
+
+	found := false
+
+	for _, container := range s.Containers {
+
+		// Container which never exited has no finish time
+		exit, _, err := inspectExit(e, container, ExitCrashed)
+		if err != nil || exit.Finished.IsZero() {
+			continue
+		}
+
+		if s.recordExit(exit) {
+			found = true
+		}
+	}
+
+	return found
+}
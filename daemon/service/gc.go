@@ -0,0 +1,141 @@
+package service
+
+import (
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/utils"
+	"sort"
+)
+
+// Deploy history kept on service record
+const maxDeployedImages = 20
+
+// Append image of successful deploy to history, repeated deploy of the same image moves it last
+func (s *Service) recordDeployedImage() {
+
+	image := s.image()
+
+	history := []string{}
+	for _, deployed := range s.DeployedImages {
+		if deployed != image {
+			history = append(history, deployed)
+		}
+	}
+
+	history = append(history, image)
+	if len(history) > maxDeployedImages {
+		history = history[len(history)-maxDeployedImages:]
+	}
+
+	s.DeployedImages = history
+}
+
+// Same image reference regardless of default registry and library prefix
+func imageRef(image string) string {
+	registry, repository, tag := utils.ParseImage(image)
+	return registry + "/" + repository + "@" + tag
+}
+
+func imageRepository(image string) string {
+	registry, repository, _ := utils.ParseImage(image)
+	return registry + "/" + repository
+}
+
+// Images service still needs: current, previous, canary and keepLast last deployed ones
+func (s *Service) keptImages(keepLast int) map[string]bool {
+
+	kept := map[string]bool{
+		imageRef(s.image()):                    true,
+		imageRef(s.Config.Image + ":" + s.Tag): true,
+	}
+
+	if s.PreviousImage != "" {
+		kept[imageRef(s.PreviousImage)] = true
+	}
+
+	if s.Canary != nil {
+		kept[imageRef(s.Canary.image(s))] = true
+	}
+
+	for i := len(s.DeployedImages) - 1; i >= 0 && i >= len(s.DeployedImages)-keepLast; i-- {
+		kept[imageRef(s.DeployedImages[i])] = true
+	}
+
+	return kept
+}
+
+// GCImages removes local images of service repositories which are not used by current deploy,
+// previous image, canaries and keepLast last deploys of every service. Images of any
+// container, running or not, are never removed. Removed image names are returned
+func GCImages(e *env.Env, keepLast int) ([]string, error) {
+	e.Log.Info(`Collect unused images, keep last `, keepLast)
+
+	removed := []string{}
+
+	if !e.HostLocked {
+		return removed, ErrHostNotLocked
+	}
+
+	if err := checkDriver(e); err != nil {
+		return removed, err
+	}
+
+	services, err := list(e)
+	if err != nil {
+		return removed, err
+	}
+
+	containers, err := e.Containers.ListContainers()
+	if err != nil {
+		return removed, err
+	}
+
+	images, err := e.Containers.ListImages()
+	if err != nil {
+		return removed, err
+	}
+
+	inUse := make(map[string]bool)
+	for _, c := range containers {
+		inUse[imageRef(c.Image)] = true
+	}
+
+	// Repositories services share keep images of all of them
+	kept := make(map[string]bool)
+	repositories := make(map[string]bool)
+
+	for _, s := range services {
+		if s.Config.Image == "" {
+			continue
+		}
+
+		repositories[imageRepository(s.Config.Image)] = true
+		for ref := range s.keptImages(keepLast) {
+			kept[ref] = true
+		}
+	}
+
+	names := []string{}
+	for name := range images {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+
+		ref := imageRef(name)
+		if !repositories[imageRepository(name)] || kept[ref] || inUse[ref] {
+			continue
+		}
+
+		e.Log.Info(`Remove unused image `, name)
+
+		if err := e.Containers.RemoveImage(name); err != nil {
+			e.Log.Error(err)
+			continue
+		}
+
+		removed = append(removed, name)
+	}
+
+	return removed, nil
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"github.com/deployithq/deployit/daemon/env"
+	"strings"
+)
+
+// Config publishes container ports on fixed host ports, like "8080:80"
+func (c *Config) fixedPorts() bool {
+
+	for _, port := range c.Ports {
+		if strings.Contains(port, ":") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Ports containers are bound with, fixed host ports are left
+// to driver while port handoff is in progress
+func (s *Service) hostPorts() []string {
+
+	if !s.handoff {
+		return s.Config.Ports
+	}
+
+	ports := []string{}
+	for _, port := range s.Config.Ports {
+		parts := strings.Split(port, ":")
+		ports = append(ports, parts[len(parts)-1])
+	}
+
+	return ports
+}
+
+// Replace containers running on temporary ports with ones bound to fixed host ports,
+// temporary containers keep serving if new ones fail
+func (s *Service) handoffPorts(e *env.Env) error {
+	e.Log.Info(`Hand off ports of service `, s.Name)
+
+	temporary := s.Containers
+	s.Containers = make(map[string]*Container)
+
+	rollback := func(err error) error {
+		e.Log.Error(err)
+
+		for _, container := range s.Containers {
+			if err := s.removeReplaced(e, container); err != nil {
+				e.Log.Error(err)
+			}
+		}
+
+		s.Containers = temporary
+
+		return err
+	}
+
+	for len(s.Containers) < len(temporary) {
+		if err := s.startReplica(e); err != nil {
+			return rollback(err)
+		}
+	}
+
+	if err := s.waitHealthy(e, len(temporary)); err != nil {
+		return rollback(err)
+	}
+
+	for _, container := range temporary {
+		if err := s.removeReplaced(e, container); err != nil {
+			e.Log.Error(err)
+			if !isNoSuchContainer(err) {
+				s.Containers[container.ID] = container
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"time"
+)
+
+// Healthy returns true only when all expected replicas exist, are running
+// and pass readiness probe if it is configured
+func (s *Service) Healthy(e *env.Env) (bool, error) {
+	return s.healthy(e, s.replicas())
+}
+
+// Healthy when at least want replicas exist and all of them are healthy
+func (s *Service) healthy(e *env.Env, want int) (bool, error) {
+
+	if s.UUID == "" {
+		return false, ErrServiceNotFound
+	}
+
+	if len(s.Containers) < want {
+		return false, nil
+	}
+
+	for _, container := range s.Containers {
+
+		ready, err := s.containerReady(e, container.ID)
+		if err != nil || !ready {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// Container is running and passes readiness probe if it is configured
+func (s *Service) containerReady(e *env.Env, id string) (bool, error) {
+
+	c := &interfaces.Container{CID: id}
+	if err := e.Containers.InspectContainer(c); err != nil {
+		// Job container removed after exit is completed
+		if s.Config.AutoRemove && isNoSuchContainer(err) {
+			return true, nil
+		}
+
+		e.Log.Error(err)
+		return false, err
+	}
+
+	if !c.State.Running {
+		return false, nil
+	}
+
+	probe := s.Config.ReadinessProbe
+	if probe == nil {
+		return true, nil
+	}
+
+	port, err := probe.hostPort(e, id)
+	if err != nil {
+		return false, err
+	}
+
+	if err := probe.Check(port); err != nil {
+		e.Log.Debug(`Probe failed `, id, err)
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// Ready returns true when at least MinReady replicas, all replicas if it is not set,
+// are running and pass readiness probe, so load balancer can route traffic to service
+func (s *Service) Ready(e *env.Env) (bool, error) {
+	e.Log.Debug(`Ready service `, s.Name)
+
+	if s.UUID == "" {
+		return false, ErrServiceNotFound
+	}
+
+	want := s.Config.MinReady
+	if want <= 0 {
+		want = s.replicas()
+	}
+
+	ready := 0
+
+	for _, container := range s.Containers {
+
+		ok, err := s.containerReady(e, container.ID)
+		if err != nil {
+			e.Log.Error(err)
+			continue
+		}
+
+		if ok {
+			ready++
+		}
+	}
+
+	return ready >= want, nil
+}
+
+const healthPrefix = `health`
+
+// Health is last known service health written by the health reporter
+type Health struct {
+	Healthy    bool      `json:"healthy" yaml:"healthy"`
+	Containers int       `json:"containers" yaml:"containers"`
+	Error      string    `json:"error,omitempty" yaml:"error,omitempty"`
+	Checked    time.Time `json:"checked" yaml:"checked"`
+}
+
+// Health is stored next to service record, so reporting does not
+// bump service version and conflict with service changes
+func healthKey(name string) string {
+	return fmt.Sprintf("%s/%s", healthPrefix, name)
+}
+
+// LastHealth returns stored health without querying containers driver
+func (s *Service) LastHealth(e *env.Env) (*Health, error) {
+
+	health := new(Health)
+
+	if err := e.Reader().Read(healthKey(s.Name), health); err != nil {
+		return nil, err
+	}
+
+	return health, nil
+}
+
+func (s *Service) reportHealth(e *env.Env) error {
+
+	health := &Health{
+		Containers: len(s.Containers),
+		Checked:    time.Now(),
+	}
+
+	healthy, err := s.Healthy(e)
+	if err != nil {
+		health.Error = err.Error()
+	}
+
+	health.Healthy = healthy
+
+	if s.recordCrashes(e) {
+		if err := s.Update(e); err != nil {
+			e.Log.Error(err)
+		}
+	}
+
+	return e.LDB.Write(healthKey(s.Name), health)
+}
+
+// ReportHealthAll stores current health of every service
+func ReportHealthAll(e *env.Env) error {
+
+	services, err := list(e)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range services {
+		if err := s.reportHealth(e); err != nil {
+			e.Log.Error(err)
+		}
+	}
+
+	return nil
+}
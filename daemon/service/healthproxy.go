@@ -0,0 +1,84 @@
+package service
+
+import (
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Body of replica health response returned to caller is cut to this size
+const maxHealthBody = 64 << 10
+
+// Response of replica which answered health path
+type HealthResponse struct {
+	Container   string
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+// ServeHealth requests health path on replicas in index order and returns response of the first
+// one answering with readiness probe status, 200 if probe does not set it. Probe path is used
+// if health path is empty, probe port or first published port of replica is requested
+func (s *Service) ServeHealth(e *env.Env, healthPath string) (*HealthResponse, error) {
+	e.Log.Debug(`Serve health of service `, s.Name)
+
+	if s.UUID == "" {
+		return nil, ErrServiceNotFound
+	}
+
+	probe := Probe{Type: probeHTTP}
+	if s.Config.ReadinessProbe != nil {
+		probe = *s.Config.ReadinessProbe
+	}
+
+	if healthPath == "" {
+		healthPath = probe.Path
+	}
+
+	if !strings.HasPrefix(healthPath, "/") {
+		healthPath = "/" + healthPath
+	}
+
+	status := probe.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	client := &http.Client{Timeout: time.Duration(probe.interval()) * time.Second}
+
+	for _, container := range s.sortedContainers() {
+
+		port, err := probe.hostPort(e, container.ID)
+		if err != nil {
+			e.Log.Debug(`Health port of `, container.ID, ` not found `, err)
+			continue
+		}
+
+		res, err := client.Get(fmt.Sprintf("http://127.0.0.1:%d%s", port, healthPath))
+		if err != nil {
+			e.Log.Debug(`Health request to `, container.ID, ` failed `, err)
+			continue
+		}
+
+		body, err := ioutil.ReadAll(io.LimitReader(res.Body, maxHealthBody))
+		res.Body.Close()
+
+		if err != nil || res.StatusCode != status {
+			continue
+		}
+
+		return &HealthResponse{
+			Container:   container.ID,
+			Status:      res.StatusCode,
+			ContentType: res.Header.Get("Content-Type"),
+			Body:        body,
+		}, nil
+	}
+
+	return nil, ErrNoHealthyReplica
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"github.com/deployithq/deployit/daemon/env"
+	"time"
+)
+
+// Deploys kept in service history, oldest ones are dropped
+const maxHistory = 5
+
+// HistoryEntry is image service ran after successful start, deploy or rollback
+type HistoryEntry struct {
+	Image      string    `json:"image" yaml:"image"`
+	Tag        string    `json:"tag" yaml:"tag"`
+	Digest     string    `json:"digest,omitempty" yaml:"digest,omitempty"`
+	Containers []string  `json:"containers" yaml:"containers"`
+	Rollback   bool      `json:"rollback,omitempty" yaml:"rollback,omitempty"`
+	At         time.Time `json:"at" yaml:"at"`
+}
+
+func (h HistoryEntry) same(s *Service) bool {
+	return h.Image == s.Config.Image && h.Tag == s.Tag && h.Digest == s.Digest
+}
+
+// Append current image to history, start of image which is already last only refreshes the entry
+func (s *Service) recordHistory(rollback bool) {
+
+	containers := []string{}
+	for _, container := range s.sortedContainers() {
+		containers = append(containers, container.ID)
+	}
+
+	entry := HistoryEntry{
+		Image:      s.Config.Image,
+		Tag:        s.Tag,
+		Digest:     s.Digest,
+		Containers: containers,
+		Rollback:   rollback,
+		At:         time.Now().UTC(),
+	}
+
+	if n := len(s.History); n > 0 && !rollback && s.History[n-1].same(s) {
+		entry.Rollback = s.History[n-1].Rollback
+		s.History[n-1] = entry
+		return
+	}
+
+	s.History = append(s.History, entry)
+	if len(s.History) > maxHistory {
+		s.History = s.History[len(s.History)-maxHistory:]
+	}
+}
+
+// Rollback switches service back to the image of previous history entry and recreates containers,
+// rollback is recorded as new entry so the next one rolls forward again
+func (s *Service) Rollback(e *env.Env) error {
+	e = s.logEnv(e)
+	e.Log.Info(`Rollback service `, s.Name)
+
+	if s.UUID == "" {
+		return ErrServiceNotFound
+	}
+
+	if err := checkDriver(e); err != nil {
+		return err
+	}
+
+	s.beginTransition(e, TransitionRollingBack)
+	defer s.endTransition(e)
+
+	n := len(s.History)
+	if n < 2 {
+		// Services updated before history was recorded
+		if s.PreviousImage != "" {
+			if err := s.switchImage(e, s.PreviousImage); err != nil {
+				return err
+			}
+
+			s.recordHistory(true)
+
+			return s.Update(e)
+		}
+
+		return ErrNoPreviousDeploy
+	}
+
+	previous := s.History[n-2]
+
+	prevImage, prevTag, prevDigest := s.Config.Image, s.Tag, s.Digest
+
+	revert := func(err error) error {
+		e.Log.Error(err)
+		s.Config.Image, s.Tag, s.Digest = prevImage, prevTag, prevDigest
+		s.Update(e)
+		return err
+	}
+
+	s.Config.Image, s.Tag, s.Digest = previous.Image, previous.Tag, previous.Digest
+
+	e.Log.Info(`Roll service `, s.Name, ` back to `, s.image())
+
+	// Tags are pulled again since they could have moved, digests are pulled when missing
+	if err := s.pullImages(context.Background(), e); err != nil {
+		return revert(err)
+	}
+
+	if err := s.replaceContainers(e); err != nil {
+		return revert(err)
+	}
+
+	s.recordHistory(true)
+
+	return s.Update(e)
+}
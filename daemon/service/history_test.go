@@ -0,0 +1,167 @@
+package service
+
+import (
+	"fmt"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"testing"
+)
+
+// Driver which pulls and starts every container, pulled images are recorded
+type deployContainers struct {
+	interfaces.IContainers
+	pulled  *[]string
+	started *int
+}
+
+func (c deployContainers) Ping() error {
+	return nil
+}
+
+func (c deployContainers) PullImage(image interfaces.Image) error {
+	*c.pulled = append(*c.pulled, image.Name)
+	return nil
+}
+
+func (c deployContainers) InspectImage(image *interfaces.Image) error {
+	return nil
+}
+
+func (c deployContainers) StartContainer(container *interfaces.Container) error {
+	if container.CID == "" {
+		*c.started++
+		container.CID = fmt.Sprintf("c%d", *c.started)
+	}
+	return nil
+}
+
+func (c deployContainers) InspectContainer(container *interfaces.Container) error {
+	container.State.Running = true
+	return nil
+}
+
+func (c deployContainers) StopContainer(*interfaces.Container) error {
+	return nil
+}
+
+func (c deployContainers) RemoveContainer(*interfaces.Container) error {
+	return nil
+}
+
+func TestRecordHistory(t *testing.T) {
+
+	entry := func(image string, rollback bool) HistoryEntry {
+		return HistoryEntry{Image: image, Tag: "latest", Rollback: rollback}
+	}
+
+	tests := []struct {
+		name     string
+		history  []HistoryEntry
+		image    string
+		rollback bool
+		want     []HistoryEntry
+	}{
+		{"first deploy", nil, "app:1", false, []HistoryEntry{entry("app:1", false)}},
+		{"new image", []HistoryEntry{entry("app:1", false)}, "app:2", false,
+			[]HistoryEntry{entry("app:1", false), entry("app:2", false)}},
+		{"same image refreshed", []HistoryEntry{entry("app:1", false)}, "app:1", false,
+			[]HistoryEntry{entry("app:1", false)}},
+		{"restart keeps rollback", []HistoryEntry{entry("app:1", false), entry("app:2", true)}, "app:2", false,
+			[]HistoryEntry{entry("app:1", false), entry("app:2", true)}},
+		{"rollback appended", []HistoryEntry{entry("app:1", false), entry("app:2", false)}, "app:1", true,
+			[]HistoryEntry{entry("app:1", false), entry("app:2", false), entry("app:1", true)}},
+		{"oldest dropped", []HistoryEntry{entry("app:1", false), entry("app:2", false), entry("app:3", false),
+			entry("app:4", false), entry("app:5", false)}, "app:6", false,
+			[]HistoryEntry{entry("app:2", false), entry("app:3", false), entry("app:4", false),
+				entry("app:5", false), entry("app:6", false)}},
+	}
+
+	for _, tt := range tests {
+
+		s := &Service{Tag: "latest", Config: Config{Image: tt.image}, History: tt.history}
+		s.recordHistory(tt.rollback)
+
+		if len(s.History) != len(tt.want) {
+			t.Errorf("%s: history has %d entries, want %d", tt.name, len(s.History), len(tt.want))
+			continue
+		}
+
+		for i, want := range tt.want {
+			got := s.History[i]
+			if got.Image != want.Image || got.Rollback != want.Rollback {
+				t.Errorf("%s: entry %d = %s rollback %v, want %s rollback %v",
+					tt.name, i, got.Image, got.Rollback, want.Image, want.Rollback)
+			}
+		}
+	}
+}
+
+func TestRollback(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		image    string
+		previous string
+		history  []string
+		want     string
+		err      error
+	}{
+		{"previous entry", "app:3", "", []string{"app:1", "app:2", "app:3"}, "app:2", nil},
+		{"previous image without history", "app:2", "app:1", nil, "app:1", nil},
+		{"nothing to roll back to", "app:1", "", []string{"app:1"}, "app:1", ErrNoPreviousDeploy},
+	}
+
+	for _, tt := range tests {
+
+		e := testEnv(t)
+
+		pulled, started := []string{}, 0
+		e.Containers = deployContainers{pulled: &pulled, started: &started}
+
+		s := &Service{
+			UUID:          "uuid-web",
+			Name:          "web",
+			Tag:           "latest",
+			Version:       1,
+			Replicas:      1,
+			Desired:       DesiredRunning,
+			Config:        Config{Image: tt.image},
+			PreviousImage: tt.previous,
+			Containers:    map[string]*Container{"old": {ID: "old"}},
+		}
+
+		for _, image := range tt.history {
+			s.History = append(s.History, HistoryEntry{Image: image, Tag: "latest"})
+		}
+
+		if err := e.LDB.Write(storageKey(s.Name), s); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := s.Rollback(e); err != tt.err {
+			t.Errorf("%s: Rollback() = %v, want %v", tt.name, err, tt.err)
+			continue
+		}
+
+		if s.Config.Image != tt.want {
+			t.Errorf("%s: image = %s, want %s", tt.name, s.Config.Image, tt.want)
+		}
+
+		if tt.err != nil {
+			continue
+		}
+
+		if len(pulled) == 0 || pulled[0] != tt.want {
+			t.Errorf("%s: pulled %v, want %s", tt.name, pulled, tt.want)
+		}
+
+		last := s.History[len(s.History)-1]
+		if last.Image != tt.want || !last.Rollback {
+			t.Errorf("%s: last history entry = %s rollback %v, want rollback to %s",
+				tt.name, last.Image, last.Rollback, tt.want)
+		}
+
+		if _, ok := s.Containers["old"]; ok || len(s.Containers) != 1 {
+			t.Errorf("%s: containers = %v, want one new container", tt.name, s.Containers)
+		}
+	}
+}
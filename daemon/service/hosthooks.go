@@ -0,0 +1,109 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"os"
+	"os/exec"
+	"time"
+)
+
+const (
+	HookPreDeploy  = `pre_deploy`
+	HookPostDeploy = `post_deploy`
+)
+
+const defaultHookTimeout = 60 * time.Second
+
+// Output kept from host hook, the rest is cut off
+const maxHookOutput = 64 * 1024
+
+// HookResult is outcome of command run on daemon host during deploy
+type HookResult struct {
+	Stage    string   `json:"stage" yaml:"stage"`
+	Command  []string `json:"command" yaml:"command"`
+	Output   string   `json:"output" yaml:"output"`
+	ExitCode int      `json:"exit_code" yaml:"exit_code"`
+	Error    string   `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+func (t Timeouts) hook() time.Duration {
+	if t.Hook > 0 {
+		return seconds(t.Hook)
+	}
+
+	return defaultHookTimeout
+}
+
+// Host hooks run any command as daemon user, reject them unless daemon allows it
+func (c *Config) checkHostHooks(e *env.Env) error {
+
+	if len(c.PreDeployHost) == 0 && len(c.PostDeployHost) == 0 {
+		return nil
+	}
+
+	if !e.AllowHostHooks {
+		return ErrHostHooksForbidden
+	}
+
+	return nil
+}
+
+// Run hook command on daemon host and record its output in service,
+// deploy details are passed to command in DEPLOYIT_* variables
+func (s *Service) runHostHook(e *env.Env, stage string, command []string) error {
+
+	if len(command) == 0 {
+		return nil
+	}
+
+	if err := s.Config.checkHostHooks(e); err != nil {
+		return err
+	}
+
+	e.Log.Info(`Run `, stage, ` host hook of service `, s.Name)
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.Config.Timeouts.hook())
+	defer cancel()
+
+	var output bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	cmd.Env = append(os.Environ(),
+		"DEPLOYIT_SERVICE="+s.Name,
+		"DEPLOYIT_TAG="+s.Tag,
+		"DEPLOYIT_DEPLOY_ID="+s.DeployID,
+	)
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("%s host hook timed out after %s", stage, s.Config.Timeouts.hook())
+	}
+
+	result := HookResult{
+		Stage:   stage,
+		Command: command,
+		Output:  output.String(),
+	}
+
+	if len(result.Output) > maxHookOutput {
+		result.Output = result.Output[:maxHookOutput]
+	}
+
+	if cmd.ProcessState != nil {
+		result.ExitCode = cmd.ProcessState.ExitCode()
+	}
+
+	if err != nil {
+		result.Error = err.Error()
+		err = fmt.Errorf("%s host hook failed: %s", stage, err)
+	}
+
+	s.HookResults = append(s.HookResults, result)
+
+	return err
+}
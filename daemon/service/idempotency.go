@@ -0,0 +1,71 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"time"
+)
+
+const deployKeyPrefix = `deploys`
+
+// IdempotencyTTL is how long deploy result is returned for repeated key
+var IdempotencyTTL = 24 * time.Hour
+
+// Pending record older than this is left by interrupted deploy and is ignored
+const pendingDeployTTL = time.Hour
+
+// Result of deploy made with idempotency key
+type deployRecord struct {
+	Tag     string    `json:"tag"`
+	Error   string    `json:"error"`
+	Pending bool      `json:"pending"`
+	At      time.Time `json:"at"`
+}
+
+func deployKey(service, key string) string {
+	return fmt.Sprintf("%s/%s/%s", deployKeyPrefix, service, key)
+}
+
+// Run deploy once per idempotency key, repeated calls within TTL get result of the first one
+func (s *Service) deployOnce(e *env.Env, key string, deploy func() error) error {
+
+	if !validName.MatchString(key) {
+		return ErrInvalidIdempotencyKey
+	}
+
+	record := new(deployRecord)
+	err := e.LDB.Read(deployKey(s.Name, key), record)
+
+	if err == nil && time.Since(record.At) < IdempotencyTTL && !(record.Pending && time.Since(record.At) > pendingDeployTTL) {
+
+		e.Log.Info(`Deploy of service `, s.Name, ` with key `, key, ` was already made`)
+
+		switch {
+		case record.Pending:
+			return ErrDeployInProgress
+		case record.Error != "":
+			return errors.New(record.Error)
+		}
+
+		return nil
+	}
+
+	record = &deployRecord{Pending: true, At: time.Now()}
+	if err := e.LDB.Write(deployKey(s.Name, key), record); err != nil {
+		return err
+	}
+
+	err = deploy()
+
+	record = &deployRecord{Tag: s.Tag, At: time.Now()}
+	if err != nil {
+		record.Error = err.Error()
+	}
+
+	if err := e.LDB.Write(deployKey(s.Name, key), record); err != nil {
+		e.Log.Error(err)
+	}
+
+	return err
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"github.com/deployithq/deployit/utils"
+	"strings"
+)
+
+// RunningImages returns image reference each container was actually created from
+func (s *Service) RunningImages(e *env.Env) (map[string]string, error) {
+	e.Log.Debug(`Running images of service `, s.Name)
+
+	images := make(map[string]string)
+
+	if s.UUID == "" {
+		return images, ErrServiceNotFound
+	}
+
+	for id := range s.Containers {
+
+		c := &interfaces.Container{CID: id}
+		if err := e.Containers.InspectContainer(c); err != nil {
+			e.Log.Error(err)
+			return images, err
+		}
+
+		images[id] = c.Image
+	}
+
+	return images, nil
+}
+
+// Converged reports whether every container runs configured image
+func (s *Service) Converged(e *env.Env) (bool, error) {
+
+	images, err := s.RunningImages(e)
+	if err != nil {
+		return false, err
+	}
+
+	for _, image := range images {
+		if image != s.image() {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// UpdateImage switches service to image, pulls it and replaces containers,
+// previous image is kept for rollback
+func (s *Service) UpdateImage(e *env.Env, image string) error {
+	e.Log.Info(`Update image of service `, s.Name, ` to `, image)
+
+	if s.UUID == "" {
+		return ErrServiceNotFound
+	}
+
+	if strings.TrimSpace(image) == "" {
+		return ErrImageRequired
+	}
+
+	if err := s.switchImage(e, image); err != nil {
+		return err
+	}
+
+	s.recordHistory(false)
+
+	return s.Update(e)
+}
+
+// Pull image and replace containers with ones created from it, service is reverted to
+// previous image if it fails, caller records history entry of switch
+func (s *Service) switchImage(e *env.Env, image string) error {
+
+	prevImage, prevTag, prevDigest, prevPrevious := s.Config.Image, s.Tag, s.Digest, s.PreviousImage
+
+	revert := func(err error) error {
+		s.Config.Image, s.Tag, s.Digest, s.PreviousImage = prevImage, prevTag, prevDigest, prevPrevious
+		s.Update(e)
+		return err
+	}
+
+	_, _, tag := utils.ParseImage(image)
+	if tag == "" {
+		tag = `latest`
+	}
+
+	s.PreviousImage = s.Config.Image
+	s.Config.Image = image
+	s.Tag = tag
+	s.Digest = ""
+
+	if err := s.Pull(e); err != nil {
+		return revert(err)
+	}
+
+	if err := s.scanImage(e); err != nil {
+		return revert(err)
+	}
+
+	if err := s.replaceContainers(e); err != nil {
+		return revert(err)
+	}
+
+	return nil
+}
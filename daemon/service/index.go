@@ -0,0 +1,121 @@
+package service
+
+import (
+	"github.com/deployithq/deployit/daemon/env"
+	"sort"
+	"sync"
+)
+
+// Summary of service served from in-memory index
+type Summary struct {
+	Name        string            `json:"name"`
+	Tag         string            `json:"tag"`
+	Tags        []string          `json:"tags,omitempty"`
+	Image       string            `json:"image"`
+	Desired     string            `json:"desired"`
+	Suspended   bool              `json:"suspended"`
+	Maintenance bool              `json:"maintenance,omitempty"`
+	Transition  string            `json:"transition,omitempty"`
+	Replicas    int               `json:"replicas"`
+	Containers  int               `json:"containers"`
+	Labels      map[string]string `json:"labels,omitempty"`
+}
+
+// Summaries of stored services by name, kept in sync with every service write
+var index = struct {
+	sync.RWMutex
+	built    bool
+	services map[string]Summary
+}{services: make(map[string]Summary)}
+
+func (s *Service) summary() Summary {
+	return Summary{
+		Name:        s.Name,
+		Tag:         s.Tag,
+		Tags:        s.Tags,
+		Image:       s.Config.Image,
+		Desired:     s.Desired,
+		Suspended:   s.Suspended,
+		Maintenance: s.Maintenance,
+		Transition:  s.Transition,
+		Replicas:    s.replicas(),
+		Containers:  len(s.Containers),
+		Labels:      s.Config.Labels,
+	}
+}
+
+func indexService(s *Service) {
+	index.Lock()
+	index.services[s.Name] = s.summary()
+	index.Unlock()
+}
+
+func unindexService(name string) {
+	index.Lock()
+	delete(index.services, name)
+	index.Unlock()
+}
+
+// RebuildIndex reads all stored services into index, called on daemon start
+func RebuildIndex(e *env.Env) error {
+	e.Log.Info(`Rebuild services index`)
+
+	services, err := list(e)
+	if err != nil {
+		return err
+	}
+
+	summaries := make(map[string]Summary)
+	for _, s := range services {
+		summaries[s.Name] = s.summary()
+	}
+
+	index.Lock()
+	index.services = summaries
+	index.built = true
+	index.Unlock()
+
+	return nil
+}
+
+// List returns summaries of all services sorted by name
+func List(e *env.Env) ([]Summary, error) {
+	return listIndex(e, func(Summary) bool { return true })
+}
+
+// ListByLabel returns summaries of services labeled with key and value
+func ListByLabel(e *env.Env, key, value string) ([]Summary, error) {
+	return listIndex(e, func(s Summary) bool {
+		v, ok := s.Labels[key]
+		return ok && v == value
+	})
+}
+
+func listIndex(e *env.Env, match func(Summary) bool) ([]Summary, error) {
+
+	index.RLock()
+	built := index.built
+	index.RUnlock()
+
+	if !built {
+		if err := RebuildIndex(e); err != nil {
+			return nil, err
+		}
+	}
+
+	summaries := []Summary{}
+
+	index.RLock()
+	for _, s := range index.services {
+		if match(s) {
+			summaries = append(summaries, s)
+		}
+	}
+	index.RUnlock()
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Name < summaries[j].Name
+	})
+
+	return summaries, nil
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+)
+
+// ContainerSpec is a one-shot container, like init container running migrations
+type ContainerSpec struct {
+	Name    string   `json:"name" yaml:"name"`
+	Image   string   `json:"image" yaml:"image"`
+	Env     []string `json:"env" yaml:"env"`
+	CMD     []string `json:"cmd" yaml:"cmd"`
+	Volumes []string `json:"volumes" yaml:"volumes"`
+}
+
+// Run init containers one by one to completion, main containers are not started
+// if any of them fails or exits with non-zero code
+func (s *Service) runInitContainers(e *env.Env) error {
+
+	for _, spec := range s.Config.InitContainers {
+
+		e.Log.Info(`Run init container `, spec.Name, ` of service `, s.Name)
+
+		if err := s.runInitContainer(e, spec); err != nil {
+			e.Log.Error(err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) runInitContainer(e *env.Env, spec ContainerSpec) error {
+
+	if !e.HostLocked {
+		return ErrHostNotLocked
+	}
+
+	c := &interfaces.Container{
+		Config: interfaces.Config{
+			Image:            spec.Image,
+			Env:              spec.Env,
+			Cmd:              spec.CMD,
+			Volumes:          spec.Volumes,
+			RequiredPlatform: s.Config.RequiredPlatform,
+		},
+		HostConfig: interfaces.HostConfig{
+			Binds: spec.Volumes,
+		},
+		Placement: s.Config.Placement,
+	}
+
+	if err := e.Containers.StartContainer(c); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := e.Containers.RemoveContainer(&interfaces.Container{CID: c.CID}); err != nil {
+			e.Log.Error(err)
+		}
+	}()
+
+	code, err := e.Containers.WaitContainer(c)
+	if err != nil {
+		return err
+	}
+
+	if code != 0 {
+		return fmt.Errorf("init container %s exited with code %d", spec.Name, code)
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"sync"
+	"time"
+)
+
+// InspectTTL is how long inspection results are served from cache
+var InspectTTL = 3 * time.Second
+
+type Report struct {
+	Name             string                 `json:"name"`
+	Tag              string                 `json:"tag"`
+	Tags             []string               `json:"tags"`
+	Image            string                 `json:"image"`
+	ImageSize        int64                  `json:"image_size"`
+	ImageLayers      int                    `json:"image_layers"`
+	LastDeployBy     string                 `json:"last_deploy_by"`
+	LastDeployReason string                 `json:"last_deploy_reason"`
+	Containers       []interfaces.Container `json:"containers"`
+	Exits            []Exit                 `json:"exits,omitempty"`
+	Failure          *Failure               `json:"failure,omitempty"`
+	Inspected        time.Time              `json:"inspected"`
+
+	// Desired replicas of service and containers found running,
+	// running is only counted in live reports
+	DesiredReplicas int `json:"desired_replicas"`
+	RunningReplicas int `json:"running_replicas"`
+
+	// Deploying, rolling back, running, degraded, stopped or suspended
+	Status string `json:"status"`
+
+	// Live is false when containers state is not requested from driver
+	Live   bool    `json:"live"`
+	Health *Health `json:"health,omitempty"`
+
+	// Driver healthcheck of containers, their results are in containers state
+	Healthcheck *interfaces.Healthcheck `json:"healthcheck,omitempty"`
+}
+
+var inspectCache = struct {
+	sync.Mutex
+	reports map[string]*Report
+}{reports: make(map[string]*Report)}
+
+// Inspect returns state of every service container
+func (s *Service) Inspect(e *env.Env) (*Report, error) {
+	e.Log.Debug(`Inspect service `, s.Name)
+
+	if s.UUID == "" {
+		return nil, ErrServiceNotFound
+	}
+
+	inspectCache.Lock()
+	cached, ok := inspectCache.reports[s.Name]
+	inspectCache.Unlock()
+
+	if ok && time.Since(cached.Inspected) < InspectTTL {
+		return cached, nil
+	}
+
+	// Stored state is served while driver is down
+	if err := checkDriver(e); err != nil {
+		return s.InspectStored(e)
+	}
+
+	report := &Report{
+		Name:             s.Name,
+		Tag:              s.Tag,
+		Tags:             s.Tags,
+		Image:            s.image(),
+		ImageSize:        s.ImageSize,
+		ImageLayers:      s.ImageLayers,
+		LastDeployBy:     s.LastDeployBy,
+		LastDeployReason: s.LastDeployReason,
+		Containers:       []interfaces.Container{},
+		Exits:            s.Exits,
+		Failure:          s.Failure,
+		Inspected:        time.Now(),
+		Live:             true,
+		DesiredReplicas:  s.replicas(),
+	}
+
+	if health, err := s.LastHealth(e); err == nil {
+		report.Health = health
+	}
+
+	changed := false
+
+	for _, container := range s.Containers {
+		c := interfaces.Container{CID: container.ID}
+		if err := e.Containers.InspectContainer(&c); err != nil {
+			if s.Config.AutoRemove && isNoSuchContainer(err) {
+				c.State.Completed = true
+				report.Containers = append(report.Containers, c)
+				continue
+			}
+
+			e.Log.Error(err)
+			return nil, err
+		}
+
+		if container.refresh(c) {
+			changed = true
+		}
+
+		if report.Healthcheck == nil {
+			report.Healthcheck = c.Config.Healthcheck
+		}
+
+		if c.State.Running {
+			report.RunningReplicas++
+		}
+
+		c.Config.Env = s.Config.maskEnv(c.Config.Env)
+
+		report.Containers = append(report.Containers, c)
+	}
+
+	report.Status = s.status(report)
+
+	// Refreshed records are stored best effort, concurrent change keeps its own
+	if changed && e.HostLocked {
+		if err := s.Update(e); err != nil {
+			e.Log.Error(err)
+		}
+	}
+
+	inspectCache.Lock()
+	inspectCache.reports[s.Name] = report
+	inspectCache.Unlock()
+
+	return report, nil
+}
+
+// Drop cached inspection of service, called on every service change
+func invalidateInspect(name string) {
+	inspectCache.Lock()
+	delete(inspectCache.reports, name)
+	inspectCache.Unlock()
+}
+
+// InspectStored returns report built from stored records only,
+// containers state comes from the last health report
+func (s *Service) InspectStored(e *env.Env) (*Report, error) {
+	e.Log.Debug(`Inspect stored service `, s.Name)
+
+	if s.UUID == "" {
+		return nil, ErrServiceNotFound
+	}
+
+	report := &Report{
+		Name:             s.Name,
+		Tag:              s.Tag,
+		Tags:             s.Tags,
+		Image:            s.image(),
+		ImageSize:        s.ImageSize,
+		ImageLayers:      s.ImageLayers,
+		LastDeployBy:     s.LastDeployBy,
+		LastDeployReason: s.LastDeployReason,
+		Containers:       []interfaces.Container{},
+		Exits:            s.Exits,
+		Failure:          s.Failure,
+		Inspected:        time.Now(),
+		DesiredReplicas:  s.replicas(),
+	}
+
+	if health, err := s.LastHealth(e); err == nil {
+		report.Health = health
+	}
+
+	report.Status = s.status(report)
+
+	return report, nil
+}
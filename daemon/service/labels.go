@@ -0,0 +1,81 @@
+package service
+
+import (
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"github.com/satori/go.uuid"
+	"time"
+)
+
+// Container labels carrying deploy provenance
+const (
+	LabelService    = `deployit.service`
+	LabelDeployID   = `deployit.deploy.id`
+	LabelDeployTime = `deployit.deploy.time`
+	LabelDeployBy   = `deployit.deploy.by`
+	LabelDeployTag  = `deployit.deploy.tag`
+	LabelConfig     = `deployit.config`
+)
+
+// Deploy provenance read back from container labels
+type DeployLabels struct {
+	Service string    `json:"service"`
+	ID      string    `json:"id"`
+	Time    time.Time `json:"time"`
+	By      string    `json:"by"`
+	Tag     string    `json:"tag"`
+}
+
+// New deploy id and time stamped on containers created from now on
+func (s *Service) stampDeploy() {
+	s.DeployID = uuid.NewV4().String()
+	s.DeployedAt = time.Now().UTC()
+}
+
+// Service labels with deploy provenance, which user labels can not override
+func (s *Service) labels() (map[string]string, error) {
+
+	checksum, err := s.configChecksum()
+	if err != nil {
+		return nil, err
+	}
+
+	labels := make(map[string]string)
+	for key, value := range s.Config.Labels {
+		labels[key] = value
+	}
+
+	labels[LabelService] = s.Name
+	labels[LabelDeployID] = s.DeployID
+	labels[LabelDeployTime] = s.DeployedAt.Format(time.RFC3339)
+	labels[LabelDeployBy] = s.LastDeployBy
+	labels[LabelDeployTag] = s.Tag
+	labels[LabelConfig] = checksum
+
+	return labels, nil
+}
+
+// ReadDeployLabels returns deploy provenance of running container
+func ReadDeployLabels(e *env.Env, id string) (*DeployLabels, error) {
+
+	c := &interfaces.Container{CID: id}
+	if err := e.Containers.InspectContainer(c); err != nil {
+		return nil, err
+	}
+
+	labels := c.Config.Labels
+
+	deploy := &DeployLabels{
+		Service: labels[LabelService],
+		ID:      labels[LabelDeployID],
+		By:      labels[LabelDeployBy],
+		Tag:     labels[LabelDeployTag],
+	}
+
+	// Containers created before labels were added have no deploy time
+	if t, err := time.Parse(time.RFC3339, labels[LabelDeployTime]); err == nil {
+		deploy.Time = t
+	}
+
+	return deploy, nil
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// Defaults applied to every service config loaded from file
+var DefaultsPath = fmt.Sprintf("%s/defaults.yaml", env.Default_root_path)
+
+// Prefix of env variables overriding top level config fields,
+// like DEPLOYIT_SERVICE_MEMORY=512 or DEPLOYIT_SERVICE_PORTS=[80]
+const envConfigPrefix = `DEPLOYIT_SERVICE_`
+
+// ConfigSource is one layer of service config
+type ConfigSource interface {
+	Load() (map[interface{}]interface{}, error)
+}
+
+// FileSource is yaml config file, optional file is skipped if it does not exist
+type FileSource struct {
+	Path     string
+	Optional bool
+}
+
+func (f FileSource) Load() (map[interface{}]interface{}, error) {
+
+	values := make(map[interface{}]interface{})
+
+	data, err := ioutil.ReadFile(f.Path)
+	if os.IsNotExist(err) && f.Optional {
+		return values, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("%s: %s", f.Path, err)
+	}
+
+	return values, nil
+}
+
+// EnvSource overrides top level fields from env variables with prefix,
+// values are parsed as yaml so numbers and lists are allowed
+type EnvSource struct {
+	Prefix string
+}
+
+func (s EnvSource) Load() (map[interface{}]interface{}, error) {
+
+	values := make(map[interface{}]interface{})
+
+	for _, variable := range os.Environ() {
+
+		parts := strings.SplitN(variable, "=", 2)
+		if len(parts) != 2 || !strings.HasPrefix(parts[0], s.Prefix) {
+			continue
+		}
+
+		var value interface{}
+		if err := yaml.Unmarshal([]byte(parts[1]), &value); err != nil {
+			return nil, fmt.Errorf("%s: %s", parts[0], err)
+		}
+
+		values[strings.ToLower(strings.TrimPrefix(parts[0], s.Prefix))] = value
+	}
+
+	return values, nil
+}
+
+// Config layers in order of precedence, later ones override earlier:
+// daemon defaults, service file, env variables
+func configSources(path string) []ConfigSource {
+	return []ConfigSource{
+		FileSource{Path: DefaultsPath, Optional: true},
+		FileSource{Path: path},
+		EnvSource{Prefix: envConfigPrefix},
+	}
+}
+
+// LoadConfig deep merges sources in order, later sources override values of earlier ones,
+// nested maps are merged and lists are replaced, unknown fields are reported as errors
+func LoadConfig(sources ...ConfigSource) (*Config, error) {
+
+	merged := make(map[interface{}]interface{})
+
+	for _, source := range sources {
+
+		values, err := source.Load()
+		if err != nil {
+			return nil, err
+		}
+
+		mergeValues(merged, values)
+	}
+
+	data, err := yaml.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+
+	config := new(Config)
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+func mergeValues(dst, src map[interface{}]interface{}) {
+
+	for key, value := range src {
+
+		from, ok := value.(map[interface{}]interface{})
+		to, exists := dst[key].(map[interface{}]interface{})
+
+		if ok && exists {
+			mergeValues(to, from)
+			continue
+		}
+
+		dst[key] = value
+	}
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Source of fixed values
+type mapSource map[interface{}]interface{}
+
+func (m mapSource) Load() (map[interface{}]interface{}, error) {
+	return m, nil
+}
+
+func TestLoadConfig(t *testing.T) {
+
+	defaults := mapSource{
+		"image":    "nginx",
+		"memory":   128,
+		"ports":    []interface{}{"80"},
+		"timeouts": map[interface{}]interface{}{"pull": 30, "start": 10},
+	}
+
+	tests := []struct {
+		name     string
+		override mapSource
+		want     func(c *Config)
+	}{
+		{"defaults", mapSource{}, func(c *Config) {}},
+		{"scalar override", mapSource{"memory": 512}, func(c *Config) { c.Memory = 512 }},
+		{"list replaced", mapSource{"ports": []interface{}{"8080", "8443"}}, func(c *Config) {
+			c.Ports = []string{"8080", "8443"}
+		}},
+		{"nested merged", mapSource{"timeouts": map[interface{}]interface{}{"pull": 60}}, func(c *Config) {
+			c.Timeouts.Pull = 60
+		}},
+	}
+
+	for _, tt := range tests {
+
+		want := &Config{Image: "nginx", Memory: 128, Ports: []string{"80"}, Timeouts: Timeouts{Pull: 30, Start: 10}}
+		tt.want(want)
+
+		config, err := LoadConfig(defaults, tt.override)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		if !reflect.DeepEqual(config, want) {
+			t.Errorf("%s: LoadConfig() = %+v, want %+v", tt.name, config, want)
+		}
+	}
+
+	if _, err := LoadConfig(mapSource{"unknown": true}); err == nil {
+		t.Error("LoadConfig() of unknown field returned nil error")
+	}
+}
+
+func TestConfigSourcesPrecedence(t *testing.T) {
+
+	dir := t.TempDir()
+
+	defaults := filepath.Join(dir, "defaults.yaml")
+	if err := ioutil.WriteFile(defaults, []byte("image: nginx\nmemory: 128\ncpus: 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "web.yaml")
+	if err := ioutil.WriteFile(path, []byte("memory: 256\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	previous := DefaultsPath
+	DefaultsPath = defaults
+	defer func() { DefaultsPath = previous }()
+
+	os.Setenv(envConfigPrefix+"CPUS", "2")
+	defer os.Unsetenv(envConfigPrefix + "CPUS")
+
+	config, err := LoadConfig(configSources(path)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Image != "nginx" || config.Memory != 256 || config.CPUs != 2 {
+		t.Errorf("LoadConfig() = image %s, memory %d, cpus %v, want nginx, 256, 2", config.Image, config.Memory, config.CPUs)
+	}
+
+	DefaultsPath = filepath.Join(dir, "missing.yaml")
+
+	if _, err := LoadConfig(configSources(path)...); err != nil {
+		t.Errorf("missing defaults file: %v", err)
+	}
+
+	if _, err := LoadConfig(configSources(filepath.Join(dir, "missing.yaml"))...); err == nil {
+		t.Error("missing service file returned nil error")
+	}
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"strings"
+)
+
+// Env variable log level is passed to service containers in
+const logLevelEnv = `DEPLOYIT_LOG_LEVEL`
+
+// Service log levels, from the most verbose
+var logLevels = map[string]int{
+	`debug`: 0,
+	`info`:  1,
+	`error`: 2,
+}
+
+// Daemon log which drops messages below service log level,
+// debug messages are still written only in daemon debug mode
+type levelLog struct {
+	interfaces.ILog
+	level int
+}
+
+func (l *levelLog) Debug(args ...interface{}) {
+	if l.level <= logLevels[`debug`] {
+		l.ILog.Debug(args...)
+	}
+}
+
+func (l *levelLog) Debugf(format string, args ...interface{}) {
+	if l.level <= logLevels[`debug`] {
+		l.ILog.Debugf(format, args...)
+	}
+}
+
+func (l *levelLog) Info(args ...interface{}) {
+	if l.level <= logLevels[`info`] {
+		l.ILog.Info(args...)
+	}
+}
+
+func (l *levelLog) Infof(format string, args ...interface{}) {
+	if l.level <= logLevels[`info`] {
+		l.ILog.Infof(format, args...)
+	}
+}
+
+// Env with daemon log limited to service log level
+func (s *Service) logEnv(e *env.Env) *env.Env {
+
+	level, ok := logLevels[strings.ToLower(s.Config.LogLevel)]
+	if !ok || s.Config.LogLevel == `` {
+		return e
+	}
+
+	if _, scoped := e.Log.(*levelLog); scoped {
+		return e
+	}
+
+	scoped := *e
+	scoped.Log = &levelLog{e.Log, level}
+
+	return &scoped
+}
+
+// Add log level variable unless service env sets it explicitly
+func (c *Config) withLogLevel(env []string) []string {
+
+	if c.LogLevel == `` {
+		return env
+	}
+
+	for _, variable := range env {
+		if strings.HasPrefix(variable, logLevelEnv+`=`) {
+			return env
+		}
+	}
+
+	return append(env, fmt.Sprintf("%s=%s", logLevelEnv, strings.ToLower(c.LogLevel)))
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"io"
+	"sort"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// Prefix of merged log lines, containers are told apart by replica index
+const DefaultLogsPrefix = `[{{.Service}}-{{.Index}}] `
+
+type LogsOptions struct {
+	Since time.Time `json:"since"`
+	Until time.Time `json:"until"`
+	Tail  int       `json:"tail"`
+
+	// Stream new lines until Context is done or containers stop
+	Follow  bool            `json:"follow"`
+	Context context.Context `json:"-"`
+
+	// Template of line prefix with {{.Service}}, {{.Index}} and {{.ID}} of container, lines are not prefixed if empty
+	Prefix string `json:"prefix"`
+}
+
+type logsVars struct {
+	Service string
+	Index   int
+	ID      string
+}
+
+// Logs writes logs of every service container to the writer, followed logs
+// of containers are streamed concurrently until context is done or containers stop
+func (s *Service) Logs(e *env.Env, w io.Writer, opts LogsOptions) error {
+	e.Log.Info(`Logs service `, s.Name)
+
+	if s.UUID == "" {
+		return ErrServiceNotFound
+	}
+
+	if !opts.Since.IsZero() && !opts.Until.IsZero() && opts.Until.Before(opts.Since) {
+		return ErrInvalidLogsRange
+	}
+
+	containers := s.sortedContainers()
+
+	// Interleaved lines of replicas could not be told apart otherwise
+	if opts.Follow && opts.Prefix == "" && len(containers) > 1 {
+		opts.Prefix = DefaultLogsPrefix
+	}
+
+	var prefix *template.Template
+	if opts.Prefix != "" {
+		var err error
+		if prefix, err = template.New("prefix").Parse(opts.Prefix); err != nil {
+			return err
+		}
+	}
+
+	if !opts.Follow {
+		for _, container := range containers {
+			if err := s.containerLogs(e, container, w, prefix, opts); err != nil {
+				e.Log.Error(err)
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if opts.Context == nil {
+		opts.Context = context.Background()
+	}
+
+	var (
+		wg   sync.WaitGroup
+		lock sync.Mutex
+		errs = ContainerErrors{}
+		out  = &lockedWriter{w: w}
+	)
+
+	for _, container := range containers {
+
+		wg.Add(1)
+
+		go func(container *Container) {
+			defer wg.Done()
+
+			err := s.containerLogs(e, container, out, prefix, opts)
+
+			// Removed container ends its stream, others are still followed
+			if err == nil || isNoSuchContainer(err) || opts.Context.Err() != nil {
+				return
+			}
+
+			e.Log.Error(err)
+
+			lock.Lock()
+			errs[container.ID] = err
+			lock.Unlock()
+		}(container)
+	}
+
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func (s *Service) containerLogs(e *env.Env, container *Container, w io.Writer, prefix *template.Template, opts LogsOptions) error {
+
+	var out, errs io.Writer = w, w
+	var flush []*prefixWriter
+
+	if prefix != nil {
+		var buf bytes.Buffer
+		if err := prefix.Execute(&buf, logsVars{Service: s.Name, Index: container.Index, ID: shortID(container.ID)}); err != nil {
+			return err
+		}
+
+		stdout := &prefixWriter{w: w, prefix: buf.Bytes()}
+		stderr := &prefixWriter{w: w, prefix: buf.Bytes()}
+
+		out, errs, flush = stdout, stderr, []*prefixWriter{stdout, stderr}
+	}
+
+	err := e.Containers.Logs(&interfaces.Container{
+		CID: container.ID,
+	}, interfaces.LogsOptions{
+		Since:        opts.Since,
+		Until:        opts.Until,
+		Tail:         opts.Tail,
+		Follow:       opts.Follow,
+		Context:      opts.Context,
+		OutputStream: out,
+		ErrorStream:  errs,
+	})
+
+	for _, pw := range flush {
+		pw.Flush()
+	}
+
+	return err
+}
+
+// Serializes writes of concurrently followed containers, prefixed lines are written whole
+type lockedWriter struct {
+	sync.Mutex
+	w io.Writer
+}
+
+func (lw *lockedWriter) Write(p []byte) (int, error) {
+	lw.Lock()
+	defer lw.Unlock()
+
+	return lw.w.Write(p)
+}
+
+// Containers ordered by replica index
+func (s *Service) sortedContainers() []*Container {
+
+	containers := []*Container{}
+	for _, container := range s.Containers {
+		containers = append(containers, container)
+	}
+
+	sort.Slice(containers, func(i, j int) bool {
+		return containers[i].Index < containers[j].Index
+	})
+
+	return containers
+}
+
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+
+	return id
+}
+
+// Writes every line with prefix, incomplete last line is written on flush
+type prefixWriter struct {
+	w      io.Writer
+	prefix []byte
+	buf    []byte
+}
+
+func (pw *prefixWriter) Write(p []byte) (int, error) {
+
+	pw.buf = append(pw.buf, p...)
+
+	for {
+		i := bytes.IndexByte(pw.buf, '\n')
+		if i == -1 {
+			break
+		}
+
+		if _, err := pw.w.Write(append(append([]byte{}, pw.prefix...), pw.buf[:i+1]...)); err != nil {
+			return len(p), err
+		}
+
+		pw.buf = pw.buf[i+1:]
+	}
+
+	return len(p), nil
+}
+
+func (pw *prefixWriter) Flush() error {
+
+	if len(pw.buf) == 0 {
+		return nil
+	}
+
+	_, err := pw.w.Write(append(append([]byte{}, pw.prefix...), pw.buf...))
+	pw.buf = nil
+
+	return err
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"io"
+	"log/syslog"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	sinkFile   = `file`
+	sinkSyslog = `syslog`
+	sinkHTTP   = `http`
+
+	maxForwardBackoff = 30 * time.Second
+)
+
+var errForwardStopped = errors.New("logs forwarding is stopped")
+
+// LogSink is external destination container logs are forwarded to
+type LogSink struct {
+	Type    string `json:"type" yaml:"type"`       // file, syslog or http
+	Path    string `json:"path" yaml:"path"`       // file only
+	Address string `json:"address" yaml:"address"` // syslog only, like udp://host:514, local syslog if empty
+	URL     string `json:"url" yaml:"url"`         // http only, log lines are posted as text
+}
+
+func (l *LogSink) open(tag string) (io.WriteCloser, error) {
+
+	switch l.Type {
+	case sinkFile:
+		return os.OpenFile(l.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+
+	case sinkSyslog:
+		if l.Address == "" {
+			return syslog.New(syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+		}
+
+		address, err := url.Parse(l.Address)
+		if err != nil {
+			return nil, err
+		}
+
+		return syslog.Dial(address.Scheme, address.Host, syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+
+	case sinkHTTP:
+		return &httpSink{url: l.URL, client: &http.Client{Timeout: 10 * time.Second}}, nil
+	}
+
+	return nil, fmt.Errorf("unknown log sink type %s", l.Type)
+}
+
+type httpSink struct {
+	url    string
+	client *http.Client
+}
+
+func (h *httpSink) Write(data []byte) (int, error) {
+
+	res, err := h.client.Post(h.url, "text/plain; charset=utf-8", bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+
+	res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return 0, fmt.Errorf("log sink responded with status %d", res.StatusCode)
+	}
+
+	return len(data), nil
+}
+
+func (h *httpSink) Close() error {
+	return nil
+}
+
+// Fails writes after forwarding is stopped, so following logs stream is aborted
+type forwardWriter struct {
+	w    io.Writer
+	done chan struct{}
+}
+
+func (f *forwardWriter) Write(data []byte) (int, error) {
+	select {
+	case <-f.done:
+		return 0, errForwardStopped
+	default:
+		return f.w.Write(data)
+	}
+}
+
+// ForwardLogs follows logs of every service container and ships them to configured sink,
+// stream is reopened with backoff when container or sink connection fails
+func (s *Service) ForwardLogs(e *env.Env) (func(), error) {
+	e.Log.Info(`Forward logs of service `, s.Name)
+
+	if s.UUID == "" {
+		return nil, ErrServiceNotFound
+	}
+
+	if s.Config.LogSink == nil {
+		return nil, ErrNoLogSink
+	}
+
+	sink := *s.Config.LogSink
+	done := make(chan struct{})
+
+	for _, container := range s.Containers {
+		go s.forward(e, sink, container.ID, done)
+	}
+
+	var once sync.Once
+
+	stop := func() {
+		once.Do(func() {
+			close(done)
+		})
+	}
+
+	return stop, nil
+}
+
+func (s *Service) forward(e *env.Env, sink LogSink, id string, done chan struct{}) {
+
+	backoff := time.Second
+	since := time.Time{}
+
+	for {
+		started := time.Now()
+
+		err := func() error {
+			out, err := sink.open(fmt.Sprintf("deployit/%s", s.Name))
+			if err != nil {
+				return err
+			}
+			defer out.Close()
+
+			w := &forwardWriter{w: out, done: done}
+
+			return e.Containers.Logs(&interfaces.Container{
+				CID: id,
+			}, interfaces.LogsOptions{
+				Since:        since,
+				Follow:       true,
+				OutputStream: w,
+				ErrorStream:  w,
+			})
+		}()
+
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		if err != nil {
+			e.Log.Error(err)
+		}
+
+		// Connection which worked for a while is retried fast again
+		if time.Since(started) > maxForwardBackoff {
+			backoff = time.Second
+		}
+
+		since = time.Now()
+
+		select {
+		case <-done:
+			return
+		case <-time.After(backoff):
+		}
+
+		if backoff *= 2; backoff > maxForwardBackoff {
+			backoff = maxForwardBackoff
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"github.com/deployithq/deployit/daemon/env"
+)
+
+// EnterMaintenance pauses reconciliation of service, containers stopped or removed
+// by hand are not started again until ExitMaintenance
+func (s *Service) EnterMaintenance(e *env.Env) error {
+	e.Log.Info(`Enter maintenance of service `, s.Name)
+
+	if s.UUID == "" {
+		return ErrServiceNotFound
+	}
+
+	s.Maintenance = true
+
+	if err := s.Update(e); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ExitMaintenance resumes reconciliation, which converges service on next run
+func (s *Service) ExitMaintenance(e *env.Env) error {
+	e.Log.Info(`Exit maintenance of service `, s.Name)
+
+	if s.UUID == "" {
+		return ErrServiceNotFound
+	}
+
+	s.Maintenance = false
+
+	if err := s.Update(e); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Metric names are scraped by monitoring, they should not be changed
+const (
+	metricRestarts       = `deployit_service_restarts_total`
+	metricGaveUp         = `deployit_service_restarts_exhausted_total`
+	metricDeployFailures = `deployit_service_deploy_failures_total`
+	metricDeployDuration = `deployit_service_deploy_duration_seconds`
+	metricPullDuration   = `deployit_service_pull_duration_seconds`
+	metricReplicas       = `deployit_service_replicas`
+	metricContainers     = `deployit_service_containers`
+)
+
+var metricHelp = map[string]string{
+	metricRestarts:       `Restarts of service made by daemon`,
+	metricGaveUp:         `Containers of service left dead after restart attempts were exhausted`,
+	metricDeployFailures: `Failed deploys of service`,
+	metricDeployDuration: `Duration of service deploys`,
+	metricPullDuration:   `Duration of service images pulls`,
+	metricReplicas:       `Desired replicas of service`,
+	metricContainers:     `Containers recorded for service`,
+}
+
+// Sum and count of observed durations
+type observation struct {
+	sum   float64
+	count int64
+}
+
+// Counters and durations by metric name and service
+var metrics = struct {
+	sync.Mutex
+	counters  map[string]map[string]int64
+	durations map[string]map[string]*observation
+}{
+	counters:  make(map[string]map[string]int64),
+	durations: make(map[string]map[string]*observation),
+}
+
+func countMetric(name, service string) {
+	metrics.Lock()
+	defer metrics.Unlock()
+
+	if metrics.counters[name] == nil {
+		metrics.counters[name] = make(map[string]int64)
+	}
+
+	metrics.counters[name][service]++
+}
+
+func observeMetric(name, service string, d time.Duration) {
+	metrics.Lock()
+	defer metrics.Unlock()
+
+	if metrics.durations[name] == nil {
+		metrics.durations[name] = make(map[string]*observation)
+	}
+
+	o, ok := metrics.durations[name][service]
+	if !ok {
+		o = new(observation)
+		metrics.durations[name][service] = o
+	}
+
+	o.sum += d.Seconds()
+	o.count++
+}
+
+// WriteMetrics writes metrics in prometheus text format,
+// replica counts are taken from services index
+func WriteMetrics(e *env.Env, w io.Writer) error {
+
+	summaries, err := List(e)
+	if err != nil {
+		return err
+	}
+
+	writeHeader := func(name, kind string) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, metricHelp[name], name, kind)
+	}
+
+	writeHeader(metricReplicas, `gauge`)
+	for _, s := range summaries {
+		fmt.Fprintf(w, "%s{service=%q} %d\n", metricReplicas, s.Name, s.Replicas)
+	}
+
+	writeHeader(metricContainers, `gauge`)
+	for _, s := range summaries {
+		fmt.Fprintf(w, "%s{service=%q} %d\n", metricContainers, s.Name, s.Containers)
+	}
+
+	metrics.Lock()
+	defer metrics.Unlock()
+
+	for _, name := range []string{metricRestarts, metricDeployFailures} {
+		writeHeader(name, `counter`)
+		for _, service := range sortedKeys(metrics.counters[name]) {
+			fmt.Fprintf(w, "%s{service=%q} %d\n", name, service, metrics.counters[name][service])
+		}
+	}
+
+	for _, name := range []string{metricDeployDuration, metricPullDuration} {
+		writeHeader(name, `summary`)
+
+		services := []string{}
+		for service := range metrics.durations[name] {
+			services = append(services, service)
+		}
+		sort.Strings(services)
+
+		for _, service := range services {
+			o := metrics.durations[name][service]
+			fmt.Fprintf(w, "%s_sum{service=%q} %g\n", name, service, o.sum)
+			fmt.Fprintf(w, "%s_count{service=%q} %d\n", name, service, o.count)
+		}
+	}
+
+	return nil
+}
+
+func sortedKeys(m map[string]int64) []string {
+
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
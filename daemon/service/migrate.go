@@ -0,0 +1,168 @@
+package service
+
+import (
+	"github.com/deployithq/deployit/daemon/env"
+	"sort"
+)
+
+// Schema of stored service records, increase it with a new migration step
+const schemaVersion = 3
+
+// Migration steps, migrations[i] upgrades record from schema i to i+1
+var migrations = []func(s *Service){
+	migrateDefaults,
+	migrateContainers,
+	migratePlatform,
+}
+
+// Records written before schema tracking lack desired state, replicas and indexes
+func migrateDefaults(s *Service) {
+
+	if s.Containers == nil {
+		s.Containers = make(map[string]*Container)
+	}
+
+	if s.Replicas < 1 {
+		s.Replicas = len(s.Containers)
+	}
+
+	if s.Desired == "" {
+		s.Desired = DesiredStopped
+		if len(s.Containers) > 0 {
+			s.Desired = DesiredRunning
+		}
+	}
+
+	if s.Version < 1 {
+		s.Version = 1
+	}
+
+	// Containers created before indexes were tracked all have index 0
+	ids := []string{}
+	for id := range s.Containers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for i, id := range ids {
+		s.Containers[id].Index = i
+	}
+}
+
+// Records written before containers metadata was tracked only have id and index,
+// image is the service one, name and driver state are filled on next inspect
+func migrateContainers(s *Service) {
+
+	for _, container := range s.Containers {
+		if container.Image == "" {
+			container.Image = s.image()
+		}
+	}
+}
+
+// Platform was renamed since image of other platform is only rejected, never pulled
+func migratePlatform(s *Service) {
+
+	if s.Config.Platform != "" && s.Config.RequiredPlatform == "" {
+		s.Config.RequiredPlatform = s.Config.Platform
+	}
+
+	s.Config.Platform = ""
+}
+
+// Migrate upgrades every stored service record to current schema,
+// it should run at daemon startup before services are used
+func Migrate(e *env.Env) error {
+	e.Log.Info(`Migrate service records`)
+
+	updateLock.Lock()
+	defer updateLock.Unlock()
+
+	if err := migrateLegacyKeys(e); err != nil {
+		return err
+	}
+
+	keys, err := e.LDB.List(storagePrefix)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+
+		s := new(Service)
+		if err := e.LDB.Read(key, s); err != nil {
+			e.Log.Error(err)
+			continue
+		}
+
+		if s.Schema >= schemaVersion {
+			continue
+		}
+
+		e.Log.Info(`Migrate service `, s.Name, ` from schema `, s.Schema)
+
+		for s.Schema < schemaVersion {
+			migrations[s.Schema](s)
+			s.Schema++
+		}
+
+		if err := e.LDB.Write(key, s); err != nil {
+			return err
+		}
+
+		invalidateInspect(s.Name)
+	}
+
+	return nil
+}
+
+// Records were stored under bare service name before storage prefix was introduced,
+// they are moved under storage key. Apps are stored the same way and are told apart by their layer
+func migrateLegacyKeys(e *env.Env) error {
+
+	keys, err := e.LDB.List("")
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+
+		raw := make(map[string]interface{})
+		if err := e.LDB.Read(key, &raw); err != nil {
+			e.Log.Error(err)
+			continue
+		}
+
+		if _, app := raw[`layer`]; app {
+			continue
+		}
+
+		s := new(Service)
+		if err := e.LDB.Read(key, s); err != nil {
+			e.Log.Error(err)
+			continue
+		}
+
+		if s.UUID == "" || s.Name != key {
+			continue
+		}
+
+		// Record written after upgrade is the current one, legacy one is left for inspection
+		if err := e.LDB.Read(storageKey(s.Name), new(Service)); err == nil {
+			e.Log.Error(`Service `, s.Name, ` is stored under both legacy and current key, legacy record is kept`)
+			continue
+		}
+
+		e.Log.Info(`Move service `, s.Name, ` record under `, storageKey(s.Name))
+
+		if err := e.LDB.Write(storageKey(s.Name), s); err != nil {
+			return err
+		}
+
+		if err := e.LDB.Remove(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"testing"
+)
+
+func TestMigrateLegacyKeys(t *testing.T) {
+
+	e := testEnv(t)
+
+	legacy := &Service{UUID: "uuid-web", Name: "web", Tag: "latest", Config: Config{Image: "nginx"}}
+	if err := e.LDB.Write("web", legacy); err != nil {
+		t.Fatal(err)
+	}
+
+	app := struct {
+		UUID  string            `yaml:"uuid"`
+		Name  string            `yaml:"name"`
+		Layer map[string]string `yaml:"layer"`
+	}{"uuid-site", "site", map[string]string{}}
+	if err := e.LDB.Write("site", app); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Migrate(e); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.LDB.Read("web", new(Service)); err != interfaces.ErrKeyNotFound {
+		t.Errorf("legacy key is not removed: %v", err)
+	}
+
+	s := new(Service)
+	if err := s.Get(e, "web"); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.UUID != legacy.UUID || s.Config.Image != "nginx" {
+		t.Errorf("moved record = %+v", s)
+	}
+
+	if s.Schema != schemaVersion {
+		t.Errorf("schema = %d, want %d", s.Schema, schemaVersion)
+	}
+
+	if err := e.LDB.Read("site", new(Service)); err != nil {
+		t.Errorf("app record is moved: %v", err)
+	}
+
+	if err := e.LDB.Read(storageKey("site"), new(Service)); err != interfaces.ErrKeyNotFound {
+		t.Errorf("app record is stored as service: %v", err)
+	}
+}
+
+func TestMigrateLegacyKeysKeepsCurrentRecord(t *testing.T) {
+
+	e := testEnv(t)
+
+	if err := e.LDB.Write("web", &Service{UUID: "old", Name: "web"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.LDB.Write(storageKey("web"), &Service{UUID: "new", Name: "web", Schema: schemaVersion}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Migrate(e); err != nil {
+		t.Fatal(err)
+	}
+
+	s := new(Service)
+	if err := s.Get(e, "web"); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.UUID != "new" {
+		t.Errorf("current record is overwritten by legacy one: %s", s.UUID)
+	}
+}
+
+func TestMigratePlatform(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		config   Config
+		required string
+	}{
+		{"former field", Config{Platform: "linux/arm64"}, "linux/arm64"},
+		{"current field kept", Config{Platform: "linux/amd64", RequiredPlatform: "linux/arm64"}, "linux/arm64"},
+		{"no platform", Config{}, ""},
+	}
+
+	for _, tt := range tests {
+
+		s := &Service{Config: tt.config}
+		migratePlatform(s)
+
+		if s.Config.RequiredPlatform != tt.required || s.Config.Platform != "" {
+			t.Errorf("%s: required platform = %q, platform = %q, want %q and empty",
+				tt.name, s.Config.RequiredPlatform, s.Config.Platform, tt.required)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/satori/go.uuid"
+	"strings"
+)
+
+// IndexNamer names containers as <service>-<index>, it is used when daemon has no namer
+type IndexNamer struct{}
+
+func (IndexNamer) Name(service string, index int) string {
+	return fmt.Sprintf("%s-%d", service, index)
+}
+
+// TemplateNamer names containers by template with {{.Service}} and {{.Index}},
+// service name is used if template fails
+type TemplateNamer struct {
+	Template string
+}
+
+func (t TemplateNamer) Name(service string, index int) string {
+
+	name, err := renderReplica(t.Template, service, index)
+	if err != nil || name == "" {
+		return IndexNamer{}.Name(service, index)
+	}
+
+	return name
+}
+
+func (s *Service) containerName(e *env.Env, index int) string {
+
+	if e.Namer == nil {
+		return IndexNamer{}.Name(s.Name, index)
+	}
+
+	return e.Namer.Name(s.Name, index)
+}
+
+// Name is still held by container of the same replica while it is replaced
+func isNameConflict(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "is already in use")
+}
+
+// Unique variant of name used when name is taken
+func uniqueName(name string) string {
+	return fmt.Sprintf("%s-%s", name, uuid.NewV4().String()[:8])
+}
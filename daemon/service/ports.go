@@ -0,0 +1,106 @@
+package service
+
+import (
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"strconv"
+	"strings"
+)
+
+// Driver failed to bind host port held by other process
+func isPortTaken(err error) bool {
+	return err != nil && (strings.Contains(err.Error(), "port is already allocated") ||
+		strings.Contains(err.Error(), "address already in use"))
+}
+
+// Bind auto allocated ports of replica to host ports it had before,
+// reservations are used when service record does not have them
+func (s *Service) reusePorts(e *env.Env, index int, ports []string) []string {
+
+	allocated := s.HostPorts[index]
+	if len(allocated) == 0 {
+		allocated = reservedPorts(e, s.Name, index)
+	}
+
+	if len(allocated) == 0 {
+		return ports
+	}
+
+	reused := []string{}
+
+	for _, port := range ports {
+		if !strings.Contains(port, ":") {
+			if containerPort, err := strconv.ParseInt(port, 10, 64); err == nil {
+				if host, ok := allocated[containerPort]; ok {
+					port = fmt.Sprintf("%d:%d", host, containerPort)
+				}
+			}
+		}
+
+		reused = append(reused, port)
+	}
+
+	return reused
+}
+
+// Store host ports driver allocated for replica, so recreated replica gets the same ones
+func (s *Service) recordPorts(e *env.Env, index int, id string) {
+
+	c := &interfaces.Container{CID: id}
+	if err := e.Containers.InspectContainer(c); err != nil {
+		e.Log.Error(err)
+		return
+	}
+
+	if s.HostPorts == nil {
+		s.HostPorts = make(map[int]map[int64]int64)
+	}
+
+	allocated := make(map[int64]int64)
+	for _, port := range c.Ports {
+		if port.Host != 0 {
+			allocated[port.Container] = port.Host
+		}
+	}
+
+	s.HostPorts[index] = allocated
+	s.reservePorts(e, index, allocated)
+}
+
+// Start container on previously allocated host ports, new ports are
+// allocated if old ones are taken
+func (s *Service) startOnPorts(e *env.Env, c *interfaces.Container, index int) error {
+
+	if s.handoff {
+		return e.Containers.StartContainer(c)
+	}
+
+	if err := s.checkReservedPorts(e); err != nil {
+		return err
+	}
+
+	ports := c.HostConfig.Ports
+	c.HostConfig.Ports = s.reusePorts(e, index, ports)
+
+	err := e.Containers.StartContainer(c)
+	if isPortTaken(err) {
+		e.Log.Info(`Host ports of replica `, index, ` of service `, s.Name, ` are taken, allocate new ones`)
+
+		if c.CID != "" {
+			if err := e.Containers.RemoveContainer(&interfaces.Container{CID: c.CID}); err != nil {
+				e.Log.Error(err)
+			}
+			c.CID = ""
+		}
+
+		c.HostConfig.Ports = ports
+		err = e.Containers.StartContainer(c)
+	}
+
+	if err == nil {
+		s.recordPorts(e, index, c.CID)
+	}
+
+	return err
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"github.com/deployithq/deployit/utils"
+)
+
+// PrePull pulls image of service, like web:2.0, pinned to its current digest without changing
+// service, so deploy of the same image later skips the pull
+func (s *Service) PrePull(e *env.Env, image string) error {
+	e = s.logEnv(e)
+	e.Log.Info(`Pre-pull `, image, ` for service `, s.Name)
+
+	if s.UUID == "" {
+		return ErrServiceNotFound
+	}
+
+	registry, repository, _ := utils.ParseImage(image)
+	if r, repo, _ := utils.ParseImage(s.Config.Image); r != registry || repo != repository {
+		return fmt.Errorf("image %s is not image of service %s", image, s.Config.Image)
+	}
+
+	if err := checkDriver(e); err != nil {
+		return err
+	}
+
+	auth := s.registryAuth(e, s.Config.Image)
+
+	digest, err := e.Registry.Digest(image, "", auth)
+	if err != nil {
+		e.Log.Error(err)
+		return err
+	}
+
+	opts := interfaces.Image{
+		Name:             fmt.Sprintf("%s@%s", s.Config.Image, digest),
+		Auth:             auth,
+		RequiredPlatform: s.Config.RequiredPlatform,
+	}
+
+	if err := runPhase(`pull`, seconds(s.Config.Timeouts.Pull), func(ctx context.Context) error {
+		return pullImage(ctx, e, opts)
+	}); err != nil {
+		e.Log.Error(err)
+		return err
+	}
+
+	e.Log.Info(`Pre-pulled `, opts.Name)
+
+	return nil
+}
+
+// Image pinned to digest is already on host, tags are pulled every time since they move
+func (s *Service) pulled(e *env.Env) bool {
+
+	if s.Digest == "" {
+		return false
+	}
+
+	return e.Containers.InspectImage(&interfaces.Image{Name: s.image()}) == nil
+}
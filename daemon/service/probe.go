@@ -0,0 +1,174 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	probeTCP  = `tcp`
+	probeHTTP = `http`
+
+	defaultProbeTimeout  = 60
+	defaultProbeInterval = 1
+)
+
+type Probe struct {
+	Type     string `json:"type" yaml:"type"`         // tcp or http
+	Port     int64  `json:"port" yaml:"port"`         // container port, first published port if empty
+	Path     string `json:"path" yaml:"path"`         // http only
+	Status   int    `json:"status" yaml:"status"`     // http only, 200 if empty
+	Timeout  int    `json:"timeout" yaml:"timeout"`   // seconds
+	Interval int    `json:"interval" yaml:"interval"` // seconds
+}
+
+// Check probes provided host port once
+func (p *Probe) Check(port int64) error {
+
+	address := fmt.Sprintf("127.0.0.1:%d", port)
+	timeout := time.Duration(p.interval()) * time.Second
+
+	switch p.Type {
+	case probeTCP, ``:
+		conn, err := net.DialTimeout("tcp", address, timeout)
+		if err != nil {
+			return err
+		}
+
+		conn.Close()
+
+	case probeHTTP:
+		client := &http.Client{Timeout: timeout}
+
+		res, err := client.Get(fmt.Sprintf("http://%s%s", address, p.Path))
+		if err != nil {
+			return err
+		}
+
+		res.Body.Close()
+
+		status := p.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		if res.StatusCode != status {
+			return fmt.Errorf("unexpected probe status %d", res.StatusCode)
+		}
+
+	default:
+		return fmt.Errorf("unknown probe type %s", p.Type)
+	}
+
+	return nil
+}
+
+func (p *Probe) timeout() time.Duration {
+	if p.Timeout <= 0 {
+		return defaultProbeTimeout * time.Second
+	}
+
+	return time.Duration(p.Timeout) * time.Second
+}
+
+func (p *Probe) interval() int {
+	if p.Interval <= 0 {
+		return defaultProbeInterval
+	}
+
+	return p.Interval
+}
+
+// Find host port mapped to the probe port of container
+func (p *Probe) hostPort(e *env.Env, id string) (int64, error) {
+
+	c := &interfaces.Container{CID: id}
+
+	if err := e.Containers.InspectContainer(c); err != nil {
+		return 0, err
+	}
+
+	for _, port := range c.Ports {
+		if p.Port == 0 || port.Container == p.Port {
+			return port.Host, nil
+		}
+	}
+
+	return 0, errors.New("probe port is not published")
+}
+
+// Poll service health until it passes or probe timeout elapses
+func (s *Service) waitReady(e *env.Env) error {
+	return s.waitHealthy(e, s.replicas())
+}
+
+// Stop containers which did not become ready, service is recorded stopped
+// when none of them did so failed start is not reported as running
+func (s *Service) stopUnready(e *env.Env) {
+
+	ready := 0
+
+	for _, container := range s.Containers {
+		if ok, _ := s.containerReady(e, container.ID); ok {
+			ready++
+			continue
+		}
+
+		e.Log.Info(`Stop unready container `, container.ID, ` of service `, s.Name)
+
+		if err := s.stopContainer(e, container.ID); err != nil && !isNoSuchContainer(err) {
+			e.Log.Error(err)
+		}
+	}
+
+	if ready == 0 {
+		s.Desired = DesiredStopped
+	}
+
+	if err := s.Update(e); err != nil {
+		e.Log.Error(err)
+	}
+}
+
+func (s *Service) waitHealthy(e *env.Env, want int) error {
+
+	probe := s.Config.ReadinessProbe
+	if probe == nil {
+		return nil
+	}
+
+	e.Log.Info(`Wait for service ready `, s.Name)
+
+	timeout := probe.timeout()
+	if limit := seconds(s.Config.Timeouts.Health); limit > 0 && limit < timeout {
+		timeout = limit
+	}
+
+	deadline := time.Now().Add(timeout)
+
+	for {
+		healthy, err := s.healthy(e, want)
+		if err != nil {
+			return err
+		}
+
+		if healthy {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("service %s is not ready", s.Name)
+		}
+
+		select {
+		case <-s.cancel:
+			return ErrDeployCanceled
+		case <-time.After(time.Duration(probe.interval()) * time.Second):
+		}
+	}
+}
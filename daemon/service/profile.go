@@ -0,0 +1,49 @@
+package service
+
+// Resources of service in daemon profile, set values override scaled base ones
+type Resources struct {
+	// Multiplier of base memory and cpus, like 0.25 for dev
+	Scale float64 `json:"scale" yaml:"scale"`
+
+	Memory   int64   `json:"memory" yaml:"memory"`
+	CPUs     float64 `json:"cpus" yaml:"cpus"`
+	Replicas int     `json:"replicas" yaml:"replicas"`
+}
+
+// Size memory and cpus for profile, config already sized is not scaled again
+func (c *Config) applyProfile(profile string) {
+
+	if profile == "" || c.Profile != "" {
+		return
+	}
+
+	resources, ok := c.Profiles[profile]
+	if !ok {
+		return
+	}
+
+	c.Profile = profile
+
+	if resources.Scale > 0 {
+		c.Memory = int64(float64(c.Memory) * resources.Scale)
+		c.CPUs *= resources.Scale
+	}
+
+	if resources.Memory > 0 {
+		c.Memory = resources.Memory
+	}
+
+	if resources.CPUs > 0 {
+		c.CPUs = resources.CPUs
+	}
+}
+
+// Replicas of applied profile, 0 if profile does not set them
+func (c *Config) profileReplicas() int {
+
+	if c.Profile == "" {
+		return 0
+	}
+
+	return c.Profiles[c.Profile].Replicas
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	StagePulling  = `pulling`
+	StageLayer    = `layer`
+	StageStarting = `starting`
+	StageHealthy  = `healthy`
+	StageDone     = `done`
+	StageFailed   = `failed`
+)
+
+type ProgressEvent struct {
+	Stage   string `json:"stage"`
+	Message string `json:"message,omitempty"`
+	Current int    `json:"current,omitempty"`
+	Total   int    `json:"total,omitempty"`
+}
+
+func (s *Service) report(event ProgressEvent) {
+	sendProgress(context.Background(), s.progress, event)
+}
+
+// Send event unless context is done, so phase which timed out does not block on reader which is gone
+func sendProgress(ctx context.Context, progress chan<- ProgressEvent, event ProgressEvent) {
+	if progress == nil {
+		return
+	}
+
+	if event.Message == "" {
+		event.Message = event.Stage
+	}
+
+	select {
+	case progress <- event:
+	case <-ctx.Done():
+	}
+}
+
+// Parses docker json pull stream and reports completed layers
+type pullProgress struct {
+	ctx      context.Context
+	progress chan<- ProgressEvent
+	buffer   bytes.Buffer
+	layers   map[string]bool
+}
+
+func (p *pullProgress) Write(data []byte) (int, error) {
+
+	p.buffer.Write(data)
+
+	for {
+		line, err := p.buffer.ReadBytes('\n')
+		if err != nil {
+			// Keep incomplete line until the rest of it is written
+			p.buffer.Write(line)
+			break
+		}
+
+		message := struct {
+			ID     string `json:"id"`
+			Status string `json:"status"`
+		}{}
+
+		if err := json.Unmarshal(line, &message); err != nil || message.ID == "" {
+			continue
+		}
+
+		if _, ok := p.layers[message.ID]; !ok {
+			p.layers[message.ID] = false
+		}
+
+		if message.Status != "Pull complete" && message.Status != "Already exists" {
+			continue
+		}
+
+		if p.layers[message.ID] {
+			continue
+		}
+
+		p.layers[message.ID] = true
+
+		done := 0
+		for _, complete := range p.layers {
+			if complete {
+				done++
+			}
+		}
+
+		sendProgress(p.ctx, p.progress, ProgressEvent{
+			Stage:   StageLayer,
+			Message: fmt.Sprintf("layer %d/%d", done, len(p.layers)),
+			Current: done,
+			Total:   len(p.layers),
+		})
+	}
+
+	return len(data), nil
+}
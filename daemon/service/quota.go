@@ -0,0 +1,61 @@
+package service
+
+import (
+	"github.com/deployithq/deployit/daemon/env"
+)
+
+// Memory and cpus committed by service replicas running or started on host
+func (s *Service) committed() (int64, float64) {
+
+	if s.Suspended || s.Desired == DesiredStopped || len(s.Containers) == 0 {
+		return 0, 0
+	}
+
+	n := s.replicas()
+	if len(s.Containers) > n {
+		n = len(s.Containers)
+	}
+
+	return int64(n) * s.Config.Memory, float64(n) * s.Config.CPUs
+}
+
+// Check n replicas of service fit into host quota together with other running services,
+// replicas without resource limits are not counted
+func (s *Service) checkQuota(e *env.Env, n int) error {
+
+	quota := e.Quota
+	if quota.Memory <= 0 && quota.CPUs <= 0 {
+		return nil
+	}
+
+	services, err := list(e)
+	if err != nil {
+		return err
+	}
+
+	var (
+		memory int64
+		cpus   float64
+	)
+
+	for _, other := range services {
+		if other.Name == s.Name {
+			continue
+		}
+
+		m, c := other.committed()
+		memory, cpus = memory+m, cpus+c
+	}
+
+	requestedMemory, requestedCPUs := int64(n)*s.Config.Memory, float64(n)*s.Config.CPUs
+
+	if quota.Memory > 0 && memory+requestedMemory > quota.Memory {
+		return QuotaError{Resource: `memory`, Requested: float64(requestedMemory), Committed: float64(memory), Quota: float64(quota.Memory)}
+	}
+
+	if quota.CPUs > 0 && cpus+requestedCPUs > quota.CPUs {
+		return QuotaError{Resource: `cpus`, Requested: requestedCPUs, Committed: cpus, Quota: quota.CPUs}
+	}
+
+	return nil
+}
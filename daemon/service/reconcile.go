@@ -0,0 +1,195 @@
+package service
+
+import (
+	"github.com/deployithq/deployit/daemon/env"
+	"time"
+)
+
+// SetReplicas stores the desired replicas count without touching containers,
+// the reconciler converges the service to it
+func (s *Service) SetReplicas(e *env.Env, n int) error {
+	e.Log.Info(`Set replicas for service `, s.Name)
+
+	if s.UUID == "" {
+		return ErrServiceNotFound
+	}
+
+	if n < 1 {
+		return ErrInvalidReplicas
+	}
+
+	if err := s.checkQuota(e, n); err != nil {
+		return err
+	}
+
+	s.Replicas = n
+
+	if err := s.Update(e); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Scale changes replicas count, new replicas are started in batches of step
+// waiting for each batch to be healthy and pausing between batches,
+// all missing replicas are started at once if step is not positive
+func (s *Service) Scale(e *env.Env, n, step int, pause time.Duration) error {
+	e = s.logEnv(e)
+	e.Log.Info(`Scale service `, s.Name)
+
+	if s.UUID == "" {
+		return ErrServiceNotFound
+	}
+
+	if err := checkDriver(e); err != nil {
+		return err
+	}
+
+	if n < 1 {
+		return ErrInvalidReplicas
+	}
+
+	if err := s.checkQuota(e, n); err != nil {
+		return err
+	}
+
+	s.Replicas = n
+
+	if step <= 0 {
+		step = n
+	}
+
+	for len(s.Containers) < n {
+
+		for i := 0; i < step && len(s.Containers) < n; i++ {
+			if _, err := s.createContainer(e); err != nil {
+				s.Update(e)
+				return err
+			}
+		}
+
+		if err := s.Update(e); err != nil {
+			return err
+		}
+
+		if err := s.waitHealthy(e, len(s.Containers)); err != nil {
+			return err
+		}
+
+		if len(s.Containers) < n {
+			time.Sleep(pause)
+		}
+	}
+
+	// Surplus replicas are removed by reconcile
+	return s.Reconcile(e)
+}
+
+// Reconcile starts missing replicas and removes surplus ones
+func (s *Service) Reconcile(e *env.Env) error {
+	e = s.logEnv(e)
+	e.Log.Debug(`Reconcile service `, s.Name)
+
+	if s.UUID == "" {
+		return ErrServiceNotFound
+	}
+
+	// Canary replicas are managed by Canary and Promote,
+	// services which were never started have no desired state
+	if s.Canary != nil || s.Suspended || s.Desired == DesiredStopped || s.Desired == "" {
+		return nil
+	}
+
+	desired := s.replicas()
+
+	if len(s.Containers) == desired {
+		return nil
+	}
+
+	if err := checkDriver(e); err != nil {
+		return err
+	}
+
+	for len(s.Containers) < desired {
+		if _, err := s.createContainer(e); err != nil {
+			// Keep already started containers recorded
+			s.Update(e)
+			return err
+		}
+	}
+
+	for _, key := range s.surplus(e, len(s.Containers)-desired) {
+
+		container := s.Containers[key]
+
+		if err := s.removeContainer(e, container); err != nil && !isNoSuchContainer(err) {
+			e.Log.Error(err)
+			s.Update(e)
+			return err
+		}
+
+		delete(s.Containers, key)
+	}
+
+	if err := s.Update(e); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ReconcileAll runs Reconcile for every stored service
+func ReconcileAll(e *env.Env) error {
+
+	services, err := list(e)
+	if err != nil {
+		return err
+	}
+
+	containers, err := e.Containers.ListContainers()
+	if err != nil {
+		e.Log.Error(err)
+	}
+
+	for _, s := range services {
+		if s.Maintenance {
+			e.Log.Debug(`Service `, s.Name, ` is in maintenance, skip reconcile`)
+			continue
+		}
+
+		if err := s.adopt(e, containers); err != nil {
+			e.Log.Error(err)
+		}
+
+		if err := s.Reconcile(e); err != nil {
+			e.Log.Error(err)
+		}
+
+		if err := s.restartOnDrift(e); err != nil {
+			e.Log.Error(err)
+		}
+
+		if err := s.restartOnExitCodes(e); err != nil {
+			e.Log.Error(err)
+		}
+
+		if err := s.checkRestarts(e); err != nil {
+			e.Log.Error(err)
+		}
+
+		if err := s.restartUnhealthy(e); err != nil {
+			e.Log.Error(err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) replicas() int {
+	if s.Replicas < 1 {
+		return 1
+	}
+
+	return s.Replicas
+}
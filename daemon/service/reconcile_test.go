@@ -0,0 +1,50 @@
+package service
+
+import (
+	"errors"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"testing"
+)
+
+// Driver which is down, methods other than Ping are not expected to be called
+type downContainers struct {
+	interfaces.IContainers
+}
+
+func (downContainers) Ping() error {
+	return errors.New("driver is down")
+}
+
+func TestReconcileSkips(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		service Service
+		err     error
+	}{
+		{"never started", Service{Desired: ""}, nil},
+		{"stopped", Service{Desired: DesiredStopped}, nil},
+		{"suspended", Service{Desired: DesiredRunning, Suspended: true}, nil},
+		{"canary", Service{Desired: DesiredRunning, Canary: &Canary{}}, nil},
+		{"converged", Service{Desired: DesiredRunning, Containers: map[string]*Container{"a": {ID: "a"}}}, nil},
+		{"missing replica", Service{Desired: DesiredRunning}, ErrDriverUnavailable},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			e := testEnv(t)
+			e.Containers = downContainers{}
+
+			s := test.service
+			s.UUID, s.Name, s.Replicas = "uuid", "web", 1
+			if s.Containers == nil {
+				s.Containers = make(map[string]*Container)
+			}
+
+			if err := s.Reconcile(e); err != test.err {
+				t.Errorf("Reconcile() = %v, want %v", err, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"github.com/deployithq/deployit/utils"
+	"io/ioutil"
+	"strings"
+)
+
+// RegistryHost returns registry host of image name, docker hub if image has no registry part
+func RegistryHost(image string) string {
+	registry, _, _ := utils.ParseImage(image)
+	return registry
+}
+
+// Select credentials configured for the registry of image
+func registryAuth(e *env.Env, image string) interfaces.AuthConfig {
+
+	host := RegistryHost(image)
+
+	if auth, ok := e.Registries[host]; ok {
+		if auth.Host == `` {
+			auth.Host = host
+		}
+		return auth
+	}
+
+	return interfaces.AuthConfig{}
+}
+
+// RegistryAuth is service own registry credentials, password may be read
+// from host file so it is not stored in service config
+type RegistryAuth struct {
+	Username     string `json:"username" yaml:"username"`
+	Password     string `json:"password" yaml:"password"`
+	PasswordFile string `json:"password_file" yaml:"password_file"`
+	Host         string `json:"host" yaml:"host"` // registry of service image if empty
+}
+
+// Service credentials are used for images of their registry, others fall back to daemon ones
+func (s *Service) registryAuth(e *env.Env, image string) interfaces.AuthConfig {
+
+	a := s.Config.RegistryAuth
+	if a == nil {
+		return registryAuth(e, image)
+	}
+
+	host := a.Host
+	if host == `` {
+		host = RegistryHost(s.Config.Image)
+	}
+
+	if RegistryHost(image) != host {
+		return registryAuth(e, image)
+	}
+
+	auth := interfaces.AuthConfig{
+		Username: a.Username,
+		Password: a.Password,
+		Host:     host,
+	}
+
+	if a.PasswordFile != `` {
+		data, err := ioutil.ReadFile(a.PasswordFile)
+		if err != nil {
+			// Pull fails as unauthorized, error of registry is reported then
+			e.Log.Error(err)
+		}
+
+		auth.Password = strings.TrimSpace(string(data))
+	}
+
+	return auth
+}
+
+// Fail create early when image is neither local nor in registry,
+// registry errors do not block create since image may still be pulled later
+func (s *Service) checkImage(e *env.Env) error {
+
+	// Images built from source or loaded from tarball are not in registry yet
+	if s.Config.Source != nil || s.Config.ImageTarball != "" || e.Registry == nil {
+		return nil
+	}
+
+	if err := e.Containers.InspectImage(&interfaces.Image{Name: s.Config.Image}); err == nil {
+		return nil
+	}
+
+	exists, err := e.Registry.Exists(s.Config.Image, "", s.registryAuth(e, s.Config.Image))
+	if err != nil {
+		e.Log.Error(err)
+		return nil
+	}
+
+	if !exists {
+		return ErrImageNotFound
+	}
+
+	return nil
+}
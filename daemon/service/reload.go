@@ -0,0 +1,46 @@
+package service
+
+import (
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"regexp"
+)
+
+const signalKill = `SIGKILL`
+
+// Signal name like SIGHUP or HUP, or signal number
+var validSignal = regexp.MustCompile(`^((SIG)?[A-Z][A-Z0-9]*|[1-9][0-9]*)$`)
+
+// Reload sends reload signal to service containers without stopping them,
+// service without reload signal is restarted
+func (s *Service) Reload(e *env.Env) error {
+	e = s.logEnv(e)
+	e.Log.Info(`Reload service `, s.Name)
+
+	if s.UUID == "" {
+		return ErrServiceNotFound
+	}
+
+	if s.Config.ReloadSignal == "" {
+		return s.Restart(e)
+	}
+
+	if err := checkDriver(e); err != nil {
+		return err
+	}
+
+	errs := ContainerErrors{}
+
+	for _, container := range s.Containers {
+		if err := e.Containers.KillContainer(&interfaces.Container{CID: container.ID}, s.Config.ReloadSignal); err != nil {
+			e.Log.Error(err)
+			errs[container.ID] = err
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"bytes"
+	"github.com/deployithq/deployit/daemon/env"
+	"strings"
+	"text/template"
+)
+
+// Values available in env templates, like SHARD_INDEX={{.Index}}
+type replicaVars struct {
+	Index   int
+	Service string
+}
+
+// Lowest replica index which is not held by any container,
+// so replacement containers take index of the removed ones
+func (s *Service) freeIndex() int {
+
+	used := make(map[int]bool)
+	for _, container := range s.Containers {
+		used[container.Index] = true
+	}
+
+	index := 0
+	for used[index] {
+		index++
+	}
+
+	return index
+}
+
+// Render env templates for replica index
+func (c *Config) replicaEnv(service string, index int) ([]string, error) {
+
+	env := []string{}
+
+	for _, variable := range c.Env {
+
+		value, err := renderReplica(variable, service, index)
+		if err != nil {
+			return nil, err
+		}
+
+		env = append(env, value)
+	}
+
+	return c.withLogLevel(env), nil
+}
+
+func renderReplica(value, service string, index int) (string, error) {
+
+	if !strings.Contains(value, "{{") {
+		return value, nil
+	}
+
+	tpl, err := template.New("replica").Option("missingkey=error").Parse(value)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, replicaVars{Index: index, Service: service}); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// ContainerIDByIndex returns container of replica with given index
+func (s *Service) ContainerIDByIndex(e *env.Env, index int) (string, error) {
+	e.Log.Debug(`Container of replica `, index, ` of service `, s.Name)
+
+	if s.UUID == "" {
+		return "", ErrServiceNotFound
+	}
+
+	if index < 0 || index >= s.replicas() {
+		return "", ErrIndexOutOfRange
+	}
+
+	for _, container := range s.Containers {
+		if container.Index == index {
+			return container.ID, nil
+		}
+	}
+
+	return "", ErrContainerNotFound
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const portsPrefix = `ports`
+
+// PortReservation is host port kept for replica of service across recreations
+// of its containers and restarts of daemon, host port is reserved by one service only
+type PortReservation struct {
+	Host      int64  `json:"host"`
+	Service   string `json:"service"`
+	Index     int    `json:"index"`
+	Container int64  `json:"container"`
+}
+
+func portKey(host int64) string {
+	return fmt.Sprintf("%s/%d", portsPrefix, host)
+}
+
+// Guards check and write of reservations
+var reserveLock sync.Mutex
+
+// ListReservations returns all host ports reserved by services
+func ListReservations(e *env.Env) ([]PortReservation, error) {
+
+	reservations := []PortReservation{}
+
+	keys, err := e.LDB.List(portsPrefix)
+	if err != nil {
+		return reservations, err
+	}
+
+	for _, key := range keys {
+
+		r := PortReservation{}
+		if err := e.LDB.Read(key, &r); err != nil {
+			e.Log.Error(err)
+			continue
+		}
+
+		reservations = append(reservations, r)
+	}
+
+	return reservations, nil
+}
+
+// Host ports reserved for replica by container port
+func reservedPorts(e *env.Env, service string, index int) map[int64]int64 {
+
+	reserved := make(map[int64]int64)
+
+	reservations, err := ListReservations(e)
+	if err != nil {
+		e.Log.Error(err)
+		return reserved
+	}
+
+	for _, r := range reservations {
+		if r.Service == service && r.Index == index {
+			reserved[r.Container] = r.Host
+		}
+	}
+
+	return reserved
+}
+
+// Reserve host ports replica is bound to, ports reserved by other services are not taken over
+func (s *Service) reservePorts(e *env.Env, index int, allocated map[int64]int64) {
+
+	reserveLock.Lock()
+	defer reserveLock.Unlock()
+
+	for container, host := range allocated {
+
+		existing := PortReservation{}
+		if err := e.LDB.Read(portKey(host), &existing); err == nil && existing.Service != "" && existing.Service != s.Name {
+			e.Log.Errorf("Host port %d of service %s is reserved by service %s", host, s.Name, existing.Service)
+			continue
+		}
+
+		r := PortReservation{Host: host, Service: s.Name, Index: index, Container: container}
+		if err := e.LDB.Write(portKey(host), r); err != nil {
+			e.Log.Error(err)
+		}
+	}
+}
+
+// Fixed host ports of config should not be reserved by other services
+func (s *Service) checkReservedPorts(e *env.Env) error {
+
+	for _, port := range s.Config.Ports {
+
+		parts := strings.Split(port, ":")
+		if len(parts) < 2 {
+			continue
+		}
+
+		host, err := strconv.ParseInt(parts[len(parts)-2], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		existing := PortReservation{}
+		if err := e.LDB.Read(portKey(host), &existing); err == nil && existing.Service != "" && existing.Service != s.Name {
+			e.Log.Errorf("Host port %d is reserved by service %s", host, existing.Service)
+			return ErrPortReserved
+		}
+	}
+
+	return nil
+}
+
+// Drop reservations of destroyed service
+func releasePorts(e *env.Env, service string) {
+
+	reserveLock.Lock()
+	defer reserveLock.Unlock()
+
+	reservations, err := ListReservations(e)
+	if err != nil {
+		e.Log.Error(err)
+		return
+	}
+
+	for _, r := range reservations {
+		if r.Service != service {
+			continue
+		}
+
+		if err := e.LDB.Remove(portKey(r.Host)); err != nil {
+			e.Log.Error(err)
+		}
+	}
+}
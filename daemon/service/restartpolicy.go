@@ -0,0 +1,197 @@
+package service
+
+import (
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"sync"
+	"time"
+)
+
+const (
+	restartAlways        = `always`
+	restartNo            = `no`
+	restartUnlessStopped = `unless-stopped`
+	restartOnFailure     = `on-failure`
+)
+
+// RestartPolicy of service containers, on-failure with exit codes restarts
+// containers only when they exit with one of the codes
+type RestartPolicy struct {
+	Name       string `json:"name" yaml:"name"`
+	MaxRetries int    `json:"max_retries" yaml:"max_retries"`
+	ExitCodes  []int  `json:"exit_codes" yaml:"exit_codes"`
+}
+
+// Restarts on exit codes are done by reconciler, driver does not support them
+func (p *RestartPolicy) byExitCode() bool {
+	return p != nil && p.Name == restartOnFailure && len(p.ExitCodes) > 0
+}
+
+func (p *RestartPolicy) restarts(code int) bool {
+	for _, c := range p.ExitCodes {
+		if c == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Driver restart policy of service containers
+func (c *Config) driverRestartPolicy() interfaces.RestartPolicyConfig {
+
+	switch {
+	case c.AutoRemove:
+		// Auto removed containers can not be restarted by driver
+		return interfaces.RestartPolicyConfig{}
+	case c.RestartPolicy == nil:
+		return interfaces.RestartPolicyConfig{Attempt: 10, Name: restartAlways}
+	case c.RestartPolicy.byExitCode():
+		return interfaces.RestartPolicyConfig{Name: restartNo}
+	}
+
+	return interfaces.RestartPolicyConfig{
+		Attempt: c.RestartPolicy.MaxRetries,
+		Name:    c.RestartPolicy.Name,
+	}
+}
+
+// Start again containers which exited with one of restart exit codes
+func (s *Service) restartOnExitCodes(e *env.Env) error {
+
+	policy := s.Config.RestartPolicy
+	if !policy.byExitCode() || s.Suspended || s.Desired == DesiredStopped {
+		return nil
+	}
+
+	for _, container := range s.Containers {
+
+		c := &interfaces.Container{CID: container.ID}
+		if err := e.Containers.InspectContainer(c); err != nil {
+			if !isNoSuchContainer(err) {
+				e.Log.Error(err)
+			}
+			continue
+		}
+
+		if c.State.Running || c.State.Restarting || !policy.restarts(c.State.ExitCode) {
+			continue
+		}
+
+		if !s.countExitRestart(container.ID) {
+			s.giveUp(e, container.ID, c.State.ExitCode, policy.MaxRetries)
+			continue
+		}
+
+		e.Log.Info(`Container `, container.ID, ` of service `, s.Name, ` exited with `, c.State.ExitCode, `, restart it`)
+
+		if err := e.Containers.StartContainer(&interfaces.Container{
+			CID:        container.ID,
+			HostConfig: s.hostConfig(),
+		}); err != nil {
+			e.Log.Error(err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Failure of service which containers exhausted restart attempts
+type Failure struct {
+	Container string    `json:"container" yaml:"container"`
+	ExitCode  int       `json:"exit_code" yaml:"exit_code"`
+	Restarts  int       `json:"restarts" yaml:"restarts"`
+	Time      time.Time `json:"time" yaml:"time"`
+}
+
+// Restarts made by daemon on exit codes by container
+var exitRestarts = struct {
+	sync.Mutex
+	counts map[string]int
+}{counts: make(map[string]int)}
+
+// Count restart on exit code, false when max retries are exhausted
+func (s *Service) countExitRestart(id string) bool {
+	exitRestarts.Lock()
+	defer exitRestarts.Unlock()
+
+	max := s.Config.RestartPolicy.MaxRetries
+	if max > 0 && exitRestarts.counts[id] >= max {
+		return false
+	}
+
+	exitRestarts.counts[id]++
+
+	return true
+}
+
+// Mark service failed when its containers stay dead after driver exhausted on-failure retries,
+// failure is cleared once failed container runs again or is replaced
+func (s *Service) checkRestarts(e *env.Env) error {
+
+	if s.Failure != nil {
+		if container, ok := s.Containers[s.Failure.Container]; !ok || s.running(e, container.ID) {
+			e.Log.Info(`Service `, s.Name, ` recovered from failure of container `, s.Failure.Container)
+
+			exitRestarts.Lock()
+			delete(exitRestarts.counts, s.Failure.Container)
+			exitRestarts.Unlock()
+
+			s.Failure = nil
+			return s.Update(e)
+		}
+
+		return nil
+	}
+
+	policy := s.Config.RestartPolicy
+	if policy == nil || policy.Name != restartOnFailure || policy.MaxRetries <= 0 || policy.byExitCode() ||
+		s.Suspended || s.Desired == DesiredStopped {
+		return nil
+	}
+
+	for _, container := range s.Containers {
+
+		c := &interfaces.Container{CID: container.ID}
+		if err := e.Containers.InspectContainer(c); err != nil {
+			continue
+		}
+
+		if c.State.Running || c.State.Restarting || c.State.ExitCode == 0 || c.State.Restarts < policy.MaxRetries {
+			continue
+		}
+
+		s.giveUp(e, container.ID, c.State.ExitCode, c.State.Restarts)
+
+		return nil
+	}
+
+	return nil
+}
+
+func (s *Service) running(e *env.Env, id string) bool {
+	c := &interfaces.Container{CID: id}
+	return e.Containers.InspectContainer(c) == nil && c.State.Running
+}
+
+// Record failure of service and notify failure webhooks
+func (s *Service) giveUp(e *env.Env, id string, code, restarts int) {
+
+	if s.Failure != nil && s.Failure.Container == id {
+		return
+	}
+
+	err := fmt.Errorf("container %s exited with %d after %d restarts, restart attempts are exhausted", id, code, restarts)
+	e.Log.Error(`Service `, s.Name, ` failed: `, err)
+
+	s.Failure = &Failure{Container: id, ExitCode: code, Restarts: restarts, Time: time.Now().UTC()}
+
+	if err := s.Update(e); err != nil {
+		e.Log.Error(err)
+	}
+
+	countMetric(metricGaveUp, s.Name)
+	s.notifyWebhooks(e, err, 0)
+}
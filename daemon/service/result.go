@@ -0,0 +1,88 @@
+package service
+
+import (
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"sort"
+)
+
+// Result describes outcome of a lifecycle action in machine readable form
+type Result struct {
+	Action     string   `json:"action"`
+	Service    string   `json:"service"`
+	Containers []string `json:"containers"`
+	Errors     []string `json:"errors"`
+}
+
+var actions = map[string]func(*Service, *env.Env) error{
+	`pull`:              (*Service).Pull,
+	`start`:             (*Service).Start,
+	`stop`:              (*Service).Stop,
+	`restart`:           (*Service).Restart,
+	`remove`:            (*Service).Remove,
+	`destroy`:           (*Service).Destroy,
+	`force-destroy`:     (*Service).forceDestroy,
+	`reconcile`:         (*Service).Reconcile,
+	`promote`:           (*Service).Promote,
+	`rollback`:          (*Service).Rollback,
+	`suspend`:           (*Service).Suspend,
+	`resume`:            (*Service).Resume,
+	`enter-maintenance`: (*Service).EnterMaintenance,
+	`exit-maintenance`:  (*Service).ExitMaintenance,
+}
+
+// Do runs lifecycle action by name and reports containers the service had
+// before or after it, error of action is returned too so callers can tell failures apart
+func (s *Service) Do(e *env.Env, action string) (*Result, error) {
+
+	fn, ok := actions[action]
+	if !ok {
+		return s.DoWith(action, func() error {
+			return ErrUnknownAction
+		})
+	}
+
+	return s.DoWith(action, func() error {
+		return fn(s, e)
+	})
+}
+
+// DoWith runs fn as lifecycle action and reports it like Do, failed containers
+// are reported one by one
+func (s *Service) DoWith(action string, fn func() error) (*Result, error) {
+
+	result := &Result{
+		Action:     action,
+		Service:    s.Name,
+		Containers: []string{},
+		Errors:     []string{},
+	}
+
+	affected := make(map[string]bool)
+	for id := range s.Containers {
+		affected[id] = true
+	}
+
+	err := fn()
+
+	if failed, ok := err.(ContainerErrors); ok {
+		for id, err := range failed {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %s", id, err))
+		}
+		sort.Strings(result.Errors)
+	} else if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+	}
+
+	for id := range s.Containers {
+		affected[id] = true
+	}
+
+	for id := range affected {
+		result.Containers = append(result.Containers, id)
+	}
+
+	sort.Strings(result.Containers)
+
+	return result, err
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDoWith(t *testing.T) {
+
+	errStuck := errors.New("stuck")
+
+	tests := []struct {
+		name   string
+		err    error
+		errors []string
+	}{
+		{"success", nil, []string{}},
+		{"service error", ErrDriverUnavailable, []string{ErrDriverUnavailable.Error()}},
+		{"container errors", ContainerErrors{"b": errStuck, "a": errStuck}, []string{"a: stuck", "b: stuck"}},
+	}
+
+	for _, tt := range tests {
+
+		s := &Service{Name: "web", Containers: map[string]*Container{"a": {ID: "a"}, "b": {ID: "b"}}}
+
+		result, err := s.DoWith(`stop`, func() error {
+			delete(s.Containers, "b")
+			s.Containers["c"] = &Container{ID: "c"}
+			return tt.err
+		})
+
+		if !reflect.DeepEqual(err, tt.err) {
+			t.Errorf("%s: DoWith() error = %v, want %v", tt.name, err, tt.err)
+		}
+
+		if !reflect.DeepEqual(result.Errors, tt.errors) {
+			t.Errorf("%s: errors = %v, want %v", tt.name, result.Errors, tt.errors)
+		}
+
+		if want := []string{"a", "b", "c"}; !reflect.DeepEqual(result.Containers, want) {
+			t.Errorf("%s: containers = %v, want %v", tt.name, result.Containers, want)
+		}
+	}
+}
+
+func TestDoUnknownAction(t *testing.T) {
+
+	result, err := (&Service{Name: "web"}).Do(testEnv(t), `explode`)
+	if err != ErrUnknownAction {
+		t.Errorf("Do() error = %v, want %v", err, ErrUnknownAction)
+	}
+
+	if len(result.Errors) != 1 || result.Action != `explode` {
+		t.Errorf("Do() = %+v, want unknown action reported", result)
+	}
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"sort"
+	"time"
+)
+
+type candidate struct {
+	key     string
+	healthy bool
+	started time.Time
+}
+
+// Keys of n containers to remove on scale down,
+// unhealthy replicas go first and oldest of equally healthy ones
+func (s *Service) surplus(e *env.Env, n int) []string {
+
+	candidates := []candidate{}
+
+	for key, container := range s.Containers {
+
+		c := candidate{key: key}
+
+		info := &interfaces.Container{CID: container.ID}
+		if err := e.Containers.InspectContainer(info); err == nil {
+			c.started = info.State.Started
+		}
+
+		// Containers failed to inspect are not healthy
+		if ready, err := s.containerReady(e, container.ID); err == nil {
+			c.healthy = ready
+		}
+
+		candidates = append(candidates, c)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].healthy != candidates[j].healthy {
+			return !candidates[i].healthy
+		}
+
+		return candidates[i].started.Before(candidates[j].started)
+	})
+
+	keys := []string{}
+	for i := 0; i < n && i < len(candidates); i++ {
+		keys = append(keys, candidates[i].key)
+	}
+
+	return keys
+}
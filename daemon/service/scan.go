@@ -0,0 +1,70 @@
+package service
+
+import (
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"strings"
+)
+
+var severityLevels = map[string]int{
+	interfaces.SeverityLow:      1,
+	interfaces.SeverityMedium:   2,
+	interfaces.SeverityHigh:     3,
+	interfaces.SeverityCritical: 4,
+}
+
+// NoopScanner reports no findings, it is used when daemon has no scanner
+type NoopScanner struct{}
+
+func (NoopScanner) Scan(image string) (interfaces.ScanResult, error) {
+	return interfaces.ScanResult{}, nil
+}
+
+// ScanError lists image findings at or above service scan threshold
+type ScanError struct {
+	Image    string
+	Findings []interfaces.Finding
+}
+
+func (s ScanError) Error() string {
+
+	findings := []string{}
+	for _, f := range s.Findings {
+		findings = append(findings, fmt.Sprintf("%s (%s, %s)", f.ID, f.Severity, f.Package))
+	}
+
+	return fmt.Sprintf("image %s has %d vulnerabilities: %s", s.Image, len(s.Findings), strings.Join(findings, ", "))
+}
+
+// Scan image containers are going to be created from, deploy is aborted
+// if it has findings at or above threshold
+func (s *Service) scanImage(e *env.Env) error {
+
+	if s.Config.ScanThreshold == "" || e.Scanner == nil {
+		return nil
+	}
+
+	image := s.image()
+	e.Log.Info(`Scan image `, image, ` of service `, s.Name)
+
+	result, err := e.Scanner.Scan(image)
+	if err != nil {
+		return err
+	}
+
+	threshold := severityLevels[strings.ToLower(s.Config.ScanThreshold)]
+
+	blocking := []interfaces.Finding{}
+	for _, f := range result.Findings {
+		if severityLevels[strings.ToLower(f.Severity)] >= threshold {
+			blocking = append(blocking, f)
+		}
+	}
+
+	if len(blocking) > 0 {
+		return ScanError{Image: image, Findings: blocking}
+	}
+
+	return nil
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Schedule is parsed cron expression: minute hour day-of-month month day-of-week
+type Schedule struct {
+	fields [5]map[int]bool
+}
+
+var cronBounds = [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 6}}
+
+// ParseSchedule parses five fields cron expression,
+// every field supports *, lists, ranges and steps like 1-5 or */15
+func ParseSchedule(expr string) (*Schedule, error) {
+
+	parts := strings.Fields(expr)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("cron expression should have 5 fields, got %d", len(parts))
+	}
+
+	s := &Schedule{}
+
+	for i, part := range parts {
+		field, err := parseCronField(part, cronBounds[i][0], cronBounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron field %q: %s", part, err)
+		}
+
+		s.fields[i] = field
+	}
+
+	return s, nil
+}
+
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+
+	values := make(map[int]bool)
+
+	for _, item := range strings.Split(field, ",") {
+
+		step := 1
+
+		if i := strings.Index(item, "/"); i != -1 {
+			n, err := strconv.Atoi(item[i+1:])
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("bad step %q", item[i+1:])
+			}
+
+			step, item = n, item[:i]
+		}
+
+		from, to := min, max
+
+		switch {
+		case item == "*":
+		case strings.Contains(item, "-"):
+			bounds := strings.SplitN(item, "-", 2)
+
+			var err error
+			if from, err = strconv.Atoi(bounds[0]); err != nil {
+				return nil, fmt.Errorf("bad range %q", item)
+			}
+			if to, err = strconv.Atoi(bounds[1]); err != nil {
+				return nil, fmt.Errorf("bad range %q", item)
+			}
+		default:
+			n, err := strconv.Atoi(item)
+			if err != nil {
+				return nil, fmt.Errorf("bad value %q", item)
+			}
+
+			from, to = n, n
+		}
+
+		if from < min || to > max || from > to {
+			return nil, fmt.Errorf("value out of range %d-%d", min, max)
+		}
+
+		for v := from; v <= to; v += step {
+			values[v] = true
+		}
+	}
+
+	return values, nil
+}
+
+// Match reports whether schedule fires at the minute of t
+func (s *Schedule) Match(t time.Time) bool {
+	return s.fields[0][t.Minute()] &&
+		s.fields[1][t.Hour()] &&
+		s.fields[2][t.Day()] &&
+		s.fields[3][int(t.Month())] &&
+		s.fields[4][int(t.Weekday())]
+}
+
+// Minute services were last restarted by schedule, so restart fires once per match
+var scheduled = struct {
+	sync.Mutex
+	last map[string]time.Time
+}{last: make(map[string]time.Time)}
+
+// RestartScheduledAll restarts services which restart schedule matches now
+func RestartScheduledAll(e *env.Env, now time.Time) error {
+
+	services, err := list(e)
+	if err != nil {
+		return err
+	}
+
+	minute := now.Truncate(time.Minute)
+
+	for _, s := range services {
+
+		if s.Config.RestartSchedule == "" || s.Maintenance || s.Suspended || s.Desired == DesiredStopped || len(s.Containers) == 0 {
+			continue
+		}
+
+		schedule, err := ParseSchedule(s.Config.RestartSchedule)
+		if err != nil {
+			e.Log.Error(err)
+			continue
+		}
+
+		if !schedule.Match(minute) {
+			continue
+		}
+
+		scheduled.Lock()
+		fired := scheduled.last[s.Name].Equal(minute)
+		scheduled.last[s.Name] = minute
+		scheduled.Unlock()
+
+		if fired {
+			continue
+		}
+
+		if s.Config.RestartJitter > 0 {
+			go s.restartDelayed(e, jitter(seconds(s.Config.RestartJitter)))
+			continue
+		}
+
+		if err := s.RollingRestart(e); err != nil {
+			e.Log.Error(err)
+		}
+	}
+
+	return nil
+}
+
+// Random delay up to window, so restarts scheduled at the same minute are spread
+func jitter(window time.Duration) time.Duration {
+	if window <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(window)))
+}
+
+// Rolling restart after delay, service is reloaded as it may change meanwhile
+func (s *Service) restartDelayed(e *env.Env, delay time.Duration) {
+
+	e.Log.Info(`Restart of service `, s.Name, ` delayed by `, delay)
+	time.Sleep(delay)
+
+	current := new(Service)
+	if err := current.Get(e, s.Name); err != nil {
+		e.Log.Error(err)
+		return
+	}
+
+	if current.Maintenance || current.Suspended || current.Desired == DesiredStopped || len(current.Containers) == 0 {
+		return
+	}
+
+	if err := current.RollingRestart(e); err != nil {
+		e.Log.Error(err)
+	}
+}
+
+// RollingRestart restarts service containers one by one,
+// next container is restarted only after service is healthy again
+func (s *Service) RollingRestart(e *env.Env) error {
+	e = s.logEnv(e)
+	e.Log.Info(`Rolling restart service `, s.Name)
+
+	if s.UUID == "" {
+		return ErrServiceNotFound
+	}
+
+	if err := checkDriver(e); err != nil {
+		return err
+	}
+
+	countMetric(metricRestarts, s.Name)
+
+	// Replicas are spread over jitter window, restart of the first one is not delayed
+	pause := seconds(s.Config.RestartJitter) / time.Duration(len(s.Containers)+1)
+	first := true
+
+	for _, container := range s.Containers {
+
+		if !first {
+			time.Sleep(jitter(pause))
+		}
+		first = false
+
+		s.drain(e, container.ID)
+
+		if err := e.Containers.RestartContainer(&interfaces.Container{
+			CID: container.ID,
+		}); err != nil {
+			e.Log.Error(err)
+			return err
+		}
+
+		s.register(e, container.ID)
+
+		if err := s.waitReady(e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
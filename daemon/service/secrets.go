@@ -0,0 +1,94 @@
+package service
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"io"
+	"os"
+	"strings"
+)
+
+// Checksum of secret files content, missing files are taken into account
+// so removal of a file is a change too
+func (c *Config) secretsChecksum() (string, error) {
+
+	if len(c.Secrets) == 0 {
+		return "", nil
+	}
+
+	hash := sha256.New()
+
+	for _, secret := range c.Secrets {
+
+		path := strings.Split(secret, ":")[0]
+		fmt.Fprintf(hash, "%s\x00", path)
+
+		file, err := os.Open(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+
+		_, err = io.Copy(hash, file)
+		file.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+// CheckConfigDrift reports whether mounted secret files were changed
+// since the checksum stored in service
+func (s *Service) CheckConfigDrift(e *env.Env) (bool, error) {
+	e.Log.Debug(`Check config drift of service `, s.Name)
+
+	if s.UUID == "" {
+		return false, ErrServiceNotFound
+	}
+
+	checksum, err := s.Config.secretsChecksum()
+	if err != nil {
+		return false, err
+	}
+
+	return checksum != s.SecretsChecksum, nil
+}
+
+// Restart service if secret files were changed and store their new checksum
+func (s *Service) restartOnDrift(e *env.Env) error {
+
+	if s.Suspended {
+		return nil
+	}
+
+	drift, err := s.CheckConfigDrift(e)
+	if err != nil || !drift {
+		return err
+	}
+
+	checksum, err := s.Config.secretsChecksum()
+	if err != nil {
+		return err
+	}
+
+	// First checksum of service only records current state
+	if s.SecretsChecksum != "" && len(s.Containers) > 0 {
+		e.Log.Info(`Secrets of service `, s.Name, ` were changed, reload it`)
+		if err := s.Reload(e); err != nil {
+			return err
+		}
+	}
+
+	s.SecretsChecksum = checksum
+
+	if err := s.Update(e); err != nil {
+		return err
+	}
+
+	return nil
+}
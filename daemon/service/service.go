@@ -1,29 +1,225 @@
 package service
 
 import (
-	"errors"
+	"context"
+	"fmt"
 	"github.com/deployithq/deployit/daemon/env"
 	"github.com/deployithq/deployit/drivers/interfaces"
+	"github.com/deployithq/deployit/utils"
 	"github.com/satori/go.uuid"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 )
 
 type Service struct {
 	UUID       string                `json:"uuid" yaml:"uuid"`
 	Name       string                `json:"name" yaml:"name"`
 	Tag        string                `json:"tag" yaml:"tag"`
+	Tags       []string              `json:"tags" yaml:"tags"`
+	Digest     string                `json:"digest" yaml:"digest"`
+	Version    int64                 `json:"version" yaml:"version"`
+	Schema     int                   `json:"schema" yaml:"schema"`
+	Replicas   int                   `json:"replicas" yaml:"replicas"`
 	Containers map[string]*Container `json:"container" yaml:"container"`
 	Config     Config                `json:"config" yaml:"config"`
+	ConfigPath string                `json:"config_path" yaml:"config_path"`
+	Canary     *Canary               `json:"canary,omitempty" yaml:"canary,omitempty"`
+	Suspended  bool                  `json:"suspended" yaml:"suspended"`
+	Desired    string                `json:"desired" yaml:"desired"`
+
+	// Set when containers exhausted restart attempts and stay dead
+	Failure *Failure `json:"failure,omitempty" yaml:"failure,omitempty"`
+
+	// Reconciler leaves service alone during hands-on maintenance
+	Maintenance bool `json:"maintenance,omitempty" yaml:"maintenance,omitempty"`
+
+	// Deploying or rolling back while containers are replaced, see status.go
+	Transition string `json:"transition,omitempty" yaml:"transition,omitempty"`
+
+	// Image used before the last UpdateImage
+	PreviousImage string `json:"previous_image" yaml:"previous_image"`
+
+	// Last deploys, oldest first, see history.go
+	History []HistoryEntry `json:"history,omitempty" yaml:"history,omitempty"`
+
+	// Images of successful deploys, oldest first, see gc.go
+	DeployedImages []string `json:"deployed_images,omitempty" yaml:"deployed_images,omitempty"`
+
+	LastDeployBy     string `json:"last_deploy_by" yaml:"last_deploy_by"`
+	LastDeployReason string `json:"last_deploy_reason" yaml:"last_deploy_reason"`
+
+	// Time of the last deploy attempt, used to limit deploy rate
+	LastDeploy time.Time `json:"last_deploy" yaml:"last_deploy"`
+
+	// Deploy containers are labeled with, see labels.go
+	DeployID   string    `json:"deploy_id" yaml:"deploy_id"`
+	DeployedAt time.Time `json:"deployed_at" yaml:"deployed_at"`
+
+	// Size in bytes and layers count of the last pulled image
+	ImageSize   int64 `json:"image_size" yaml:"image_size"`
+	ImageLayers int   `json:"image_layers" yaml:"image_layers"`
+
+	// Host ports allocated to replicas by index, reused when replicas are recreated
+	HostPorts map[int]map[int64]int64 `json:"host_ports,omitempty" yaml:"host_ports,omitempty"`
+
+	// Output of host hooks run by the last deploy
+	HookResults []HookResult `json:"hook_results,omitempty" yaml:"hook_results,omitempty"`
+
+	// Last exits of service containers, see exits.go
+	Exits []Exit `json:"exits,omitempty" yaml:"exits,omitempty"`
+
+	// Checksum of secret files containers were restarted with
+	SecretsChecksum string `json:"secrets_checksum" yaml:"secrets_checksum"`
+
+	progress chan<- ProgressEvent
+
+	// Fixed host ports are bound to random ones during port handoff
+	handoff bool
+
+	// Anonymous volumes binds of replaced containers by replica index
+	preserved map[int][]string
+
+	// Closed when in-flight deploy is canceled, see cancel.go
+	cancel chan struct{}
+}
+
+// Annotation tells who triggers deploy or start and why
+type Annotation struct {
+	By     string `json:"by"`
+	Reason string `json:"reason"`
+
+	// Deploys with the same key are made once, see IdempotencyTTL
+	Key string `json:"key"`
 }
 
 type Container struct {
-	ID string `json:"id" yaml:"id"`
+	ID        string            `json:"id" yaml:"id"`
+	Name      string            `json:"name" yaml:"name"`
+	Index     int               `json:"index" yaml:"index"`
+	CreatedAt time.Time         `json:"created_at" yaml:"created_at"`
+	Sidecars  map[string]string `json:"sidecars,omitempty" yaml:"sidecars,omitempty"`
+
+	// Image reference container was created from, pinned to digest when it was resolved
+	Image string `json:"image" yaml:"image"`
+
+	// Driver state seen on last inspect
+	LastExitCode int    `json:"last_exit_code" yaml:"last_exit_code"`
+	RestartCount int    `json:"restart_count" yaml:"restart_count"`
+	HealthState  string `json:"health_state" yaml:"health_state"`
+}
+
+const storagePrefix = `services`
+
+// Containers stopped at once
+const stopConcurrency = 10
+
+// Desired service states restored after daemon restart
+const (
+	DesiredRunning = `running`
+	DesiredStopped = `stopped`
+)
+
+// Serializes updates of service records in daemon, db serializes them between daemons
+var updateLock sync.Mutex
+
+func storageKey(name string) string {
+	return fmt.Sprintf("%s/%s", storagePrefix, name)
+}
+
+// Read all stored services, unreadable records are logged and skipped
+func list(e *env.Env) ([]*Service, error) {
+
+	services, failed, err := readServices(e, e.LDB)
+	if err != nil {
+		return services, err
+	}
+
+	for key, err := range failed {
+		e.Log.Error(key, `: `, err)
+	}
+
+	return services, nil
+}
+
+// ListStored returns every stored service ordered by name, records which can not be read
+// are skipped and returned as RecordErrors along with the readable services.
+// Services are read from read replica and should not be changed
+func ListStored(e *env.Env) ([]*Service, error) {
+	e.Log.Info(`List stored services`)
+
+	services, failed, err := readServices(e, e.Reader())
+	if err != nil {
+		return services, err
+	}
+
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Name < services[j].Name
+	})
+
+	if len(failed) > 0 {
+		return services, failed
+	}
+
+	return services, nil
+}
+
+// Services read for changes are read from LDB, replica may lag behind version Update checks
+func readServices(e *env.Env, db interfaces.ILDB) ([]*Service, RecordErrors, error) {
+
+	services := []*Service{}
+	failed := RecordErrors{}
+
+	keys, err := db.List(storagePrefix)
+	if err != nil {
+		return services, failed, err
+	}
+
+	for _, key := range keys {
+
+		// Record is decoded into its own service so partly decoded ones are dropped
+		s := new(Service)
+		if err := db.Read(key, s); err != nil {
+			failed[key] = err
+			continue
+		}
+
+		services = append(services, s)
+	}
+
+	return services, failed, nil
+}
+
+// Driver error for container removed outside of deployit
+func isNoSuchContainer(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "No such container")
+}
+
+// Drop record of container which does not exist in driver anymore
+func (s *Service) forgetContainer(e *env.Env, id string) {
+	e.Log.Info(`Clear record in db `, s.Name)
+	delete(s.Containers, id)
+}
+
+// Get reads service stored by name, services are stored and removed by name only
+func (s *Service) Get(e *env.Env, name string) error {
+	return s.read(e, e.LDB, name)
+}
+
+// View reads service like Get from read replica, service should not be changed
+// after it since replica may lag behind and Update would conflict
+func (s *Service) View(e *env.Env, name string) error {
+	return s.read(e, e.Reader(), name)
 }
 
-func (s *Service) Get(e *env.Env, key string) error {
-	e.Log.Info(`Get service `, key)
+func (s *Service) read(e *env.Env, db interfaces.ILDB, name string) error {
+	e.Log.Info(`Get service `, name)
 
-	if err := e.LDB.Read(key, s); err != nil {
+	if err := db.Read(storageKey(name), s); err != nil {
+		if err == interfaces.ErrKeyNotFound {
+			return ErrServiceNotFound
+		}
 		return err
 	}
 
@@ -34,52 +230,174 @@ func (s *Service) Update(e *env.Env) error {
 	e.Log.Info(`Update service `, s.Name)
 
 	if s.UUID == "" {
-		return errors.New("service not found")
+		return ErrServiceNotFound
 	}
 
-	if err := e.LDB.Write(s.Name, s); err != nil {
+	if !e.HostLocked {
+		return ErrHostNotLocked
+	}
+
+	updateLock.Lock()
+	defer updateLock.Unlock()
+
+	// Version is checked and record written atomically by db, also against other daemons
+	version := s.Version
+
+	stored := new(Service)
+	check := func() error {
+		if stored.Version != version {
+			return ErrConflict
+		}
+
+		return nil
+	}
+
+	s.Version++
+
+	invalidateInspect(s.Name)
+
+	if err := e.LDB.CompareAndSwap(storageKey(s.Name), stored, check, s); err != nil {
+		s.Version = version
+
+		switch err {
+		case interfaces.ErrKeyNotFound:
+			return ErrServiceNotFound
+		case interfaces.ErrKeyChanged:
+			return ErrConflict
+		}
+
 		return err
 	}
 
+	indexService(s)
+
 	return nil
 }
 
+// Create stores service with built-in config of the same name
 func (s *Service) Create(e *env.Env, name string) error {
+
+	config := Config{}
+	config.Get(e, name)
+
+	if config.Image == `` {
+		return ErrServiceNotFound
+	}
+
+	return s.create(e, name, config)
+}
+
+// CreateWithConfig stores service with provided config and returns it
+func CreateWithConfig(e *env.Env, name string, config Config) (*Service, error) {
+
+	s := new(Service)
+	if err := s.create(e, name, config); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *Service) create(e *env.Env, name string, config Config) error {
 	e.Log.Info(`Create service `, name)
 
+	if !e.HostLocked {
+		return ErrHostNotLocked
+	}
+
+	if !validName.MatchString(name) {
+		return ErrInvalidName
+	}
+
+	existing := new(Service)
+	if err := e.LDB.Read(storageKey(name), existing); err == nil && existing.UUID != "" {
+		return ErrAlreadyExists
+	}
+
 	u := uuid.NewV4()
 	s.UUID = u.String()
 	s.Name = name
 	s.Tag = `latest`
 	s.Containers = make(map[string]*Container)
+	s.Config = config
+	s.Config.applyProfile(e.Profile)
+
+	if s.Config.Scale > 0 {
+		s.Replicas = s.Config.Scale
+	}
+
+	if replicas := s.Config.profileReplicas(); replicas > 0 {
+		s.Replicas = replicas
+	}
+
+	if errs := s.Config.Validate(); len(errs) > 0 {
+		return errs
+	}
+
+	if err := s.Config.checkRequiredEnv(); err != nil {
+		return err
+	}
+
+	if err := s.Config.checkMounts(e); err != nil {
+		return err
+	}
+
+	if err := s.Config.checkHostHooks(e); err != nil {
+		return err
+	}
 
-	s.Config = Config{}
-	s.Config.Get(e, name)
+	if err := s.checkImage(e); err != nil {
+		return err
+	}
 
-	if s.Config.Image == `` {
-		return errors.New(`service not found`)
+	if err := s.checkReservedPorts(e); err != nil {
+		return err
 	}
 
-	if err := e.LDB.Write(s.Name, s); err != nil {
+	if err := s.checkQuota(e, s.replicas()); err != nil {
 		return err
 	}
 
+	// Service is started explicitly, reconcile does not start it before
+	s.Desired = DesiredStopped
+	s.Version = 1
+	s.Schema = schemaVersion
+
+	if err := e.LDB.Write(storageKey(s.Name), s); err != nil {
+		return err
+	}
+
+	indexService(s)
+
 	return nil
 }
 
 func (s *Service) Pull(e *env.Env) error {
+	return s.PullWithProgress(e, nil)
+}
+
+// PullWithProgress pulls service images sending layer progress events,
+// channel should be read until pull returns
+func (s *Service) PullWithProgress(e *env.Env, progress chan<- ProgressEvent) error {
+	e = s.logEnv(e)
 	e.Log.Info(`Pull service `, s.Config.Image)
 
-	opts := interfaces.Image{
-		Name: s.Config.Image,
-		Auth: interfaces.AuthConfig{},
+	if err := checkDriver(e); err != nil {
+		return err
 	}
 
-	if err := e.Containers.PullImage(opts); err != nil {
-		e.Log.Error(err)
+	s.progress = progress
+	defer func() { s.progress = nil }()
+
+	err := s.pullImages(context.Background(), e)
+
+	if err != nil {
+		s.report(ProgressEvent{Stage: StageFailed, Message: err.Error()})
 		return err
 	}
 
+	s.report(ProgressEvent{Stage: StageDone})
+
 	if err := s.Update(e); err != nil {
 		return err
 	}
@@ -87,175 +405,487 @@ func (s *Service) Pull(e *env.Env) error {
 	return nil
 }
 
+// Pull service and sidecars images without storing the service
+func (s *Service) pullImages(ctx context.Context, e *env.Env) error {
+
+	started := time.Now()
+	defer func() { observeMetric(metricPullDuration, s.Name, time.Since(started)) }()
+
+	opts := interfaces.Image{
+		Name:             s.image(),
+		Auth:             s.registryAuth(e, s.Config.Image),
+		RequiredPlatform: s.Config.RequiredPlatform,
+	}
+
+	if s.progress != nil {
+		s.report(ProgressEvent{Stage: StagePulling, Message: "pulling " + opts.Name})
+		opts.OutputStream = &pullProgress{ctx: ctx, progress: s.progress, layers: make(map[string]bool)}
+	}
+
+	if s.Config.ImageTarball != "" {
+		if err := s.loadTarball(e); err != nil {
+			e.Log.Error(err)
+			return err
+		}
+	} else if s.pulled(e) {
+		e.Log.Info(`Image `, opts.Name, ` is already pulled`)
+	} else if err := pullImage(ctx, e, opts); err != nil {
+		e.Log.Error(err)
+		return err
+	}
+
+	if err := e.Containers.InspectImage(&opts); err != nil {
+		e.Log.Error(err)
+	} else {
+		s.ImageSize, s.ImageLayers = opts.Size, opts.Layers
+		e.Log.Info(`Pulled `, opts.Name, `: `, opts.Size, ` bytes in `, opts.Layers, ` layers`)
+	}
+
+	for _, sidecar := range s.Config.Sidecars {
+		if err := pullImage(ctx, e, interfaces.Image{
+			Name:             sidecar.Image,
+			Auth:             s.registryAuth(e, sidecar.Image),
+			RequiredPlatform: s.Config.RequiredPlatform,
+		}); err != nil {
+			e.Log.Error(err)
+			return err
+		}
+	}
+
+	for _, spec := range s.Config.InitContainers {
+		if err := pullImage(ctx, e, interfaces.Image{
+			Name:             spec.Image,
+			Auth:             s.registryAuth(e, spec.Image),
+			RequiredPlatform: s.Config.RequiredPlatform,
+		}); err != nil {
+			e.Log.Error(err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Empty annotation keeps previous one
+func (s *Service) annotate(note Annotation) {
+	if note.By == "" && note.Reason == "" {
+		return
+	}
+
+	s.LastDeployBy = note.By
+	s.LastDeployReason = note.Reason
+}
+
+// Pull image waiting for a free pull slot of the daemon, slot is released when
+// context is done even if driver is still pulling
+func pullImage(ctx context.Context, e *env.Env, opts interfaces.Image) error {
+
+	if e.Pulls != nil {
+		select {
+		case e.Pulls <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		defer func() { <-e.Pulls }()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- e.Containers.PullImage(opts)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (s *Service) Start(e *env.Env) error {
+	return s.StartWith(e, Annotation{})
+}
+
+// StartWith starts service recording provided annotation
+func (s *Service) StartWith(e *env.Env, note Annotation) error {
+	e = s.logEnv(e)
 	e.Log.Info(`Start service `, s.Name)
 
 	if s.UUID == "" {
-		return errors.New("service not found")
+		return ErrServiceNotFound
+	}
+
+	if err := checkDriver(e); err != nil {
+		return err
+	}
+
+	if err := s.checkQuota(e, s.replicas()); err != nil {
+		return err
+	}
+
+	if err := s.runInitContainers(e); err != nil {
+		return err
 	}
 
-	//TODO: implement scale
+	s.annotate(note)
+	s.stampDeploy()
+	s.Suspended = false
+	s.Desired = DesiredRunning
 
-	hcfg := interfaces.HostConfig{
-		Memory:     s.Config.Memory,
-		Ports:      s.Config.Ports,
-		Binds:      s.Config.Volumes,
-		Privileged: false,
-		RestartPolicy: interfaces.RestartPolicyConfig{
-			Attempt: 10,
-			Name:    "always",
-		},
+	hcfg := s.hostConfig()
+
+	files, err := s.writeConfigFiles(e)
+	if err != nil {
+		return err
 	}
 
+	hcfg.Binds = append(hcfg.Binds, files...)
+
 	// Run containers if exists
 	for _, container := range s.Containers {
 
+		if err := s.startSidecars(e, container, true); err != nil {
+			return err
+		}
+
 		if err := e.Containers.StartContainer(&interfaces.Container{
 			CID:        container.ID,
 			HostConfig: hcfg,
 		}); err != nil {
 			e.Log.Error(err)
+			if isNoSuchContainer(err) {
+				s.forgetContainer(e, container.ID)
+				continue
+			}
+
 			return err
 		}
-	}
 
-	for len(s.Containers) == 0 {
+		s.register(e, container.ID)
 
-		c := &interfaces.Container{
-			Config: interfaces.Config{
-				Image:   s.Config.Image,
-				Memory:  s.Config.Memory,
-				Ports:   s.Config.Ports,
-				Volumes: s.Config.Volumes,
-				Env:     s.Config.Env,
-			},
-			HostConfig: hcfg,
+		if err := s.startSidecars(e, container, false); err != nil {
+			return err
 		}
+	}
 
-		if err := e.Containers.StartContainer(c); err != nil {
-			e.Log.Error(err)
+	for len(s.Containers) < s.replicas() {
+		if _, err := s.createContainer(e); err != nil {
+			// Keep already started containers recorded, so next start tops them up
+			s.Update(e)
 			return err
 		}
+	}
 
-		s.Containers[c.CID] = &Container{
-			ID: c.CID,
-		}
+	if err := s.removeSurplus(e); err != nil {
+		s.Update(e)
+		return err
 	}
 
 	if err := s.Update(e); err != nil {
 		return err
 	}
 
+	if err := s.waitReady(e); err != nil {
+		s.stopUnready(e)
+		return err
+	}
+
+	s.recordHistory(false)
+	if err := s.Update(e); err != nil {
+		e.Log.Error(err)
+	}
+
+	s.upsertDNS(e)
+
 	return nil
 }
 
 func (s *Service) Stop(e *env.Env) error {
+	e = s.logEnv(e)
+	e.Log.Info(`Stop service `, s.Name)
+
+	if s.UUID == "" {
+		return ErrServiceNotFound
+	}
+
+	if err := checkDriver(e); err != nil {
+		return err
+	}
+
+	s.Desired = DesiredStopped
+	s.deleteDNS(e)
+
+	return s.stop(e)
+}
+
+// StopWithResult stops service like Stop and returns outcome of every container,
+// nil error means container was stopped
+func (s *Service) StopWithResult(e *env.Env) (map[string]error, error) {
+	e = s.logEnv(e)
 	e.Log.Info(`Stop service `, s.Name)
 
 	if s.UUID == "" {
-		return errors.New("service not found")
+		return nil, ErrServiceNotFound
 	}
 
+	if err := checkDriver(e); err != nil {
+		return nil, err
+	}
+
+	s.Desired = DesiredStopped
+	s.deleteDNS(e)
+
+	return s.stopAll(e)
+}
+
+// Stop containers keeping desired state, so service is started again after daemon restart
+func (s *Service) stop(e *env.Env) error {
+	_, err := s.stopAll(e)
+	return err
+}
+
+// Stop containers concurrently, at most stopConcurrency at once,
+// failed containers are reported together after all of them are processed
+func (s *Service) stopAll(e *env.Env) (map[string]error, error) {
+
+	var (
+		lock    sync.Mutex
+		wg      sync.WaitGroup
+		slots   = make(chan struct{}, stopConcurrency)
+		results = make(map[string]error)
+	)
+
 	for _, container := range s.Containers {
 
 		if container.ID == "" {
 			continue
 		}
 
-		if err := e.Containers.StopContainer(&interfaces.Container{
-			CID: container.ID,
-		}); err != nil {
-			e.Log.Error(err)
-			return err
+		wg.Add(1)
+
+		go func(container *Container) {
+			defer wg.Done()
+
+			slots <- struct{}{}
+			err := s.stopReplica(e, container)
+			<-slots
+
+			// Exit is captured before container can be removed
+			exit, _, inspectErr := inspectExit(e, container, ExitStopped)
+
+			lock.Lock()
+			results[container.ID] = err
+			if err == nil && inspectErr == nil {
+				s.recordExit(exit)
+			}
+			lock.Unlock()
+		}(container)
+	}
+
+	wg.Wait()
+
+	failed := ContainerErrors{}
+
+	for id, err := range results {
+		if err == nil {
+			continue
+		}
+
+		e.Log.Error(err)
+		if isNoSuchContainer(err) {
+			s.forgetContainer(e, id)
+			continue
 		}
+
+		failed[id] = err
 	}
 
 	if err := s.Update(e); err != nil {
-		return err
+		return results, err
 	}
 
-	return nil
+	if len(failed) > 0 {
+		return results, failed
+	}
+
+	return results, nil
 }
 
+// Restart service containers, containers created from other config are recreated
 func (s *Service) Restart(e *env.Env) error {
+	return s.RestartWith(e, false)
+}
+
+// RestartWith restarts service like Restart, with pull set image is pulled first
+// and containers created from other image than the pulled one are recreated too
+func (s *Service) RestartWith(e *env.Env, pull bool) error {
+	e = s.logEnv(e)
 	e.Log.Info(`Restart service `, s.Name)
 
-	//TODO: implement start with configs
-	//TODO: implement scale
+	if s.UUID == "" {
+		return ErrServiceNotFound
+	}
+
+	if err := checkDriver(e); err != nil {
+		return err
+	}
+
+	countMetric(metricRestarts, s.Name)
+
+	defer invalidateInspect(s.Name)
+
+	if pull {
+		if err := s.pullImages(context.Background(), e); err != nil {
+			return err
+		}
+	}
+
+	s.Suspended = false
+	s.Desired = DesiredRunning
 
 	if err := s.Update(e); err != nil {
 		return err
 	}
 
-	hcfg := interfaces.HostConfig{
-		Memory:     s.Config.Memory,
-		Ports:      s.Config.Ports,
-		Binds:      s.Config.Volumes,
-		Privileged: false,
-		RestartPolicy: interfaces.RestartPolicyConfig{
-			Attempt: 10,
-			Name:    "always",
-		},
+	// Containers running old config are replaced instead of restarted
+	changed, err := s.Diff(e)
+	if err != nil {
+		e.Log.Error(err)
+	}
+
+	if len(changed) > 0 {
+		e.Log.Info(`Config of service `, s.Name, ` was changed, recreate containers`)
+		return s.replaceContainers(e)
+	}
+
+	if pull {
+		outdated, err := s.outdatedImage(e)
+		if err != nil {
+			e.Log.Error(err)
+		}
+
+		if len(outdated) > 0 {
+			e.Log.Info(`Image of service `, s.Name, ` was changed, recreate containers`)
+			return s.replaceContainers(e)
+		}
+	}
+
+	if s.canSurge() {
+		err := s.surgeRestart(e)
+		if err == nil {
+			return nil
+		}
+
+		e.Log.Error(err)
+		e.Log.Info(`Surge restart of service `, s.Name, ` failed, restart in place`)
 	}
 
+	hcfg := s.hostConfig()
+
 	// Run containers if exists
 	for _, container := range s.Containers {
+		s.drain(e, container.ID)
+
 		if err := e.Containers.RestartContainer(&interfaces.Container{
 			CID:        container.ID,
 			HostConfig: hcfg,
 		}); err != nil {
 			e.Log.Error(err)
+			if isNoSuchContainer(err) {
+				s.forgetContainer(e, container.ID)
+				continue
+			}
+
 			return err
 		}
+
+		s.register(e, container.ID)
+
+		for _, id := range container.Sidecars {
+			if err := e.Containers.RestartContainer(&interfaces.Container{
+				CID: id,
+			}); err != nil {
+				e.Log.Error(err)
+				return err
+			}
+		}
 	}
 
-	for len(s.Containers) == 0 {
-		c := &interfaces.Container{
-			Config: interfaces.Config{
-				Image:   s.Config.Image,
-				Memory:  s.Config.Memory,
-				Ports:   s.Config.Ports,
-				Volumes: s.Config.Volumes,
-				Env:     s.Config.Env,
-			},
-			HostConfig: hcfg,
+	for len(s.Containers) < s.replicas() {
+		if _, err := s.createContainer(e); err != nil {
+			s.Update(e)
+			return err
 		}
+	}
+
+	if err := s.removeSurplus(e); err != nil {
+		s.Update(e)
+		return err
+	}
+
+	if err := s.Update(e); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Remove containers over replicas count, the ones surplus selects
+func (s *Service) removeSurplus(e *env.Env) error {
+
+	n := len(s.Containers) - s.replicas()
+	if n <= 0 {
+		return nil
+	}
 
-		if err := e.Containers.StartContainer(c); err != nil {
+	for _, key := range s.surplus(e, n) {
+
+		if err := s.removeContainer(e, s.Containers[key]); err != nil && !isNoSuchContainer(err) {
 			e.Log.Error(err)
 			return err
 		}
 
-		s.Containers[c.CID] = &Container{
-			ID: c.CID,
-		}
+		delete(s.Containers, key)
 	}
 
 	return nil
 }
 
+// Remove service containers with their volumes
 func (s *Service) Remove(e *env.Env) error {
+	return s.RemoveWith(e, false)
+}
+
+// RemoveWith removes service containers, volumes are kept
+// for stateful services when keepVolumes is set
+func (s *Service) RemoveWith(e *env.Env, keepVolumes bool) error {
+	e = s.logEnv(e)
 	e.Log.Info(`Remove service `, s.Name)
 
 	if s.UUID == "" {
-		return errors.New("service not found")
+		return ErrServiceNotFound
+	}
+
+	if err := checkDriver(e); err != nil {
+		return err
 	}
 
 	for key, container := range s.Containers {
 		if container.ID != "" {
-			if err := e.Containers.RemoveContainer(&interfaces.Container{
-				CID: container.ID,
-			}); err != nil {
+			if err := s.removeReplica(e, container, keepVolumes); err != nil {
 				e.Log.Error(err)
-				index := strings.Index(err.Error(), "No such container")
-				if index != -1 {
-					e.Log.Info(`Clear record in db `, s.Name)
-					delete(s.Containers, key)
+				if isNoSuchContainer(err) {
+					s.forgetContainer(e, key)
 					continue
 				}
 
 				return err
 			}
+
+			if err := s.waitRemoved(e, container.ID); err != nil {
+				e.Log.Error(err)
+				return err
+			}
 		}
 
 		delete(s.Containers, key)
@@ -268,25 +898,72 @@ func (s *Service) Remove(e *env.Env) error {
 	return nil
 }
 
+func (s *Service) RemoveContainer(e *env.Env, id string) error {
+	e.Log.Info(`Remove container `, id, ` of service `, s.Name)
+
+	if s.UUID == "" {
+		return ErrServiceNotFound
+	}
+
+	if _, ok := s.Containers[id]; !ok {
+		return ErrContainerNotFound
+	}
+
+	if err := s.removeContainer(e, s.Containers[id]); err != nil {
+		e.Log.Error(err)
+		if !isNoSuchContainer(err) {
+			return err
+		}
+	} else if err := s.waitRemoved(e, id); err != nil {
+		e.Log.Error(err)
+		return err
+	}
+
+	s.forgetContainer(e, id)
+
+	if err := s.Update(e); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (s *Service) Destroy(e *env.Env) error {
+	return s.DestroyWith(e, false)
+}
+
+// DestroyWith removes service containers and record, with force containers which fail
+// to be removed are killed and the record is removed even if some of them are left
+func (s *Service) DestroyWith(e *env.Env, force bool) error {
 	e.Log.Info(`Destroy service `, s.Name)
 
 	if s.UUID == "" {
-		return errors.New("service not found")
+		return ErrServiceNotFound
 	}
 
 	if err := s.Remove(e); err != nil {
-		return err
+		if !force {
+			return err
+		}
+
+		e.Log.Error(err)
+		s.forceRemove(e)
 	}
 
-	if err := e.LDB.Remove(s.UUID); err != nil {
+	if err := e.LDB.Remove(storageKey(s.Name)); err != nil {
 		return err
 	}
 
+	unindexService(s.Name)
+	releasePorts(e, s.Name)
+	e.LDB.Remove(healthKey(s.Name))
+	s.removeConfigFiles(e)
+
 	return nil
 }
 
-// Todo: temporary solution
+// Ports returns first published host port of one service container, clients use it as
+// service address, 0 is returned if service has no containers, see PortMappings for all of them
 func (s *Service) Ports(e *env.Env) (int64, error) {
 
 	var port int64
@@ -295,20 +972,327 @@ func (s *Service) Ports(e *env.Env) (int64, error) {
 		return port, nil
 	}
 
-	for _, container := range s.Containers {
-		ports, err := e.Containers.InspectContainers(&interfaces.Container{
-			CID: container.ID,
-		})
+	// Slow driver should not block callers
+	ctx, cancel := context.WithTimeout(context.Background(), s.Config.Timeouts.inspect())
+	defer cancel()
 
+	for _, container := range s.Containers {
+		ports, err := inspectPorts(ctx, e, container.ID)
 		if err != nil {
 			e.Log.Error(err)
 			return port, err
 		}
 
-		port = ports[0]
+		if len(ports) > 0 {
+			port = ports[0]
+		}
 
 		break
 	}
 
 	return port, nil
 }
+
+// PortMappings returns published host ports of every container keyed by container id,
+// containers without published ports have empty list
+func (s *Service) PortMappings(e *env.Env) (map[string][]int64, error) {
+
+	mappings := make(map[string][]int64)
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.Config.Timeouts.inspect())
+	defer cancel()
+
+	for _, container := range s.Containers {
+		ports, err := inspectPorts(ctx, e, container.ID)
+		if err != nil {
+			e.Log.Error(err)
+			return mappings, err
+		}
+
+		if ports == nil {
+			ports = []int64{}
+		}
+
+		mappings[container.ID] = ports
+	}
+
+	return mappings, nil
+}
+
+func inspectPorts(ctx context.Context, e *env.Env, id string) ([]int64, error) {
+
+	ports, err := e.Containers.InspectContainers(ctx, &interfaces.Container{CID: id})
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, ErrInspectTimeout
+	}
+
+	return ports, err
+}
+
+// SetPorts changes published ports, containers are recreated since
+// port bindings can not be changed in place
+func (s *Service) SetPorts(e *env.Env, ports []string) error {
+	e.Log.Info(`Set ports for service `, s.Name)
+
+	if s.UUID == "" {
+		return ErrServiceNotFound
+	}
+
+	prev := s.Config
+
+	config := s.Config
+	config.Ports = ports
+
+	if err := s.UpdateConfig(e, &config); err != nil {
+		return err
+	}
+
+	if err := s.replaceContainers(e); err != nil {
+		s.Config = prev
+		s.Update(e)
+		return err
+	}
+
+	return nil
+}
+
+func (s *Service) hostConfig() interfaces.HostConfig {
+
+	binds := append([]string{}, s.Config.Volumes...)
+
+	for _, secret := range s.Config.Secrets {
+		if len(strings.Split(secret, ":")) == 2 {
+			secret += ":ro"
+		}
+		binds = append(binds, secret)
+	}
+	if s.Config.DockerSocket {
+		binds = append(binds, fmt.Sprintf("%s:%s:ro", dockerSocket, dockerSocket))
+	}
+
+	return interfaces.HostConfig{
+		Memory:        s.Config.Memory,
+		CPUs:          s.Config.CPUs,
+		Ports:         s.hostPorts(),
+		Binds:         binds,
+		Privileged:    false,
+		RestartPolicy: s.Config.driverRestartPolicy(),
+		CapAdd:        s.Config.CapAdd,
+		CapDrop:       s.Config.CapDrop,
+		Sysctls:       s.Config.Sysctls,
+		PidMode:       s.Config.PidMode,
+		IpcMode:       s.Config.IpcMode,
+		AutoRemove:    s.Config.AutoRemove,
+		ShmSize:       int64(s.Config.ShmSize),
+		SecurityOpt:   s.Config.SecurityOpt,
+		CgroupParent:  s.Config.CgroupParent,
+
+		BlkioWeight:         s.Config.BlkioWeight,
+		BlkioDeviceReadBps:  s.Config.BlkioDeviceReadBps,
+		BlkioDeviceWriteBps: s.Config.BlkioDeviceWriteBps,
+	}
+}
+
+// Image reference containers are created from, pinned to digest when it is resolved
+func (s *Service) image() string {
+	if s.Digest != "" {
+		return fmt.Sprintf("%s@%s", s.Config.Image, s.Digest)
+	}
+
+	// Tag of service is used when image does not name one, latest is pulled by default
+	if _, _, tag := utils.ParseImage(s.Config.Image); tag == "" && s.Tag != "" && s.Tag != `latest` {
+		return fmt.Sprintf("%s:%s", s.Config.Image, s.Tag)
+	}
+
+	return s.Config.Image
+}
+
+func (s *Service) containerConfig(image string, index int) (interfaces.Config, error) {
+
+	env, err := s.Config.replicaEnv(s.Name, index)
+	if err != nil {
+		return interfaces.Config{}, err
+	}
+
+	hostname, err := renderReplica(s.Config.Hostname, s.Name, index)
+	if err != nil {
+		return interfaces.Config{}, err
+	}
+
+	labels, err := s.labels()
+	if err != nil {
+		return interfaces.Config{}, err
+	}
+
+	return interfaces.Config{
+		Image:            image,
+		Memory:           s.Config.Memory,
+		Ports:            s.Config.Ports,
+		Volumes:          s.Config.Volumes,
+		Env:              env,
+		RequiredPlatform: s.Config.RequiredPlatform,
+		Hostname:         hostname,
+		Labels:           labels,
+	}, nil
+}
+
+// Create and start a new container from the current config and track it in the service
+func (s *Service) createContainer(e *env.Env) (string, error) {
+	return s.createContainerFrom(e, s.image())
+}
+
+func (s *Service) createContainerFrom(e *env.Env, image string) (string, error) {
+
+	container, err := s.newContainer(context.Background(), e, image, s.freeIndex())
+
+	if container != nil {
+		if s.Containers == nil {
+			s.Containers = make(map[string]*Container)
+		}
+
+		s.Containers[container.ID] = container
+
+		return container.ID, err
+	}
+
+	return "", err
+}
+
+// Start replica with its sidecars without recording it, replica is returned
+// when its main container is started even if sidecars failed
+func (s *Service) newContainer(ctx context.Context, e *env.Env, image string, index int) (*Container, error) {
+
+	if !e.HostLocked {
+		return nil, ErrHostNotLocked
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := s.Config.checkMounts(e); err != nil {
+		return nil, err
+	}
+
+	config, err := s.containerConfig(image, index)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Env, err = resolveServiceRefs(e, config.Env); err != nil {
+		return nil, err
+	}
+
+	container := &Container{
+		Index: index,
+	}
+
+	// Sidecars main container waits for are started first
+	if err := s.createSidecars(ctx, e, container, true); err != nil {
+		s.removeSidecars(e, container)
+		return nil, err
+	}
+
+	c := &interfaces.Container{
+		Name:       s.containerName(e, index),
+		Config:     config,
+		HostConfig: s.hostConfig(),
+		Placement:  s.Config.Placement,
+		Context:    ctx,
+	}
+
+	files, err := s.writeConfigFiles(e)
+	if err != nil {
+		s.removeSidecars(e, container)
+		return nil, err
+	}
+
+	c.HostConfig.Binds = append(c.HostConfig.Binds, files...)
+	c.HostConfig.Binds = append(c.HostConfig.Binds, s.preserved[index]...)
+
+	err = s.startOnPorts(e, c, index)
+	if isNameConflict(err) && c.CID == "" {
+		c.Name = uniqueName(c.Name)
+		err = s.startOnPorts(e, c, index)
+	}
+
+	if err != nil {
+		e.Log.Error(err)
+		s.removeSidecars(e, container)
+		return nil, err
+	}
+
+	container.ID = c.CID
+	container.Name = c.Name
+	container.Image = image
+	container.CreatedAt = time.Now()
+	container.HealthState = StateRunning
+	s.register(e, c.CID)
+
+	if err := s.createSidecars(ctx, e, container, false); err != nil {
+		return container, err
+	}
+
+	return container, nil
+}
+
+func (s *Service) forceDestroy(e *env.Env) error {
+	return s.DestroyWith(e, true)
+}
+
+// Kill and remove containers left after failed remove, containers which
+// still can not be removed are logged as orphans
+func (s *Service) forceRemove(e *env.Env) {
+
+	for key, container := range s.Containers {
+
+		for _, id := range container.Sidecars {
+			e.Containers.KillContainer(&interfaces.Container{CID: id}, signalKill)
+		}
+
+		if err := e.Containers.KillContainer(&interfaces.Container{CID: container.ID}, signalKill); err != nil {
+			e.Log.Error(err)
+		}
+
+		if err := s.removeContainer(e, container); err != nil && !isNoSuchContainer(err) {
+			e.Log.Error(`Orphan container `, container.ID, ` of service `, s.Name, `: `, err)
+		}
+
+		delete(s.Containers, key)
+	}
+}
+
+// Stop container gracefully and kill it if stop fails or hangs longer than grace period
+func (s *Service) stopContainer(e *env.Env, id string) error {
+
+	if !e.HostLocked {
+		return ErrHostNotLocked
+	}
+
+	if len(s.Config.StopEscalation) > 0 {
+		return s.escalateStop(e, id)
+	}
+
+	grace := s.Config.stopGracePeriod()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- e.Containers.StopContainer(&interfaces.Container{CID: id, StopTimeout: uint(grace.Seconds())})
+	}()
+
+	// Driver kills container itself after grace period, margin covers its kill
+	select {
+	case err := <-done:
+		if err == nil || isNoSuchContainer(err) {
+			return err
+		}
+		e.Log.Error(err)
+	case <-time.After(grace + stopKillMargin):
+		e.Log.Info(`Stop timed out for container `, id)
+	}
+
+	e.Log.Info(`Kill container `, id, ` of service `, s.Name)
+
+	return e.Containers.KillContainer(&interfaces.Container{CID: id}, signalKill)
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"errors"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"sync"
+	"testing"
+)
+
+// Driver which stops every container except failing ones
+type stopContainers struct {
+	interfaces.IContainers
+	failing map[string]error
+}
+
+func (c stopContainers) Ping() error {
+	return nil
+}
+
+func (c stopContainers) StopContainer(container *interfaces.Container) error {
+	return c.failing[container.CID]
+}
+
+func (c stopContainers) KillContainer(container *interfaces.Container, signal string) error {
+	return c.failing[container.CID]
+}
+
+func (c stopContainers) InspectContainer(container *interfaces.Container) error {
+	return c.failing[container.CID]
+}
+
+func TestUpdateConflict(t *testing.T) {
+
+	e := testEnv(t)
+
+	if err := e.LDB.Write(storageKey("web"), &Service{UUID: "uuid-web", Name: "web", Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	first, second := new(Service), new(Service)
+	if err := first.Get(e, "web"); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Get(e, "web"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		s       *Service
+		err     error
+		version int64
+	}{
+		{"first writer", first, nil, 2},
+		{"stale writer", second, ErrConflict, 1},
+	}
+
+	for _, tt := range tests {
+		if err := tt.s.Update(e); err != tt.err {
+			t.Errorf("%s: Update() = %v, want %v", tt.name, err, tt.err)
+		}
+
+		if tt.s.Version != tt.version {
+			t.Errorf("%s: version = %d, want %d", tt.name, tt.s.Version, tt.version)
+		}
+	}
+
+	if err := (&Service{UUID: "uuid-db", Name: "db", Version: 1}).Update(e); err != ErrServiceNotFound {
+		t.Errorf("Update() of missing record = %v, want %v", err, ErrServiceNotFound)
+	}
+}
+
+func TestUpdateRace(t *testing.T) {
+
+	e := testEnv(t)
+
+	if err := e.LDB.Write(storageKey("web"), &Service{UUID: "uuid-web", Name: "web", Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	services := []*Service{new(Service), new(Service)}
+	for _, s := range services {
+		if err := s.Get(e, "web"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	errs := make([]error, len(services))
+
+	var wg sync.WaitGroup
+	for i, s := range services {
+		wg.Add(1)
+		go func(i int, s *Service) {
+			defer wg.Done()
+			errs[i] = s.Update(e)
+		}(i, s)
+	}
+	wg.Wait()
+
+	updated, conflicts := 0, 0
+	for _, err := range errs {
+		switch err {
+		case nil:
+			updated++
+		case ErrConflict:
+			conflicts++
+		default:
+			t.Fatal(err)
+		}
+	}
+
+	if updated != 1 || conflicts != 1 {
+		t.Errorf("updated = %d, conflicts = %d, want one of each", updated, conflicts)
+	}
+
+	stored := new(Service)
+	if err := stored.Get(e, "web"); err != nil {
+		t.Fatal(err)
+	}
+
+	if stored.Version != 2 {
+		t.Errorf("stored version = %d, want 2", stored.Version)
+	}
+}
+
+func TestStopWithResult(t *testing.T) {
+
+	e := testEnv(t)
+
+	errStuck := errors.New("container is stuck")
+	e.Containers = stopContainers{failing: map[string]error{
+		"stuck": errStuck,
+		"gone":  errors.New("No such container: gone"),
+	}}
+
+	s := &Service{UUID: "uuid-web", Name: "web", Version: 1, Containers: map[string]*Container{
+		"ok":    {ID: "ok"},
+		"stuck": {ID: "stuck", Index: 1},
+		"gone":  {ID: "gone", Index: 2},
+	}}
+
+	if err := e.LDB.Write(storageKey(s.Name), s); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := s.StopWithResult(e)
+
+	failed, ok := err.(ContainerErrors)
+	if !ok || len(failed) != 1 || failed["stuck"] != errStuck {
+		t.Fatalf("StopWithResult() error = %v, want only stuck container failed", err)
+	}
+
+	tests := []struct {
+		id     string
+		failed bool
+		kept   bool
+	}{
+		{"ok", false, true},
+		{"stuck", true, true},
+		{"gone", true, false},
+	}
+
+	for _, tt := range tests {
+		if result, ok := results[tt.id]; !ok || (result != nil) != tt.failed {
+			t.Errorf("result of %s = %v, failed %v", tt.id, result, tt.failed)
+		}
+
+		if _, ok := s.Containers[tt.id]; ok != tt.kept {
+			t.Errorf("container %s kept = %v, want %v", tt.id, ok, tt.kept)
+		}
+	}
+
+	if s.Desired != DesiredStopped {
+		t.Errorf("desired = %q, want %q", s.Desired, DesiredStopped)
+	}
+}
+
+func TestDestroyThenGet(t *testing.T) {
+
+	e := testEnv(t)
+	e.Containers = stopContainers{}
+
+	s, err := CreateWithConfig(e, "web", Config{Image: "nginx"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Destroy(e); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := new(Service).Get(e, "web"); err != ErrServiceNotFound {
+		t.Errorf("Get() of destroyed service = %v, want %v", err, ErrServiceNotFound)
+	}
+
+	if err := new(Service).Destroy(e); err != ErrServiceNotFound {
+		t.Errorf("Destroy() of missing service = %v, want %v", err, ErrServiceNotFound)
+	}
+}
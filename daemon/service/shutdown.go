@@ -0,0 +1,106 @@
+package service
+
+import (
+	"errors"
+	"github.com/deployithq/deployit/daemon/env"
+	"time"
+)
+
+// Sort services so every service follows the services it depends on,
+// services in dependency cycle keep their order
+func dependencyOrder(services []*Service) []*Service {
+
+	byName := make(map[string]*Service)
+	for _, s := range services {
+		byName[s.Name] = s
+	}
+
+	ordered := []*Service{}
+	visited := make(map[string]bool)
+
+	var visit func(s *Service)
+	visit = func(s *Service) {
+		if visited[s.Name] {
+			return
+		}
+
+		visited[s.Name] = true
+
+		for _, name := range s.Config.DependsOn {
+			if dependency, ok := byName[name]; ok {
+				visit(dependency)
+			}
+		}
+
+		ordered = append(ordered, s)
+	}
+
+	for _, s := range services {
+		visit(s)
+	}
+
+	return ordered
+}
+
+// ShutdownAll stops all stored services, dependent services are stopped first
+func ShutdownAll(e *env.Env, timeout time.Duration) error {
+	e.Log.Info(`Shutdown services`)
+
+	services, err := list(e)
+	if err != nil {
+		return err
+	}
+
+	ordered := dependencyOrder(services)
+
+	done := make(chan error, 1)
+
+	go func() {
+		var failed error
+
+		for i := len(ordered) - 1; i >= 0; i-- {
+			if err := ordered[i].stop(e); err != nil {
+				e.Log.Error(err)
+				failed = err
+			}
+		}
+
+		done <- failed
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		return errors.New("services shutdown timeout exceeded")
+	}
+}
+
+// RestoreAll starts services which were running before daemon restart,
+// dependencies first
+func RestoreAll(e *env.Env) error {
+	e.Log.Info(`Restore services`)
+
+	services, err := list(e)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range dependencyOrder(services) {
+
+		// Deploy interrupted by daemon stop is not in progress anymore
+		if s.Transition != "" {
+			s.endTransition(e)
+		}
+
+		if s.Desired != DesiredRunning || s.Suspended {
+			continue
+		}
+
+		if err := s.Start(e); err != nil {
+			e.Log.Error(err)
+		}
+	}
+
+	return nil
+}
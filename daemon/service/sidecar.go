@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"time"
+)
+
+const (
+	stopBefore = `before`
+	stopAfter  = `after`
+)
+
+// Sidecar is a helper container started next to every replica of the service
+type Sidecar struct {
+	Name    string   `json:"name" yaml:"name"`
+	Image   string   `json:"image" yaml:"image"`
+	Env     []string `json:"env" yaml:"env"`
+	CMD     []string `json:"cmd" yaml:"cmd"`
+	Volumes []string `json:"volumes" yaml:"volumes"`
+
+	// Stop sidecar before or after the main container, after if empty
+	StopOrder string `json:"stop_order" yaml:"stop_order"`
+
+	// Start sidecar before the main container and wait until it is running
+	// and passes readiness probe if it is set
+	WaitForReady   bool     `json:"wait_for_ready" yaml:"wait_for_ready"`
+	Ports          []string `json:"ports" yaml:"ports"`
+	ReadinessProbe *Probe   `json:"readiness_probe,omitempty" yaml:"readiness_probe,omitempty"`
+}
+
+func (sc *Sidecar) stopBefore() bool {
+	return sc.StopOrder == stopBefore
+}
+
+// Create and start sidecars of replica which main container waits for or the rest of them,
+// started ones are recorded even on failure
+func (s *Service) createSidecars(ctx context.Context, e *env.Env, container *Container, waited bool) error {
+
+	for _, sidecar := range s.Config.Sidecars {
+
+		if sidecar.WaitForReady != waited {
+			continue
+		}
+
+		c := &interfaces.Container{
+			Config: interfaces.Config{
+				Image:   sidecar.Image,
+				Env:     sidecar.Env,
+				Cmd:     sidecar.CMD,
+				Volumes: sidecar.Volumes,
+				Ports:   sidecar.Ports,
+			},
+			HostConfig: interfaces.HostConfig{
+				Binds: sidecar.Volumes,
+				Ports: sidecar.Ports,
+				RestartPolicy: interfaces.RestartPolicyConfig{
+					Attempt: 10,
+					Name:    "always",
+				},
+			},
+			Placement: s.Config.Placement,
+			Context:   ctx,
+		}
+
+		if err := e.Containers.StartContainer(c); err != nil {
+			e.Log.Error(err)
+			return err
+		}
+
+		if container.Sidecars == nil {
+			container.Sidecars = make(map[string]string)
+		}
+
+		container.Sidecars[sidecar.Name] = c.CID
+
+		if waited {
+			if err := s.waitSidecar(e, sidecar, c.CID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Start existing sidecars of replica which main container waits for or the rest of them
+func (s *Service) startSidecars(e *env.Env, container *Container, waited bool) error {
+
+	for _, sidecar := range s.Config.Sidecars {
+
+		id, ok := container.Sidecars[sidecar.Name]
+		if !ok || sidecar.WaitForReady != waited {
+			continue
+		}
+
+		if err := e.Containers.StartContainer(&interfaces.Container{
+			CID: id,
+		}); err != nil {
+			e.Log.Error(err)
+			return err
+		}
+
+		if waited {
+			if err := s.waitSidecar(e, sidecar, id); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Poll sidecar until it is running and passes its probe or probe timeout elapses
+func (s *Service) waitSidecar(e *env.Env, sidecar Sidecar, id string) error {
+	e.Log.Info(`Wait for sidecar `, sidecar.Name, ` of service `, s.Name)
+
+	probe := sidecar.ReadinessProbe
+	if probe == nil {
+		probe = &Probe{}
+	}
+
+	deadline := time.Now().Add(probe.timeout())
+
+	for {
+		c := &interfaces.Container{CID: id}
+		if err := e.Containers.InspectContainer(c); err != nil {
+			return err
+		}
+
+		if c.State.Running {
+			if sidecar.ReadinessProbe == nil {
+				return nil
+			}
+
+			port, err := probe.hostPort(e, id)
+			if err == nil && probe.Check(port) == nil {
+				return nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("sidecar %s of service %s is not ready", sidecar.Name, s.Name)
+		}
+
+		time.Sleep(time.Duration(probe.interval()) * time.Second)
+	}
+}
+
+// Remove replica with its sidecars, missing sidecars are ignored
+func (s *Service) removeContainer(e *env.Env, container *Container) error {
+	return s.removeReplica(e, container, false)
+}
+
+// Remove container with its sidecars, volumes are removed unless keepVolumes is set
+func (s *Service) removeReplica(e *env.Env, container *Container, keepVolumes bool) error {
+
+	if !e.HostLocked {
+		return ErrHostNotLocked
+	}
+
+	remove := e.Containers.RemoveContainer
+	if keepVolumes {
+		remove = e.Containers.RemoveContainerKeepVolumes
+	}
+
+	s.drain(e, container.ID)
+	s.recordRemoval(e, container)
+
+	if err := s.removeSidecarsWith(e, container, remove); err != nil {
+		return err
+	}
+
+	return remove(&interfaces.Container{
+		CID: container.ID,
+	})
+}
+
+const removePollInterval = 500 * time.Millisecond
+
+// Poll driver until removed container does not exist, so record is not cleared
+// while container name is still taken
+func (s *Service) waitRemoved(e *env.Env, id string) error {
+
+	deadline := time.Now().Add(s.Config.Timeouts.remove())
+
+	for {
+		err := e.Containers.InspectContainer(&interfaces.Container{CID: id})
+		if isNoSuchContainer(err) {
+			return nil
+		}
+
+		if err != nil {
+			e.Log.Error(err)
+		}
+
+		if time.Now().After(deadline) {
+			return ErrRemoveTimeout
+		}
+
+		time.Sleep(removePollInterval)
+	}
+}
+
+func (s *Service) removeSidecars(e *env.Env, container *Container) error {
+	return s.removeSidecarsWith(e, container, e.Containers.RemoveContainer)
+}
+
+func (s *Service) removeSidecarsWith(e *env.Env, container *Container, remove func(*interfaces.Container) error) error {
+
+	for name, id := range container.Sidecars {
+		if err := remove(&interfaces.Container{
+			CID: id,
+		}); err != nil && !isNoSuchContainer(err) {
+			e.Log.Error(err)
+			return err
+		}
+
+		delete(container.Sidecars, name)
+	}
+
+	return nil
+}
+
+// Stop replica and its sidecars in configured stop order
+func (s *Service) stopReplica(e *env.Env, container *Container) error {
+
+	stop := func(before bool) error {
+		for _, sidecar := range s.Config.Sidecars {
+
+			id, ok := container.Sidecars[sidecar.Name]
+			if !ok || sidecar.stopBefore() != before {
+				continue
+			}
+
+			if err := s.stopContainer(e, id); err != nil && !isNoSuchContainer(err) {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	s.drain(e, container.ID)
+
+	if err := stop(true); err != nil {
+		return err
+	}
+
+	if err := s.stopContainer(e, container.ID); err != nil {
+		return err
+	}
+
+	return stop(false)
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ByteSize is size in bytes parsed from plain number or human readable value like 512m or 2g
+type ByteSize int64
+
+var sizeUnits = map[string]int64{
+	"b": 1,
+	"k": 1 << 10,
+	"m": 1 << 20,
+	"g": 1 << 30,
+}
+
+// ParseByteSize parses size like 1024, 64k, 512m or 2g, units are case insensitive
+func ParseByteSize(value string) (ByteSize, error) {
+
+	value = strings.ToLower(strings.TrimSpace(value))
+	value = strings.TrimSuffix(value, "b")
+	if value == "" {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+
+	multiplier := int64(1)
+	if unit, ok := sizeUnits[value[len(value)-1:]]; ok {
+		multiplier = unit
+		value = value[:len(value)-1]
+	}
+
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+
+	return ByteSize(n * float64(multiplier)), nil
+}
+
+func (b *ByteSize) UnmarshalYAML(unmarshal func(interface{}) error) error {
+
+	var value string
+	if err := unmarshal(&value); err != nil {
+		return err
+	}
+
+	size, err := ParseByteSize(value)
+	if err != nil {
+		return err
+	}
+
+	*b = size
+
+	return nil
+}
+
+func (b *ByteSize) UnmarshalJSON(data []byte) error {
+
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case float64:
+		*b = ByteSize(v)
+	case string:
+		size, err := ParseByteSize(v)
+		if err != nil {
+			return err
+		}
+		*b = size
+	case nil:
+		*b = 0
+	default:
+		return fmt.Errorf("invalid size %v", value)
+	}
+
+	return nil
+}
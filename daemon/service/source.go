@@ -0,0 +1,97 @@
+package service
+
+import (
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Source is git repository service image is built from
+type Source struct {
+	Git        string `json:"git" yaml:"git"`
+	Ref        string `json:"ref" yaml:"ref"`               // branch, tag or commit, default branch if empty
+	Context    string `json:"context" yaml:"context"`       // build context inside repository
+	Dockerfile string `json:"dockerfile" yaml:"dockerfile"` // relative to context, Dockerfile if empty
+}
+
+func git(dir string, args ...string) (string, error) {
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(out)))
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Clone source at ref, build service image from it and replace containers,
+// ref overrides the one from config
+func (s *Service) deploySource(e *env.Env, ref string) error {
+
+	src := s.Config.Source
+
+	if ref == "" {
+		ref = src.Ref
+	}
+
+	if strings.HasPrefix(ref, "-") {
+		return fmt.Errorf("invalid git ref %q", ref)
+	}
+
+	dir, err := ioutil.TempDir(fmt.Sprintf("%s/tmp", env.Default_root_path), "source-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	e.Log.Info(`Clone `, src.Git, ` for service `, s.Name)
+
+	if _, err := git(dir, "clone", "--quiet", "--", src.Git, "."); err != nil {
+		e.Log.Error(err)
+		return err
+	}
+
+	if ref != "" {
+		if _, err := git(dir, "checkout", "--quiet", ref); err != nil {
+			e.Log.Error(err)
+			return err
+		}
+	}
+
+	commit, err := git(dir, "rev-parse", "HEAD")
+	if err != nil {
+		return err
+	}
+
+	e.Log.Info(`Build service `, s.Name, ` from commit `, commit)
+
+	if err := e.Containers.BuildImage(interfaces.BuildImageOptions{
+		Name:           s.Config.Image,
+		RmTmpContainer: true,
+		ContextDir:     filepath.Join(dir, src.Context),
+		Dockerfile:     src.Dockerfile,
+		OutputStream:   ioutil.Discard,
+	}); err != nil {
+		e.Log.Error(err)
+		return err
+	}
+
+	prevTag, prevDigest := s.Tag, s.Digest
+	s.Tag, s.Digest = commit, ""
+
+	if err := s.replaceContainers(e); err != nil {
+		s.Tag, s.Digest = prevTag, prevDigest
+		s.Update(e)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+)
+
+const stackPrefix = `stacks`
+
+// Stack groups services which are managed as a unit
+type Stack struct {
+	Name     string   `json:"name" yaml:"name"`
+	Services []string `json:"services" yaml:"services"`
+}
+
+type StackMember struct {
+	Name       string `json:"name"`
+	Containers int    `json:"containers"`
+	Healthy    bool   `json:"healthy"`
+	Error      string `json:"error,omitempty"`
+}
+
+func stackKey(name string) string {
+	return fmt.Sprintf("%s/%s", stackPrefix, name)
+}
+
+func (st *Stack) Get(e *env.Env, name string) error {
+	e.Log.Info(`Get stack `, name)
+
+	if err := e.LDB.Read(stackKey(name), st); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (st *Stack) Save(e *env.Env) error {
+	e.Log.Info(`Save stack `, st.Name)
+
+	if st.Name == "" {
+		return ErrStackNameRequired
+	}
+
+	if err := e.LDB.Write(stackKey(st.Name), st); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (st *Stack) Remove(e *env.Env) error {
+	e.Log.Info(`Remove stack `, st.Name)
+
+	if err := e.LDB.Remove(stackKey(st.Name)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Up creates missing members and starts all of them, dependencies first
+func (st *Stack) Up(e *env.Env) error {
+	e.Log.Info(`Up stack `, st.Name)
+
+	services, err := st.members(e, true)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range dependencyOrder(services) {
+		if err := s.Start(e); err != nil {
+			e.Log.Error(err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Down stops all members in reverse dependency order
+func (st *Stack) Down(e *env.Env) error {
+	e.Log.Info(`Down stack `, st.Name)
+
+	services, err := st.members(e, false)
+	if err != nil {
+		return err
+	}
+
+	ordered := dependencyOrder(services)
+
+	for i := len(ordered) - 1; i >= 0; i-- {
+		if err := ordered[i].Stop(e); err != nil {
+			e.Log.Error(err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (st *Stack) Status(e *env.Env) ([]StackMember, error) {
+	e.Log.Info(`Status of stack `, st.Name)
+
+	status := []StackMember{}
+
+	for _, name := range st.Services {
+
+		member := StackMember{Name: name}
+
+		s := new(Service)
+		if err := s.Get(e, name); err != nil || s.UUID == "" {
+			member.Error = ErrServiceNotFound.Error()
+			status = append(status, member)
+			continue
+		}
+
+		member.Containers = len(s.Containers)
+
+		healthy, err := s.Healthy(e)
+		if err != nil {
+			member.Error = err.Error()
+		}
+
+		member.Healthy = healthy
+
+		status = append(status, member)
+	}
+
+	return status, nil
+}
+
+// Load member services, missing ones are created and pulled when create is set
+func (st *Stack) members(e *env.Env, create bool) ([]*Service, error) {
+
+	services := []*Service{}
+
+	for _, name := range st.Services {
+
+		s := new(Service)
+		s.Get(e, name)
+
+		if s.UUID == "" {
+			if !create {
+				return services, fmt.Errorf("service %s not found", name)
+			}
+
+			if err := s.Create(e, name); err != nil {
+				return services, err
+			}
+
+			if err := s.Pull(e); err != nil {
+				return services, err
+			}
+		}
+
+		services = append(services, s)
+	}
+
+	return services, nil
+}
+
+// Deploy deploys all members at once, see DeployStack
+func (st *Stack) Deploy(e *env.Env) error {
+	e.Log.Info(`Deploy stack `, st.Name)
+
+	services, err := st.members(e, false)
+	if err != nil {
+		return err
+	}
+
+	return DeployStack(e, services)
+}
+
+// Image service ran before stack deploy
+type stackSnapshot struct {
+	service *Service
+	tag     string
+	digest  string
+}
+
+// DeployStack deploys services in dependency order, all or nothing: when any of them fails,
+// services deployed before it are switched back to images they ran. Failed service keeps its
+// previous containers itself. Images built from source are rebuilt, so they are not restored
+func DeployStack(e *env.Env, services []*Service) error {
+	e.Log.Info(`Deploy stack of `, len(services), ` services`)
+
+	for _, s := range services {
+		if s.UUID == "" {
+			return ErrServiceNotFound
+		}
+	}
+
+	deployed := []stackSnapshot{}
+
+	for _, s := range dependencyOrder(services) {
+
+		snapshot := stackSnapshot{service: s, tag: s.Tag, digest: s.Digest}
+
+		if err := s.Deploy(e, "", Annotation{Reason: `stack deploy`}); err != nil {
+			e.Log.Error(err)
+			rollbackStack(e, deployed)
+			return err
+		}
+
+		deployed = append(deployed, snapshot)
+	}
+
+	return nil
+}
+
+// Restore deployed services in reverse order, failures are logged and the rest are restored
+func rollbackStack(e *env.Env, deployed []stackSnapshot) {
+
+	for i := len(deployed) - 1; i >= 0; i-- {
+		snapshot := deployed[i]
+
+		if err := snapshot.service.restoreImage(e, snapshot.tag, snapshot.digest); err != nil {
+			e.Log.Error(err)
+		}
+	}
+}
+
+// Replace containers with ones from image of tag and digest service ran before
+func (s *Service) restoreImage(e *env.Env, tag, digest string) error {
+	e = s.logEnv(e)
+
+	if s.Tag == tag && s.Digest == digest {
+		return nil
+	}
+
+	e.Log.Info(`Restore service `, s.Name, ` to `, tag)
+
+	s.beginTransition(e, TransitionRollingBack)
+	defer s.endTransition(e)
+
+	prevTag, prevDigest := s.Tag, s.Digest
+	s.Tag, s.Digest = tag, digest
+
+	if err := s.replaceContainers(e); err != nil {
+		s.Tag, s.Digest = prevTag, prevDigest
+		s.Update(e)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"sync"
+)
+
+type StatsSample struct {
+	Container   string  `json:"container"`
+	CPUPercent  float64 `json:"cpu_percent"`
+	MemoryUsage uint64  `json:"memory_usage"`
+	MemoryLimit uint64  `json:"memory_limit"`
+	Time        int64   `json:"time"` // unix nano
+}
+
+// StreamStats pushes usage samples of every service container until stop is called,
+// channel is closed after all streams are finished
+func (s *Service) StreamStats(e *env.Env) (<-chan StatsSample, func(), error) {
+	e.Log.Info(`Stream stats of service `, s.Name)
+
+	if s.UUID == "" {
+		return nil, nil, ErrServiceNotFound
+	}
+
+	out := make(chan StatsSample)
+	done := make(chan bool)
+
+	var wg sync.WaitGroup
+
+	for _, container := range s.Containers {
+
+		wg.Add(1)
+
+		go func(id string) {
+			defer wg.Done()
+
+			samples := make(chan interfaces.StatsSample)
+
+			go func() {
+				defer close(samples)
+				if err := e.Containers.Stats(&interfaces.Container{CID: id}, samples, done); err != nil {
+					e.Log.Error(err)
+				}
+			}()
+
+			for sample := range samples {
+				select {
+				case out <- StatsSample{
+					Container:   sample.CID,
+					CPUPercent:  sample.CPUPercent,
+					MemoryUsage: sample.MemoryUsage,
+					MemoryLimit: sample.MemoryLimit,
+					Time:        sample.Time.UnixNano(),
+				}:
+				case <-done:
+				}
+			}
+		}(container.ID)
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	var once sync.Once
+
+	stop := func() {
+		once.Do(func() {
+			close(done)
+		})
+	}
+
+	return out, stop, nil
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"github.com/deployithq/deployit/daemon/env"
+)
+
+// Transient states of service while its containers are replaced
+const (
+	TransitionDeploying   = `deploying`
+	TransitionRollingBack = `rolling_back`
+)
+
+// Service states reported by Status
+const (
+	StatusRunning     = `running`
+	StatusDegraded    = `degraded`
+	StatusStopped     = `stopped`
+	StatusSuspended   = `suspended`
+	StatusMaintenance = `maintenance`
+	StatusFailed      = `failed`
+)
+
+// Store transient state so it is seen by other readers of service
+func (s *Service) beginTransition(e *env.Env, transition string) {
+	s.Transition = transition
+	if err := s.Update(e); err != nil {
+		e.Log.Error(err)
+	}
+}
+
+func (s *Service) endTransition(e *env.Env) {
+	s.Transition = ""
+	if err := s.Update(e); err != nil {
+		e.Log.Error(err)
+	}
+}
+
+// Transition in progress, or state derived from report
+func (s *Service) status(report *Report) string {
+	switch {
+	case s.Transition != "":
+		return s.Transition
+	case s.Suspended:
+		return StatusSuspended
+	case s.Maintenance:
+		return StatusMaintenance
+	case s.Desired == DesiredStopped:
+		return StatusStopped
+	case s.Failure != nil:
+		return StatusFailed
+	case report.Live && report.RunningReplicas < report.DesiredReplicas:
+		return StatusDegraded
+	}
+
+	return StatusRunning
+}
+
+// Status returns deploying or rolling back while containers are replaced,
+// otherwise state of service containers
+func (s *Service) Status(e *env.Env) (string, error) {
+	e.Log.Debug(`Status of service `, s.Name)
+
+	report, err := s.Inspect(e)
+	if err != nil {
+		return "", err
+	}
+
+	return report.Status, nil
+}
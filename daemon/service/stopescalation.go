@@ -0,0 +1,71 @@
+package service
+
+import (
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"strings"
+	"time"
+)
+
+const stopPollInterval = 500 * time.Millisecond
+
+// StopStep is signal sent to container and seconds it is given to exit
+type StopStep struct {
+	Signal string `json:"signal" yaml:"signal"`
+	Wait   int    `json:"wait" yaml:"wait"`
+}
+
+// Send escalation signals until container exits, it is killed after the last step
+func (s *Service) escalateStop(e *env.Env, id string) error {
+
+	for _, step := range s.Config.StopEscalation {
+
+		e.Log.Info(`Send `, step.Signal, ` to container `, id, ` of service `, s.Name)
+
+		if err := e.Containers.KillContainer(&interfaces.Container{CID: id}, step.Signal); err != nil {
+			if isNoSuchContainer(err) || isNotRunning(err) {
+				return nil
+			}
+
+			e.Log.Error(err)
+		}
+
+		if exited(e, id, seconds(step.Wait)) {
+			return nil
+		}
+	}
+
+	e.Log.Info(`Kill container `, id, ` of service `, s.Name)
+
+	err := e.Containers.KillContainer(&interfaces.Container{CID: id}, signalKill)
+	if isNotRunning(err) {
+		return nil
+	}
+
+	return err
+}
+
+// Wait until container is not running, false if it still runs after wait
+func exited(e *env.Env, id string, wait time.Duration) bool {
+
+	deadline := time.Now().Add(wait)
+
+	for {
+		c := &interfaces.Container{CID: id}
+		err := e.Containers.InspectContainer(c)
+		if isNoSuchContainer(err) || (err == nil && !c.State.Running) {
+			return true
+		}
+
+		if time.Now().After(deadline) {
+			return false
+		}
+
+		time.Sleep(stopPollInterval)
+	}
+}
+
+// Driver error for signal sent to container which already exited
+func isNotRunning(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "is not running")
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"sync"
+	"time"
+)
+
+const defaultUnhealthyTimeout = 60
+
+// Time running containers started failing readiness probe
+var unhealthy = struct {
+	sync.Mutex
+	since map[string]time.Time
+}{since: make(map[string]time.Time)}
+
+func (c *Config) unhealthyTimeout() time.Duration {
+	if c.UnhealthyTimeout > 0 {
+		return seconds(c.UnhealthyTimeout)
+	}
+
+	return seconds(defaultUnhealthyTimeout)
+}
+
+// Restart running containers which fail readiness probe longer than unhealthy timeout,
+// exited containers are left to restart policy
+func (s *Service) restartUnhealthy(e *env.Env) error {
+
+	if !s.Config.RestartOnUnhealthy || s.Config.ReadinessProbe == nil || s.Suspended || s.Desired == DesiredStopped {
+		return nil
+	}
+
+	now := time.Now()
+
+	for _, container := range s.Containers {
+
+		c := &interfaces.Container{CID: container.ID}
+		if err := e.Containers.InspectContainer(c); err != nil || !c.State.Running {
+			forgetUnhealthy(container.ID)
+			continue
+		}
+
+		ready, err := s.containerReady(e, container.ID)
+		if err != nil || ready {
+			forgetUnhealthy(container.ID)
+			continue
+		}
+
+		unhealthy.Lock()
+		since, ok := unhealthy.since[container.ID]
+		if !ok {
+			since = now
+			unhealthy.since[container.ID] = now
+		}
+		unhealthy.Unlock()
+
+		if now.Sub(since) < s.Config.unhealthyTimeout() {
+			continue
+		}
+
+		e.Log.Info(`Container `, container.ID, ` of service `, s.Name, ` is unhealthy since `, since.Format(time.RFC3339), `, restart it`)
+
+		forgetUnhealthy(container.ID)
+		countMetric(metricRestarts, s.Name)
+
+		if err := e.Containers.RestartContainer(&interfaces.Container{
+			CID:        container.ID,
+			HostConfig: s.hostConfig(),
+		}); err != nil {
+			e.Log.Error(err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func forgetUnhealthy(id string) {
+	unhealthy.Lock()
+	delete(unhealthy.since, id)
+	unhealthy.Unlock()
+}
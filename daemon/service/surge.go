@@ -0,0 +1,27 @@
+package service
+
+import (
+	"github.com/deployithq/deployit/daemon/env"
+)
+
+// Replacement of the only replica can be started next to it
+// unless old container holds fixed host ports
+func (s *Service) canSurge() bool {
+	return s.Config.SurgeRestart && s.replicas() == 1 && len(s.Containers) == 1 && !s.Config.fixedPorts()
+}
+
+// Start replacement of the only replica, wait until it is ready and remove old one,
+// replacement gets new host ports as old ones are held until it is ready
+func (s *Service) surgeRestart(e *env.Env) error {
+	e.Log.Info(`Surge restart service `, s.Name)
+
+	hostPorts := s.HostPorts
+	s.HostPorts = nil
+
+	if err := s.replaceContainers(e); err != nil {
+		s.HostPorts = hostPorts
+		return err
+	}
+
+	return nil
+}
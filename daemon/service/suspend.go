@@ -0,0 +1,68 @@
+package service
+
+import (
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+)
+
+// Suspend stops service containers keeping them, so Resume brings back
+// the same containers with their state and anonymous volumes
+func (s *Service) Suspend(e *env.Env) error {
+	e.Log.Info(`Suspend service `, s.Name)
+
+	if s.UUID == "" {
+		return ErrServiceNotFound
+	}
+
+	s.Suspended = true
+
+	if err := s.Stop(e); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Resume starts exactly the containers stopped by Suspend, no new containers are created
+func (s *Service) Resume(e *env.Env) error {
+	e.Log.Info(`Resume service `, s.Name)
+
+	if s.UUID == "" {
+		return ErrServiceNotFound
+	}
+
+	if !s.Suspended {
+		return ErrNotSuspended
+	}
+
+	for _, container := range s.Containers {
+
+		ids := []string{container.ID}
+		for _, id := range container.Sidecars {
+			ids = append(ids, id)
+		}
+
+		for _, id := range ids {
+			if err := e.Containers.StartContainer(&interfaces.Container{
+				CID: id,
+			}); err != nil {
+				e.Log.Error(err)
+				if isNoSuchContainer(err) {
+					return fmt.Errorf("container %s of suspended service does not exist anymore", id)
+				}
+
+				return err
+			}
+		}
+	}
+
+	s.Suspended = false
+	s.Desired = DesiredRunning
+
+	if err := s.Update(e); err != nil {
+		return err
+	}
+
+	return nil
+}
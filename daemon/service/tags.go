@@ -0,0 +1,63 @@
+package service
+
+import (
+	"errors"
+	"github.com/deployithq/deployit/daemon/env"
+	"regexp"
+)
+
+// Docker tag format
+var validTag = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_.-]{0,127}$`)
+
+// SetTags replaces aliases of the current deploy, like stable for v1.2.3,
+// primary tag is left as the pulled reference
+func (s *Service) SetTags(e *env.Env, tags []string) error {
+	e.Log.Info(`Set tags of service `, s.Name)
+
+	if s.UUID == "" {
+		return ErrServiceNotFound
+	}
+
+	aliases := []string{}
+	seen := map[string]bool{s.Tag: true}
+
+	for _, tag := range tags {
+		if !validTag.MatchString(tag) {
+			return errors.New("invalid tag " + tag)
+		}
+
+		if !seen[tag] {
+			seen[tag] = true
+			aliases = append(aliases, tag)
+		}
+	}
+
+	s.Tags = aliases
+
+	if err := s.Update(e); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Service serves tag as primary tag or alias
+func (s *Summary) hasTag(tag string) bool {
+
+	if s.Tag == tag {
+		return true
+	}
+
+	for _, alias := range s.Tags {
+		if alias == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ListByTag returns summaries of services serving tag as primary tag or alias
+func ListByTag(e *env.Env, tag string) ([]Summary, error) {
+	return listIndex(e, func(s Summary) bool { return s.hasTag(tag) })
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"fmt"
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"io"
+	"os"
+)
+
+// Load service image from tarball configured in service
+func (s *Service) loadTarball(e *env.Env) error {
+
+	f, err := os.Open(s.Config.ImageTarball)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return s.loadImage(e, f)
+}
+
+// Load stores image from tarball in docker save format to be used by next start,
+// tarball should contain service image
+func (s *Service) Load(e *env.Env, r io.Reader) error {
+	e = s.logEnv(e)
+	e.Log.Info(`Load image of service `, s.Name)
+
+	if s.UUID == "" {
+		return ErrServiceNotFound
+	}
+
+	if err := checkDriver(e); err != nil {
+		return err
+	}
+
+	if err := s.loadImage(e, r); err != nil {
+		e.Log.Error(err)
+		return err
+	}
+
+	// Loaded image is not known by registry
+	s.Digest = ""
+
+	if err := s.Update(e); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Service) loadImage(e *env.Env, r io.Reader) error {
+
+	if err := e.Containers.LoadImage(r); err != nil {
+		return err
+	}
+
+	image := interfaces.Image{Name: s.Config.Image}
+	if err := e.Containers.InspectImage(&image); err != nil {
+		return fmt.Errorf("image %s is not found in tarball: %s", s.Config.Image, err)
+	}
+
+	s.ImageSize, s.ImageLayers = image.Size, image.Layers
+	e.Log.Info(`Loaded `, image.Name, `: `, image.Size, ` bytes in `, image.Layers, ` layers`)
+
+	return nil
+}
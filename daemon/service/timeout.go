@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Timeouts limit deploy phases in seconds, phase is not limited if empty
+type Timeouts struct {
+	Pull   int `json:"pull" yaml:"pull"`
+	Start  int `json:"start" yaml:"start"`
+	Health int `json:"health" yaml:"health"`
+
+	// Ports inspection, 10 seconds if empty
+	Inspect int `json:"inspect" yaml:"inspect"`
+
+	// Host hooks, 60 seconds if empty
+	Hook int `json:"hook" yaml:"hook"`
+
+	// Wait for removed containers to disappear from driver, 30 seconds if empty
+	Remove int `json:"remove" yaml:"remove"`
+}
+
+const (
+	defaultInspectTimeout = 10 * time.Second
+	defaultRemoveTimeout  = 30 * time.Second
+)
+
+func (t Timeouts) inspect() time.Duration {
+	if t.Inspect > 0 {
+		return seconds(t.Inspect)
+	}
+
+	return defaultInspectTimeout
+}
+
+func (t Timeouts) remove() time.Duration {
+	if t.Remove > 0 {
+		return seconds(t.Remove)
+	}
+
+	return defaultRemoveTimeout
+}
+
+func seconds(n int) time.Duration {
+	return time.Duration(n) * time.Second
+}
+
+// Run phase and fail with timeout error if it is not finished within limit,
+// context passed to phase is canceled on timeout so it can release pull slots and stop reporting
+func runPhase(phase string, limit time.Duration, fn func(ctx context.Context) error) error {
+
+	if limit <= 0 {
+		return fn(context.Background())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), limit)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- fn(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("%s timed out after %s", phase, limit)
+	}
+}
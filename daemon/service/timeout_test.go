@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"testing"
+	"time"
+)
+
+// Driver which pulls until it is released
+type hungContainers struct {
+	interfaces.IContainers
+	release chan struct{}
+}
+
+func (c hungContainers) PullImage(interfaces.Image) error {
+	<-c.release
+	return nil
+}
+
+func TestRunPhaseTimeoutReleasesPullSlot(t *testing.T) {
+
+	e := testEnv(t)
+
+	driver := hungContainers{release: make(chan struct{})}
+	defer close(driver.release)
+
+	e.Containers = driver
+	e.Pulls = make(chan struct{}, 1)
+
+	err := runPhase(`pull`, 10*time.Millisecond, func(ctx context.Context) error {
+		return pullImage(ctx, e, interfaces.Image{Name: "nginx"})
+	})
+	if err == nil {
+		t.Fatal("runPhase() of hung pull returned nil")
+	}
+
+	select {
+	case e.Pulls <- struct{}{}:
+	case <-time.After(time.Second):
+		t.Fatal("pull slot is held after timeout")
+	}
+}
+
+func TestSendProgressAfterTimeout(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Nobody reads progress once deploy returned
+	progress := make(chan ProgressEvent)
+
+	done := make(chan struct{})
+	go func() {
+		sendProgress(ctx, progress, ProgressEvent{Stage: StageLayer})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("sendProgress blocks after phase timed out")
+	}
+}
+
+// Driver which starts containers only after delay, start is canceled with container context
+type slowStartContainers struct {
+	interfaces.IContainers
+	delay    time.Duration
+	honorCtx bool
+	removed  *[]string
+}
+
+func (c slowStartContainers) StartContainer(container *interfaces.Container) error {
+
+	if container.Context == nil {
+		return errors.New("container is started without context")
+	}
+
+	done := container.Context.Done()
+	if !c.honorCtx {
+		done = nil
+	}
+
+	select {
+	case <-time.After(c.delay):
+		container.CID = "late"
+		return nil
+	case <-done:
+		return container.Context.Err()
+	}
+}
+
+func (c slowStartContainers) RemoveContainer(container *interfaces.Container) error {
+	*c.removed = append(*c.removed, container.CID)
+	return nil
+}
+
+func (c slowStartContainers) InspectContainer(*interfaces.Container) error {
+	return errors.New("No such container")
+}
+
+func TestStartReplicaTimeout(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		honorCtx bool
+		removed  int
+	}{
+		{"start canceled", true, 0},
+		{"started after timeout", false, 1},
+	}
+
+	for _, tt := range tests {
+
+		e := testEnv(t)
+
+		removed := []string{}
+		e.Containers = slowStartContainers{
+			delay:    1500 * time.Millisecond,
+			honorCtx: tt.honorCtx,
+			removed:  &removed,
+		}
+
+		s := &Service{
+			UUID:       "uuid-web",
+			Name:       "web",
+			Containers: map[string]*Container{},
+			Config:     Config{Image: "nginx", Timeouts: Timeouts{Start: 1}},
+		}
+
+		if err := s.startReplica(e); err == nil {
+			t.Errorf("%s: startReplica() returned nil after timeout", tt.name)
+		}
+
+		if len(s.Containers) != 0 {
+			t.Errorf("%s: containers = %v, want none recorded", tt.name, s.Containers)
+		}
+
+		if len(removed) != tt.removed {
+			t.Errorf("%s: removed %v, want %d containers", tt.name, removed, tt.removed)
+		}
+	}
+}
@@ -0,0 +1,548 @@
+package service
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (f FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", f.Field, f.Message)
+}
+
+type ValidationError []FieldError
+
+func (v ValidationError) Error() string {
+	messages := []string{}
+	for _, err := range v {
+		messages = append(messages, err.Error())
+	}
+
+	return fmt.Sprintf("invalid config: %s", strings.Join(messages, "; "))
+}
+
+// ReadConfig reads service config from yaml file, unknown fields are reported as errors
+func ReadConfig(path string) (*Config, error) {
+
+	config := new(Config)
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return config, err
+	}
+
+	return config, nil
+}
+
+// Validate checks config fields and returns all found problems
+func (c *Config) Validate() ValidationError {
+
+	errs := ValidationError{}
+
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, FieldError{field, fmt.Sprintf(format, args...)})
+	}
+
+	if strings.TrimSpace(c.Image) == `` {
+		add(`image`, `is required`)
+	}
+
+	if c.Memory < 0 {
+		add(`memory`, `should not be negative`)
+	}
+
+	if c.CPUs < 0 {
+		add(`cpus`, `should not be negative`)
+	}
+
+	for name, resources := range c.Profiles {
+		if resources.Scale < 0 || resources.Memory < 0 || resources.CPUs < 0 || resources.Replicas < 0 {
+			add(`profiles.`+name, `should not be negative`)
+		}
+	}
+
+	if c.ShmSize < 0 {
+		add(`shm_size`, `should be positive`)
+	}
+
+	for i, port := range c.Ports {
+		for _, p := range strings.Split(port, ":") {
+			if !validPort(p) {
+				add(fmt.Sprintf("ports[%d]", i), `invalid port %q`, port)
+				break
+			}
+		}
+	}
+
+	for i, volume := range c.Volumes {
+		parts := strings.Split(volume, ":")
+		if len(parts) < 2 || len(parts) > 3 || parts[0] == `` || parts[1] == `` {
+			add(fmt.Sprintf("volumes[%d]", i), `should be in host:container[:mode] format, got %q`, volume)
+		}
+	}
+
+	for i, secret := range c.Secrets {
+		parts := strings.Split(secret, ":")
+		if len(parts) < 2 || len(parts) > 3 || parts[0] == `` || parts[1] == `` {
+			add(fmt.Sprintf("secrets[%d]", i), `should be in host:container[:mode] format, got %q`, secret)
+		}
+	}
+
+	for i, mount := range c.ConfigFiles {
+		parts := strings.SplitN(mount, ":", 2)
+		if len(parts) != 2 || !validName.MatchString(parts[0]) || !path.IsAbs(parts[1]) {
+			add(fmt.Sprintf("config_files[%d]", i), `should be in name:/container/path format, got %q`, mount)
+		}
+	}
+
+	for i, variable := range c.Env {
+		if strings.Index(variable, "=") < 1 {
+			add(fmt.Sprintf("env[%d]", i), `should be in KEY=VALUE format, got %q`, variable)
+		}
+
+		if strings.Contains(variable, "{{") {
+			if _, err := template.New("env").Parse(variable); err != nil {
+				add(fmt.Sprintf("env[%d]", i), `invalid template: %s`, err)
+			}
+		}
+	}
+
+	for i, key := range c.RequiredEnv {
+		if strings.TrimSpace(key) == `` || strings.Contains(key, "=") {
+			add(fmt.Sprintf("required_env[%d]", i), `invalid variable name %q`, key)
+		}
+	}
+
+	for i, capability := range c.CapAdd {
+		if !validCapability(capability) {
+			add(fmt.Sprintf("cap_add[%d]", i), `unknown capability %q`, capability)
+		}
+	}
+
+	for i, capability := range c.CapDrop {
+		if !validCapability(capability) {
+			add(fmt.Sprintf("cap_drop[%d]", i), `unknown capability %q`, capability)
+		}
+	}
+
+	for key, value := range c.Labels {
+		if strings.TrimSpace(key) == `` {
+			add(`labels`, `empty key for value %q`, value)
+		}
+	}
+
+	for key, value := range c.Sysctls {
+		if strings.TrimSpace(key) == `` {
+			add(`sysctls`, `empty key for value %q`, value)
+		}
+	}
+
+	if c.BlkioWeight != 0 && (c.BlkioWeight < 10 || c.BlkioWeight > 1000) {
+		add(`blkio_weight`, `should be in 10-1000 range, got %d`, c.BlkioWeight)
+	}
+
+	for i, limit := range c.BlkioDeviceReadBps {
+		if !filepath.IsAbs(limit.Path) || limit.Rate <= 0 {
+			add(fmt.Sprintf("blkio_device_read_bps[%d]", i), `should have absolute device path and positive rate`)
+		}
+	}
+
+	for i, limit := range c.BlkioDeviceWriteBps {
+		if !filepath.IsAbs(limit.Path) || limit.Rate <= 0 {
+			add(fmt.Sprintf("blkio_device_write_bps[%d]", i), `should have absolute device path and positive rate`)
+		}
+	}
+
+	if strings.Contains(c.Hostname, "{{") {
+		if _, err := template.New("hostname").Parse(c.Hostname); err != nil {
+			add(`hostname`, `invalid template: %s`, err)
+		}
+	} else if c.Hostname != `` && !validHostname.MatchString(c.Hostname) {
+		add(`hostname`, `invalid hostname %q`, c.Hostname)
+	}
+
+	if c.Platform != `` {
+		add(`platform`, `is renamed to required_platform, platform of pulled image is only checked`)
+	}
+
+	if c.RequiredPlatform != `` {
+		parts := strings.Split(c.RequiredPlatform, "/")
+		if len(parts) < 2 || len(parts) > 3 || parts[0] == `` || parts[1] == `` {
+			add(`required_platform`, `should be in os/arch[/variant] format, got %q`, c.RequiredPlatform)
+		}
+	}
+
+	if a := c.RegistryAuth; a != nil {
+		if a.Username == `` {
+			add(`registry_auth`, `username is required`)
+		}
+
+		if a.Password != `` && a.PasswordFile != `` {
+			add(`registry_auth`, `password and password_file can not be both set`)
+		}
+	}
+
+	// Referenced services should be started first to have ports allocated
+	for _, name := range c.referencedServices() {
+		found := false
+		for _, dependency := range c.DependsOn {
+			found = found || dependency == name
+		}
+
+		if !found {
+			add(`env`, `references service %q which is not listed in depends_on`, name)
+		}
+	}
+
+	if c.CgroupParent != `` && !validCgroupParent(c.CgroupParent) {
+		add(`cgroup_parent`, `should be absolute cgroup path or systemd slice, got %q`, c.CgroupParent)
+	}
+
+	for i, opt := range c.SecurityOpt {
+		if !validSecurityOpt(opt) {
+			add(fmt.Sprintf("security_opt[%d]", i), `should be seccomp=, apparmor=, label= or no-new-privileges, got %q`, opt)
+		}
+	}
+
+	if !validNamespaceMode(c.PidMode) {
+		add(`pid_mode`, `should be host or container:<id>, got %q`, c.PidMode)
+	}
+
+	switch c.IpcMode {
+	case `private`, `shareable`, `none`:
+	default:
+		if !validNamespaceMode(c.IpcMode) {
+			add(`ipc_mode`, `should be host, private, shareable, none or container:<id>, got %q`, c.IpcMode)
+		}
+	}
+
+	for i, constraint := range c.Placement.Constraints {
+		if !validConstraint(constraint) {
+			add(fmt.Sprintf("placement.constraints[%d]", i), `should be in key==value or key!=value format, got %q`, constraint)
+		}
+	}
+
+	if c.Timeouts.Pull < 0 || c.Timeouts.Start < 0 || c.Timeouts.Health < 0 || c.Timeouts.Inspect < 0 || c.Timeouts.Hook < 0 {
+		add(`timeouts`, `should not be negative`)
+	}
+
+	for i, pattern := range c.SecretEnv {
+		if _, err := path.Match(pattern, ``); err != nil {
+			add(fmt.Sprintf("secret_env[%d]", i), `invalid pattern %q`, pattern)
+		}
+	}
+
+	if c.RestartOnUnhealthy && c.ReadinessProbe == nil {
+		add(`restart_on_unhealthy`, `requires readiness_probe`)
+	}
+
+	if c.UnhealthyTimeout < 0 {
+		add(`unhealthy_timeout`, `should not be negative`)
+	}
+
+	if c.MinReady < 0 {
+		add(`min_ready`, `should not be negative`)
+	}
+
+	if p := c.RestartPolicy; p != nil {
+		switch p.Name {
+		case restartAlways, restartNo, restartUnlessStopped, restartOnFailure:
+		default:
+			add(`restart_policy.name`, `should be %s, %s, %s or %s`, restartAlways, restartNo, restartUnlessStopped, restartOnFailure)
+		}
+
+		if p.MaxRetries < 0 {
+			add(`restart_policy.max_retries`, `should not be negative`)
+		}
+
+		if len(p.ExitCodes) > 0 && p.Name != restartOnFailure {
+			add(`restart_policy.exit_codes`, `are used only with %s`, restartOnFailure)
+		}
+
+		for _, code := range p.ExitCodes {
+			if code < 1 || code > 255 {
+				add(`restart_policy.exit_codes`, `invalid exit code %d`, code)
+			}
+		}
+
+		if c.AutoRemove && p.Name != restartNo {
+			add(`restart_policy`, `can not be used with auto_remove`)
+		}
+	}
+
+	if _, ok := severityLevels[strings.ToLower(c.ScanThreshold)]; c.ScanThreshold != `` && !ok {
+		add(`scan_threshold`, `should be low, medium, high or critical, got %q`, c.ScanThreshold)
+	}
+
+	for i, step := range c.StopEscalation {
+		if !validSignal.MatchString(step.Signal) {
+			add(fmt.Sprintf(`stop_escalation[%d].signal`, i), `should be signal name or number, got %q`, step.Signal)
+		}
+
+		if step.Wait < 0 {
+			add(fmt.Sprintf(`stop_escalation[%d].wait`, i), `should not be negative`)
+		}
+	}
+
+	if c.ReloadSignal != `` && !validSignal.MatchString(c.ReloadSignal) {
+		add(`reload_signal`, `should be signal name or number, got %q`, c.ReloadSignal)
+	}
+
+	if c.MinDeployInterval < 0 {
+		add(`min_deploy_interval`, `should not be negative`)
+	}
+
+	if c.StopGracePeriod < 0 {
+		add(`stop_grace_period`, `should not be negative`)
+	}
+
+	if c.DrainPeriod < 0 {
+		add(`drain_period`, `should not be negative`)
+	}
+
+	if c.MaxExecSessions < 0 {
+		add(`max_exec_sessions`, `should not be negative`)
+	}
+
+	if _, ok := logLevels[strings.ToLower(c.LogLevel)]; c.LogLevel != `` && !ok {
+		add(`log_level`, `should be debug, info or error, got %q`, c.LogLevel)
+	}
+
+	if c.Scale < 0 {
+		add(`scale`, `should not be negative`)
+	}
+
+	if c.RestartJitter < 0 {
+		add(`restart_jitter`, `should not be negative`)
+	}
+
+	if c.RestartSchedule != `` {
+		if _, err := ParseSchedule(c.RestartSchedule); err != nil {
+			add(`restart_schedule`, `%s`, err)
+		}
+	}
+
+	if src := c.Source; src != nil {
+		if strings.TrimSpace(src.Git) == `` {
+			add(`source.git`, `is required`)
+		}
+
+		if filepath.IsAbs(src.Context) || strings.HasPrefix(filepath.Clean(src.Context), "..") {
+			add(`source.context`, `should be relative path inside repository, got %q`, src.Context)
+		}
+	}
+
+	if sink := c.LogSink; sink != nil {
+		switch sink.Type {
+		case sinkFile:
+			if sink.Path == `` {
+				add(`log_sink.path`, `is required for file sink`)
+			}
+		case sinkSyslog:
+			if sink.Address != `` {
+				if u, err := url.Parse(sink.Address); err != nil || u.Scheme == `` || u.Host == `` {
+					add(`log_sink.address`, `should be like udp://host:514, got %q`, sink.Address)
+				}
+			}
+		case sinkHTTP:
+			if u, err := url.Parse(sink.URL); err != nil || (u.Scheme != `http` && u.Scheme != `https`) {
+				add(`log_sink.url`, `should be http or https url, got %q`, sink.URL)
+			}
+		default:
+			add(`log_sink.type`, `should be %s, %s or %s`, sinkFile, sinkSyslog, sinkHTTP)
+		}
+	}
+
+	inits := make(map[string]bool)
+	for i, spec := range c.InitContainers {
+		field := fmt.Sprintf("init_containers[%d]", i)
+
+		if spec.Name == `` {
+			add(field+`.name`, `is required`)
+		} else if inits[spec.Name] {
+			add(field+`.name`, `duplicate init container %q`, spec.Name)
+		}
+		inits[spec.Name] = true
+
+		if strings.TrimSpace(spec.Image) == `` {
+			add(field+`.image`, `is required`)
+		}
+
+		for j, volume := range spec.Volumes {
+			parts := strings.Split(volume, ":")
+			if len(parts) < 2 || len(parts) > 3 || parts[0] == `` || parts[1] == `` {
+				add(fmt.Sprintf("%s.volumes[%d]", field, j), `should be in host:container[:mode] format, got %q`, volume)
+			}
+		}
+	}
+
+	sidecars := make(map[string]bool)
+	for i, sidecar := range c.Sidecars {
+		field := fmt.Sprintf("sidecars[%d]", i)
+
+		if sidecar.Name == `` {
+			add(field+`.name`, `is required`)
+		} else if sidecars[sidecar.Name] {
+			add(field+`.name`, `duplicate sidecar %q`, sidecar.Name)
+		}
+		sidecars[sidecar.Name] = true
+
+		if strings.TrimSpace(sidecar.Image) == `` {
+			add(field+`.image`, `is required`)
+		}
+
+		for j, volume := range sidecar.Volumes {
+			parts := strings.Split(volume, ":")
+			if len(parts) < 2 || len(parts) > 3 || parts[0] == `` || parts[1] == `` {
+				add(fmt.Sprintf("%s.volumes[%d]", field, j), `should be in host:container[:mode] format, got %q`, volume)
+			}
+		}
+
+		for j, port := range sidecar.Ports {
+			for _, p := range strings.Split(port, ":") {
+				if !validPort(p) {
+					add(fmt.Sprintf("%s.ports[%d]", field, j), `invalid port %q`, port)
+					break
+				}
+			}
+		}
+
+		if sidecar.ReadinessProbe != nil && !sidecar.WaitForReady {
+			add(field+`.readiness_probe`, `is used only with wait_for_ready`)
+		}
+
+		if sidecar.StopOrder != `` && sidecar.StopOrder != stopBefore && sidecar.StopOrder != stopAfter {
+			add(field+`.stop_order`, `should be %s or %s`, stopBefore, stopAfter)
+		}
+	}
+
+	if p := c.ReadinessProbe; p != nil {
+		if p.Type != `` && p.Type != probeTCP && p.Type != probeHTTP {
+			add(`readiness_probe.type`, `should be %s or %s`, probeTCP, probeHTTP)
+		}
+
+		if p.Port < 0 || p.Port > 65535 {
+			add(`readiness_probe.port`, `out of range`)
+		}
+
+		if p.Status != 0 && (p.Status < 100 || p.Status > 599) {
+			add(`readiness_probe.status`, `invalid http status %d`, p.Status)
+		}
+
+		if p.Timeout < 0 {
+			add(`readiness_probe.timeout`, `should not be negative`)
+		}
+
+		if p.Interval < 0 {
+			add(`readiness_probe.interval`, `should not be negative`)
+		}
+	}
+
+	return errs
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
+}
+
+var capabilities = []string{
+	"ALL", "AUDIT_CONTROL", "AUDIT_READ", "AUDIT_WRITE", "BLOCK_SUSPEND", "CHOWN",
+	"DAC_OVERRIDE", "DAC_READ_SEARCH", "FOWNER", "FSETID", "IPC_LOCK", "IPC_OWNER",
+	"KILL", "LEASE", "LINUX_IMMUTABLE", "MAC_ADMIN", "MAC_OVERRIDE", "MKNOD",
+	"NET_ADMIN", "NET_BIND_SERVICE", "NET_BROADCAST", "NET_RAW", "SETFCAP", "SETGID",
+	"SETPCAP", "SETUID", "SYSLOG", "SYS_ADMIN", "SYS_BOOT", "SYS_CHROOT", "SYS_MODULE",
+	"SYS_NICE", "SYS_PACCT", "SYS_PTRACE", "SYS_RAWIO", "SYS_RESOURCE", "SYS_TIME",
+	"SYS_TTY_CONFIG", "WAKE_ALARM",
+}
+
+// Capabilities are accepted with or without CAP_ prefix in any case
+func validCapability(capability string) bool {
+	capability = strings.TrimPrefix(strings.ToUpper(capability), "CAP_")
+	for _, c := range capabilities {
+		if c == capability {
+			return true
+		}
+	}
+
+	return false
+}
+
+func validConstraint(constraint string) bool {
+	for _, op := range []string{"==", "!="} {
+		parts := strings.SplitN(constraint, op, 2)
+		if len(parts) == 2 {
+			return strings.TrimSpace(parts[0]) != `` && strings.TrimSpace(parts[1]) != ``
+		}
+	}
+
+	return false
+}
+
+func validNamespaceMode(mode string) bool {
+	if mode == `` || mode == `host` {
+		return true
+	}
+
+	return strings.HasPrefix(mode, `container:`) && len(mode) > len(`container:`)
+}
+
+var validHostname = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+
+func validSecurityOpt(opt string) bool {
+
+	if opt == `no-new-privileges` {
+		return true
+	}
+
+	parts := strings.SplitN(opt, "=", 2)
+	if len(parts) != 2 || parts[1] == `` {
+		return false
+	}
+
+	switch parts[0] {
+	case `seccomp`, `apparmor`, `label`:
+		return true
+	case `no-new-privileges`:
+		return parts[1] == `true` || parts[1] == `false`
+	}
+
+	return false
+}
+
+var validSlice = regexp.MustCompile(`^[a-zA-Z0-9_.-]+\.slice$`)
+
+func validCgroupParent(path string) bool {
+
+	if validSlice.MatchString(path) {
+		return true
+	}
+
+	if !strings.HasPrefix(path, "/") || strings.ContainsAny(path, " \t") {
+		return false
+	}
+
+	for _, part := range strings.Split(path, "/") {
+		if part == `..` || part == `.` {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"github.com/deployithq/deployit/daemon/env"
+	"github.com/fsnotify/fsnotify"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const watchDebounce = 500 * time.Millisecond
+
+// UpdateConfig validates and stores new service config,
+// containers should be recreated to apply it
+func (s *Service) UpdateConfig(e *env.Env, config *Config) error {
+	e.Log.Info(`Update config of service `, s.Name)
+
+	if s.UUID == "" {
+		return ErrServiceNotFound
+	}
+
+	config.applyProfile(e.Profile)
+
+	if errs := config.Validate(); len(errs) > 0 {
+		return errs
+	}
+
+	if err := config.checkMounts(e); err != nil {
+		return err
+	}
+
+	// Containers recreated with new config would start without required variables
+	if err := config.checkRequiredEnv(); err != nil {
+		return err
+	}
+
+	// Changed scale of config overrides replicas set by scaling
+	if config.Scale > 0 && config.Scale != s.Config.Scale {
+		s.Replicas = config.Scale
+	}
+
+	s.Config = *config
+
+	if err := s.Update(e); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Watch re-applies service config and recreates containers every time
+// config file is changed, rapid edits are applied once
+func (s *Service) Watch(e *env.Env) (func(), error) {
+	e.Log.Info(`Watch config of service `, s.Name)
+
+	if s.UUID == "" {
+		return nil, ErrServiceNotFound
+	}
+
+	if s.ConfigPath == "" {
+		return nil, ErrNoConfigFile
+	}
+
+	path, err := filepath.Abs(s.ConfigPath)
+	if err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	// Directory is watched because editors replace files on save
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	var (
+		lock  sync.Mutex
+		timer *time.Timer
+	)
+
+	apply := func() {
+		lock.Lock()
+		defer lock.Unlock()
+
+		config, err := LoadConfig(configSources(path)...)
+		if err != nil {
+			e.Log.Error(err)
+			return
+		}
+
+		if err := s.UpdateConfig(e, config); err != nil {
+			e.Log.Error(err)
+			return
+		}
+
+		if err := s.replaceContainers(e); err != nil {
+			e.Log.Error(err)
+		}
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				if filepath.Clean(event.Name) != path || event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+					continue
+				}
+
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(watchDebounce, apply)
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+
+				e.Log.Error(err)
+
+			case <-done:
+				if timer != nil {
+					timer.Stop()
+				}
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+
+	stop := func() {
+		once.Do(func() {
+			close(done)
+			watcher.Close()
+		})
+	}
+
+	return stop, nil
+}
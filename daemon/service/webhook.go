@@ -0,0 +1,116 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/deployithq/deployit/daemon/env"
+	"net/http"
+	"time"
+)
+
+const webhookTimeout = 5 * time.Second
+
+type Webhooks struct {
+	OnSuccess []string `json:"on_success" yaml:"on_success"`
+	OnFailure []string `json:"on_failure" yaml:"on_failure"`
+}
+
+// DeployEvent is payload of webhooks, one per deploy with everything known about it.
+// Fields are only added, consumers can rely on existing ones
+type DeployEvent struct {
+	Service  string  `json:"service"`
+	DeployID string  `json:"deploy_id,omitempty"`
+	Tag      string  `json:"tag"`
+	Result   string  `json:"result"`
+	Error    string  `json:"error,omitempty"`
+	Duration float64 `json:"duration"` // seconds
+
+	// Image service ran before deploy and runs after it, the same when deploy failed
+	Before DeployImage `json:"before"`
+	After  DeployImage `json:"after"`
+
+	// Containers of service before and after deploy
+	ReplicasBefore int `json:"replicas_before"`
+	ReplicasAfter  int `json:"replicas_after"`
+	ReplicasDelta  int `json:"replicas_delta"`
+
+	By      string    `json:"by,omitempty"`
+	Reason  string    `json:"reason,omitempty"`
+	Started time.Time `json:"started"`
+}
+
+type DeployImage struct {
+	Image  string `json:"image"`
+	Tag    string `json:"tag"`
+	Digest string `json:"digest,omitempty"`
+}
+
+func (s *Service) deployImageRef() DeployImage {
+	return DeployImage{Image: s.image(), Tag: s.Tag, Digest: s.Digest}
+}
+
+// Event with state of service before deploy, completed by notifyDeploy
+func (s *Service) deployEvent(note Annotation) DeployEvent {
+	return DeployEvent{
+		Service:        s.Name,
+		Before:         s.deployImageRef(),
+		ReplicasBefore: len(s.Containers),
+		By:             note.By,
+		Reason:         note.Reason,
+		Started:        time.Now().UTC(),
+	}
+}
+
+// Notify webhooks about result of operation which is not a deploy, like giving up restarts
+func (s *Service) notifyWebhooks(e *env.Env, err error, duration time.Duration) {
+	s.notifyDeploy(e, s.deployEvent(Annotation{}), err, duration)
+}
+
+// Notify configured webhooks about deploy result in background,
+// webhook failures are only logged
+func (s *Service) notifyDeploy(e *env.Env, event DeployEvent, err error, duration time.Duration) {
+
+	urls := s.Config.Webhooks.OnSuccess
+
+	event.DeployID = s.DeployID
+	event.Tag = s.Tag
+	event.Result = `success`
+	event.Duration = duration.Seconds()
+	event.After = s.deployImageRef()
+	event.ReplicasAfter = len(s.Containers)
+	event.ReplicasDelta = event.ReplicasAfter - event.ReplicasBefore
+
+	if err != nil {
+		urls = s.Config.Webhooks.OnFailure
+		event.Result = `failure`
+		event.Error = err.Error()
+	}
+
+	if len(urls) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		e.Log.Error(err)
+		return
+	}
+
+	client := &http.Client{Timeout: webhookTimeout}
+
+	for _, url := range urls {
+		go func(url string) {
+			res, err := client.Post(url, "application/json", bytes.NewReader(body))
+			if err != nil {
+				e.Log.Error(err)
+				return
+			}
+
+			res.Body.Close()
+
+			if res.StatusCode >= 300 {
+				e.Log.Errorf("Webhook %s responded with status %d", url, res.StatusCode)
+			}
+		}(url)
+	}
+}
@@ -0,0 +1,102 @@
+package dns
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const defaultTTL = 60
+
+// ZoneFile keeps records of services in zone file included by DNS server,
+// every service gets A record, or CNAME if host is a name, and SRV record of its port
+type ZoneFile struct {
+	Path string
+
+	// Seconds resolvers cache records, 60 if empty
+	TTL int
+
+	lock    sync.Mutex
+	records map[string]string
+}
+
+func (z *ZoneFile) Upsert(name string, target string) error {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+
+	if z.records == nil {
+		z.records = make(map[string]string)
+	}
+
+	if z.records[name] == target {
+		return nil
+	}
+
+	z.records[name] = target
+
+	return z.write()
+}
+
+func (z *ZoneFile) Delete(name string) error {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+
+	if _, ok := z.records[name]; !ok {
+		return nil
+	}
+
+	delete(z.records, name)
+
+	return z.write()
+}
+
+// Replace zone file at once, so DNS server never reads it half written
+func (z *ZoneFile) write() error {
+
+	if err := os.MkdirAll(filepath.Dir(z.Path), 0755); err != nil {
+		return err
+	}
+
+	ttl := z.TTL
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+
+	names := []string{}
+	for name := range z.records {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	content := ""
+	for _, name := range names {
+
+		host, port, err := net.SplitHostPort(z.records[name])
+		if err != nil {
+			host, port = z.records[name], ""
+		}
+
+		if net.ParseIP(host) != nil {
+			content += fmt.Sprintf("%s\t%d\tIN\tA\t%s\n", name, ttl, host)
+		} else {
+			content += fmt.Sprintf("%s\t%d\tIN\tCNAME\t%s.\n", name, ttl, strings.TrimSuffix(host, "."))
+		}
+
+		if port != "" {
+			content += fmt.Sprintf("_%s._tcp\t%d\tIN\tSRV\t0 0 %s %s\n", name, ttl, port, name)
+		}
+	}
+
+	tmp := z.Path + ".tmp"
+
+	if err := ioutil.WriteFile(tmp, []byte(content), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, z.Path)
+}
@@ -1,10 +1,14 @@
 package docker
 
 import (
+	"context"
+	"fmt"
 	"github.com/deployithq/deployit/drivers/interfaces"
 	"github.com/fsouza/go-dockerclient"
-	"strings"
+	"io"
+	"io/ioutil"
 	"strconv"
+	"strings"
 )
 
 type Containers struct {
@@ -40,6 +44,15 @@ func (d *Containers) client() (*docker.Client, error) {
 	return client, nil
 }
 
+func (d *Containers) Ping() error {
+	client, err := d.client()
+	if err != nil {
+		return err
+	}
+
+	return client.Ping()
+}
+
 func (d *Containers) PullImage(i interfaces.Image) error {
 
 	registry := "index.docker.io"
@@ -67,16 +80,94 @@ func (d *Containers) PullImage(i interfaces.Image) error {
 		tag = t[1]
 	}
 
-	return client.PullImage(docker.PullImageOptions{
-		Repository: i.Name,
-		Registry:   registry,
-		Tag:        tag,
+	// Image pinned by digest is pulled by its full reference
+	if strings.Contains(i.Name, "@") {
+		tag = ""
+	}
+
+	if err := client.PullImage(docker.PullImageOptions{
+		Repository:    i.Name,
+		Registry:      registry,
+		Tag:           tag,
+		OutputStream:  i.OutputStream,
+		RawJSONStream: i.OutputStream != nil,
 	}, docker.AuthConfiguration{
 		Username:      i.Auth.Username,
 		Password:      i.Auth.Password,
 		Email:         i.Auth.Email,
 		ServerAddress: i.Auth.Host,
-	})
+	}); err != nil {
+		return err
+	}
+
+	return checkPlatform(client, i.Name, i.RequiredPlatform)
+}
+
+// Docker client can not request platform variant of image, docker pulls the one of its host,
+// so pulled image is checked to match required platform and nothing else is pulled
+func checkPlatform(client *docker.Client, name, platform string) error {
+
+	if platform == "" {
+		return nil
+	}
+
+	image, err := client.InspectImage(name)
+	if err != nil {
+		return err
+	}
+
+	parts := strings.Split(platform, "/")
+	if image.OS != parts[0] || (len(parts) > 1 && image.Architecture != parts[1]) {
+		return fmt.Errorf("image %s is built for %s/%s, %s is required", name, image.OS, image.Architecture, platform)
+	}
+
+	return nil
+}
+
+// Docker API expects seccomp profile content, so profile files are read
+// like docker cli does, "unconfined" and inline profiles are passed as is
+func loadSeccomp(opts []string) ([]string, error) {
+
+	loaded := []string{}
+
+	for _, opt := range opts {
+
+		parts := strings.SplitN(opt, "=", 2)
+		if len(parts) == 2 && parts[0] == "seccomp" && parts[1] != "unconfined" && !strings.HasPrefix(parts[1], "{") {
+			profile, err := ioutil.ReadFile(parts[1])
+			if err != nil {
+				return nil, err
+			}
+
+			opt = "seccomp=" + string(profile)
+		}
+
+		loaded = append(loaded, opt)
+	}
+
+	return loaded, nil
+}
+
+// Fill image size and layers count of local image
+func (d *Containers) InspectImage(i *interfaces.Image) error {
+
+	client, err := d.client()
+	if err != nil {
+		return err
+	}
+
+	image, err := client.InspectImage(i.Name)
+	if err != nil {
+		return err
+	}
+
+	i.ID = image.ID
+	i.Size = image.Size
+	if image.RootFS != nil {
+		i.Layers = len(image.RootFS.Layers)
+	}
+
+	return nil
 }
 
 func (d *Containers) BuildImage(opts interfaces.BuildImageOptions) error {
@@ -92,6 +183,7 @@ func (d *Containers) BuildImage(opts interfaces.BuildImageOptions) error {
 		InputStream:    opts.InputStream,
 		OutputStream:   opts.OutputStream,
 		ContextDir:     opts.ContextDir,
+		Dockerfile:     opts.Dockerfile,
 		RawJSONStream:  opts.RawJSONStream,
 	}
 
@@ -102,6 +194,17 @@ func (d *Containers) BuildImage(opts interfaces.BuildImageOptions) error {
 	return nil
 }
 
+// Load images from tarball in docker save format
+func (d *Containers) LoadImage(r io.Reader) error {
+
+	client, err := d.client()
+	if err != nil {
+		return err
+	}
+
+	return client.LoadImage(docker.LoadImageOptions{InputStream: r})
+}
+
 func (d *Containers) StartContainer(c *interfaces.Container) error {
 
 	client, err := d.client()
@@ -109,13 +212,29 @@ func (d *Containers) StartContainer(c *interfaces.Container) error {
 		return err
 	}
 
+	// Placement is not used, all containers run on the docker host
 	config := CreateConfig(c.Config)
 	hostconf := CreateHostConfig(c.HostConfig)
 
+	if hostconf.SecurityOpt, err = loadSeccomp(hostconf.SecurityOpt); err != nil {
+		return err
+	}
+
+	ctx := c.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
 	if c.CID == "" {
+		if err := checkPlatform(client, c.Config.Image, c.Config.RequiredPlatform); err != nil {
+			return err
+		}
+
 		options := docker.CreateContainerOptions{
+			Name:       c.Name,
 			Config:     &config,
 			HostConfig: &hostconf,
+			Context:    ctx,
 		}
 
 		container, err := client.CreateContainer(options)
@@ -126,7 +245,7 @@ func (d *Containers) StartContainer(c *interfaces.Container) error {
 		c.CID = container.ID
 	}
 
-	if err := client.StartContainer(c.CID, &hostconf); err != nil {
+	if err := client.StartContainerWithContext(c.CID, &hostconf, ctx); err != nil {
 		return err
 	}
 
@@ -140,7 +259,48 @@ func (d *Containers) StopContainer(c *interfaces.Container) error {
 		return err
 	}
 
-	return client.StopContainer(c.CID, 10)
+	timeout := c.StopTimeout
+	if timeout == 0 {
+		timeout = 10
+	}
+
+	// Container which already exited is stopped
+	if err := client.StopContainer(c.CID, timeout); err != nil {
+		if _, ok := err.(*docker.ContainerNotRunning); !ok {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Send signal like SIGHUP to container, SIGKILL if signal is empty
+func (d *Containers) KillContainer(c *interfaces.Container, signal string) error {
+
+	sig, err := parseSignal(signal)
+	if err != nil {
+		return err
+	}
+
+	client, err := d.client()
+	if err != nil {
+		return err
+	}
+
+	return client.KillContainer(docker.KillContainerOptions{
+		ID:     c.CID,
+		Signal: sig,
+	})
+}
+
+// Block until container exits and return its exit code
+func (d *Containers) WaitContainer(c *interfaces.Container) (int, error) {
+	client, err := d.client()
+	if err != nil {
+		return 0, err
+	}
+
+	return client.WaitContainer(c.CID)
 }
 
 func (d *Containers) RestartContainer(c *interfaces.Container) error {
@@ -153,6 +313,15 @@ func (d *Containers) RestartContainer(c *interfaces.Container) error {
 }
 
 func (d *Containers) RemoveContainer(c *interfaces.Container) error {
+	return d.removeContainer(c, true)
+}
+
+// Remove container leaving its volumes for the next container to reuse
+func (d *Containers) RemoveContainerKeepVolumes(c *interfaces.Container) error {
+	return d.removeContainer(c, false)
+}
+
+func (d *Containers) removeContainer(c *interfaces.Container, volumes bool) error {
 	client, err := d.client()
 	if err != nil {
 		return err
@@ -160,7 +329,7 @@ func (d *Containers) RemoveContainer(c *interfaces.Container) error {
 
 	return client.RemoveContainer(docker.RemoveContainerOptions{
 		ID:            c.CID,
-		RemoveVolumes: true,
+		RemoveVolumes: volumes,
 		Force:         true,
 	})
 }
@@ -189,10 +358,13 @@ func (d *Containers) ListImages() (map[string]interfaces.Image, error) {
 	for index := range ims {
 		i := ims[index]
 
-		for index := range i.RepoTags {
-			name := i.RepoTags[index]
+		// Images pulled by digest may have no tags
+		names := append(append([]string{}, i.RepoTags...), i.RepoDigests...)
+
+		for index := range names {
+			name := names[index]
 
-			if name == "<none>:<none>" {
+			if name == "<none>:<none>" || name == "<none>@<none>" {
 				continue
 			}
 
@@ -206,15 +378,30 @@ func (d *Containers) ListImages() (map[string]interfaces.Image, error) {
 				continue
 			}
 
-			if images[name], err = convertImage(im); err != nil {
+			image, err := convertImage(im)
+			if err != nil {
 				return images, err
 			}
+
+			image.Name = name
+			images[name] = image
 		}
 	}
 
 	return images, nil
 }
 
+// Remove image reference, image used by containers is not removed
+func (d *Containers) RemoveImage(name string) error {
+
+	client, err := d.client()
+	if err != nil {
+		return err
+	}
+
+	return client.RemoveImage(name)
+}
+
 func (d *Containers) ListContainers() (map[string]interfaces.Container, error) {
 
 	var (
@@ -252,7 +439,8 @@ func (d *Containers) ListContainers() (map[string]interfaces.Container, error) {
 	return containers, err
 }
 
-func (d *Containers) InspectContainers(c *interfaces.Container) ([]int64, error) {
+// Host ports of container, inspection is cancelled with context
+func (d *Containers) InspectContainers(ctx context.Context, c *interfaces.Container) ([]int64, error) {
 
 	ports := []int64{}
 
@@ -261,7 +449,7 @@ func (d *Containers) InspectContainers(c *interfaces.Container) ([]int64, error)
 		return ports, err
 	}
 
-	info, err := client.InspectContainer(c.CID)
+	info, err := client.InspectContainerWithContext(c.CID, ctx)
 	if err != nil {
 		return ports, err
 	}
@@ -276,3 +464,93 @@ func (d *Containers) InspectContainers(c *interfaces.Container) ([]int64, error)
 
 	return ports, nil
 }
+
+// Files added, modified or deleted in container writable layer
+func (d *Containers) Changes(c *interfaces.Container) ([]interfaces.FileChange, error) {
+
+	changes := []interfaces.FileChange{}
+
+	client, err := d.client()
+	if err != nil {
+		return changes, err
+	}
+
+	list, err := client.ContainerChanges(c.CID)
+	if err != nil {
+		return changes, err
+	}
+
+	for _, change := range list {
+
+		kind := interfaces.ChangeModified
+		switch change.Kind {
+		case docker.ChangeAdd:
+			kind = interfaces.ChangeAdded
+		case docker.ChangeDelete:
+			kind = interfaces.ChangeDeleted
+		}
+
+		changes = append(changes, interfaces.FileChange{Path: change.Path, Kind: kind})
+	}
+
+	return changes, nil
+}
+
+func (d *Containers) InspectContainer(c *interfaces.Container) error {
+
+	client, err := d.client()
+	if err != nil {
+		return err
+	}
+
+	info, err := client.InspectContainer(c.CID)
+	if err != nil {
+		return err
+	}
+
+	cn, err := ConvertContainer(info)
+	if err != nil {
+		return err
+	}
+
+	*c = cn
+
+	return nil
+}
+
+// Run command in running container and wait for it to exit
+func (d *Containers) Exec(c *interfaces.Container, opts interfaces.ExecOptions) (int, error) {
+
+	client, err := d.client()
+	if err != nil {
+		return 0, err
+	}
+
+	exec, err := client.CreateExec(docker.CreateExecOptions{
+		Container:    c.CID,
+		Cmd:          opts.Cmd,
+		Env:          opts.Env,
+		User:         opts.User,
+		AttachStdin:  opts.InputStream != nil,
+		AttachStdout: opts.OutputStream != nil,
+		AttachStderr: opts.ErrorStream != nil,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := client.StartExec(exec.ID, docker.StartExecOptions{
+		InputStream:  opts.InputStream,
+		OutputStream: opts.OutputStream,
+		ErrorStream:  opts.ErrorStream,
+	}); err != nil {
+		return 0, err
+	}
+
+	info, err := client.InspectExec(exec.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	return info.ExitCode, nil
+}
@@ -0,0 +1,128 @@
+package docker
+
+import (
+	"bytes"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"github.com/fsouza/go-dockerclient"
+	"io"
+	"strconv"
+	"time"
+)
+
+func (d *Containers) Logs(c *interfaces.Container, opts interfaces.LogsOptions) error {
+
+	client, err := d.client()
+	if err != nil {
+		return err
+	}
+
+	o := docker.LogsOptions{
+		Context:      opts.Context,
+		Container:    c.CID,
+		OutputStream: opts.OutputStream,
+		ErrorStream:  opts.ErrorStream,
+		Follow:       opts.Follow,
+		Stdout:       opts.OutputStream != nil,
+		Stderr:       opts.ErrorStream != nil,
+		Timestamps:   opts.Timestamps,
+		Tail:         "all",
+	}
+
+	if opts.Tail > 0 {
+		o.Tail = strconv.Itoa(opts.Tail)
+	}
+
+	// Docker filters by seconds only and has no upper bound,
+	// so requested window is applied to line timestamps
+	if !opts.Since.IsZero() || !opts.Until.IsZero() {
+		if !opts.Since.IsZero() {
+			o.Since = opts.Since.Unix()
+		}
+
+		o.Timestamps = true
+
+		if o.OutputStream != nil {
+			out := &windowWriter{w: o.OutputStream, since: opts.Since, until: opts.Until, timestamps: opts.Timestamps}
+			defer out.Flush()
+			o.OutputStream = out
+		}
+
+		if o.ErrorStream != nil {
+			out := &windowWriter{w: o.ErrorStream, since: opts.Since, until: opts.Until, timestamps: opts.Timestamps}
+			defer out.Flush()
+			o.ErrorStream = out
+		}
+	}
+
+	return client.Logs(o)
+}
+
+// Writes only log lines with timestamp inside of [since, until] window
+type windowWriter struct {
+	w          io.Writer
+	since      time.Time
+	until      time.Time
+	timestamps bool
+	buf        []byte
+}
+
+func (ww *windowWriter) Write(p []byte) (int, error) {
+
+	ww.buf = append(ww.buf, p...)
+
+	for {
+		i := bytes.IndexByte(ww.buf, '\n')
+		if i == -1 {
+			break
+		}
+
+		if err := ww.line(ww.buf[:i+1]); err != nil {
+			return len(p), err
+		}
+
+		ww.buf = ww.buf[i+1:]
+	}
+
+	return len(p), nil
+}
+
+func (ww *windowWriter) Flush() error {
+
+	if len(ww.buf) == 0 {
+		return nil
+	}
+
+	err := ww.line(ww.buf)
+	ww.buf = nil
+
+	return err
+}
+
+func (ww *windowWriter) line(line []byte) error {
+
+	i := bytes.IndexByte(line, ' ')
+	if i == -1 {
+		return nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, string(line[:i]))
+	if err != nil {
+		return nil
+	}
+
+	if !ww.since.IsZero() && t.Before(ww.since) {
+		return nil
+	}
+
+	if !ww.until.IsZero() && t.After(ww.until) {
+		return nil
+	}
+
+	if !ww.timestamps {
+		line = line[i+1:]
+	}
+
+	_, err = ww.w.Write(line)
+
+	return err
+}
@@ -0,0 +1,58 @@
+package docker
+
+import (
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"github.com/fsouza/go-dockerclient"
+)
+
+// Stats streams container usage samples until done is closed or container is removed
+func (d *Containers) Stats(c *interfaces.Container, samples chan<- interfaces.StatsSample, done <-chan bool) error {
+
+	client, err := d.client()
+	if err != nil {
+		return err
+	}
+
+	stats := make(chan *docker.Stats)
+	errs := make(chan error, 1)
+
+	go func() {
+		errs <- client.Stats(docker.StatsOptions{
+			ID:     c.CID,
+			Stats:  stats,
+			Stream: true,
+			Done:   done,
+		})
+	}()
+
+	// Stats channel is closed by client when streaming is finished
+	for s := range stats {
+		samples <- convertStats(c.CID, s)
+	}
+
+	return <-errs
+}
+
+func convertStats(id string, s *docker.Stats) interfaces.StatsSample {
+
+	sample := interfaces.StatsSample{
+		CID:         id,
+		MemoryUsage: s.MemoryStats.Usage,
+		MemoryLimit: s.MemoryStats.Limit,
+		Time:        s.Read,
+	}
+
+	cpu := float64(s.CPUStats.CPUUsage.TotalUsage) - float64(s.PreCPUStats.CPUUsage.TotalUsage)
+	system := float64(s.CPUStats.SystemCPUUsage) - float64(s.PreCPUStats.SystemCPUUsage)
+
+	if cpu > 0 && system > 0 {
+		cpus := float64(len(s.CPUStats.CPUUsage.PercpuUsage))
+		if cpus == 0 {
+			cpus = 1
+		}
+
+		sample.CPUPercent = cpu / system * cpus * 100
+	}
+
+	return sample
+}
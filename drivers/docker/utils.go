@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/deployithq/deployit/drivers/interfaces"
 	"github.com/fsouza/go-dockerclient"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -22,6 +23,22 @@ func ConvertContainer(info *docker.Container) (interfaces.Container, error) {
 
 	cn.CID = info.ID
 	cn.Name = info.Name
+	cn.ImageID = info.Image
+
+	if info.Config != nil {
+		cn.Image = info.Config.Image
+		cn.Config.Labels = info.Config.Labels
+		cn.Config.Env = info.Config.Env
+
+		if check := info.Config.Healthcheck; check != nil && len(check.Test) > 0 && check.Test[0] != "NONE" {
+			cn.Config.Healthcheck = &interfaces.Healthcheck{
+				Test:     check.Test,
+				Interval: check.Interval,
+				Timeout:  check.Timeout,
+				Retries:  check.Retries,
+			}
+		}
+	}
 
 	cn.State.Running = info.State.Running
 	cn.State.Paused = info.State.Paused
@@ -32,6 +49,39 @@ func ConvertContainer(info *docker.Container) (interfaces.Container, error) {
 	cn.State.Error = info.State.Error
 	cn.State.Started = info.State.StartedAt
 	cn.State.Finished = info.State.FinishedAt
+	cn.State.Restarts = info.RestartCount
+
+	if health := info.State.Health; health.Status != "" {
+		cn.State.Health = &interfaces.HealthState{
+			Status:        health.Status,
+			FailingStreak: health.FailingStreak,
+		}
+
+		if len(health.Log) > 0 {
+			last := health.Log[len(health.Log)-1]
+			cn.State.Health.Output = strings.TrimSpace(last.Output)
+			cn.State.Health.ExitCode = last.ExitCode
+			cn.State.Health.Checked = last.End
+		}
+	}
+
+	for _, mount := range info.Mounts {
+
+		kind := interfaces.MountBind
+		if mount.Name != "" {
+			kind = interfaces.MountVolume
+		}
+
+		cn.Mounts = append(cn.Mounts, interfaces.Mount{
+			Type:        kind,
+			Name:        mount.Name,
+			Driver:      mount.Driver,
+			Source:      mount.Source,
+			Destination: mount.Destination,
+			Mode:        mount.Mode,
+			RW:          mount.RW,
+		})
+	}
 
 	for port := range info.NetworkSettings.Ports {
 
@@ -49,6 +99,23 @@ func ConvertContainer(info *docker.Container) (interfaces.Container, error) {
 		}
 	}
 
+	for name, network := range info.NetworkSettings.Networks {
+		cn.Networks = append(cn.Networks, interfaces.Network{
+			Name:        name,
+			ID:          network.NetworkID,
+			IPAddress:   network.IPAddress,
+			IPPrefixLen: network.IPPrefixLen,
+			IPv6Address: network.GlobalIPv6Address,
+			Gateway:     network.Gateway,
+			MacAddress:  network.MacAddress,
+			Aliases:     network.Aliases,
+		})
+	}
+
+	sort.Slice(cn.Networks, func(i, j int) bool {
+		return cn.Networks[i].Name < cn.Networks[j].Name
+	})
+
 	return cn, nil
 }
 
@@ -63,12 +130,14 @@ func CreateConfig(c interfaces.Config) docker.Config {
 	config.Entrypoint = c.Entrypoint
 
 	config.Image = c.Image
+	config.Hostname = c.Hostname
+	config.Labels = c.Labels
 
 	config.ExposedPorts = make(map[docker.Port]struct{})
 	config.Volumes = make(map[string]struct{})
 
 	for _, port := range c.Ports {
-		containerPort, _ := strconv.ParseInt(port, 10, 64)
+		_, containerPort := splitPort(port)
 
 		key := docker.Port(fmt.Sprintf("%d/tcp", containerPort))
 		config.ExposedPorts[key] = struct{}{}
@@ -82,6 +151,9 @@ func CreateConfig(c interfaces.Config) docker.Config {
 	return config
 }
 
+// CFS period in microseconds cpus limit is applied in
+const cpuPeriod = 100000
+
 func CreateHostConfig(c interfaces.HostConfig) docker.HostConfig {
 	host := docker.HostConfig{}
 
@@ -92,14 +164,85 @@ func CreateHostConfig(c interfaces.HostConfig) docker.HostConfig {
 	host.Memory = c.Memory * 1024 * 1024
 	host.Binds = c.Binds
 
+	if c.CPUs > 0 {
+		host.CPUPeriod = cpuPeriod
+		host.CPUQuota = int64(c.CPUs * cpuPeriod)
+	}
+
+	host.CapAdd = c.CapAdd
+	host.CapDrop = c.CapDrop
+	host.Sysctls = c.Sysctls
+	host.PidMode = c.PidMode
+	host.AutoRemove = c.AutoRemove
+	host.ShmSize = c.ShmSize
+	host.SecurityOpt = c.SecurityOpt
+	host.CgroupParent = c.CgroupParent
+	host.IpcMode = c.IpcMode
+
+	host.BlkioWeight = c.BlkioWeight
+	for _, limit := range c.BlkioDeviceReadBps {
+		host.BlkioDeviceReadBps = append(host.BlkioDeviceReadBps, docker.BlockLimit{Path: limit.Path, Rate: limit.Rate})
+	}
+	for _, limit := range c.BlkioDeviceWriteBps {
+		host.BlkioDeviceWriteBps = append(host.BlkioDeviceWriteBps, docker.BlockLimit{Path: limit.Path, Rate: limit.Rate})
+	}
+
 	host.PortBindings = make(map[docker.Port][]docker.PortBinding)
 
 	for _, port := range c.Ports {
-		containerPort, _ := strconv.ParseInt(port, 10, 64)
+		hostPort, containerPort := splitPort(port)
 		key := docker.Port(fmt.Sprintf("%d/tcp", containerPort))
 
-		host.PortBindings[key] = append(host.PortBindings[key], docker.PortBinding{})
+		binding := docker.PortBinding{}
+		if hostPort != "" {
+			binding.HostPort = hostPort
+		}
+
+		host.PortBindings[key] = append(host.PortBindings[key], binding)
 	}
 
 	return host
 }
+
+// Split "8080:80" to host and container port, host port is empty
+// for container port only and is chosen by docker
+func splitPort(port string) (string, int64) {
+
+	parts := strings.Split(port, ":")
+	containerPort, _ := strconv.ParseInt(parts[len(parts)-1], 10, 64)
+
+	if len(parts) == 1 {
+		return "", containerPort
+	}
+
+	return parts[0], containerPort
+}
+
+var signals = map[string]docker.Signal{
+	"HUP":   docker.SIGHUP,
+	"INT":   docker.SIGINT,
+	"QUIT":  docker.SIGQUIT,
+	"KILL":  docker.SIGKILL,
+	"USR1":  docker.SIGUSR1,
+	"USR2":  docker.SIGUSR2,
+	"TERM":  docker.SIGTERM,
+	"WINCH": docker.SIGWINCH,
+}
+
+// Signal by name like SIGHUP or HUP, or by number
+func parseSignal(name string) (docker.Signal, error) {
+
+	if name == "" {
+		return docker.SIGKILL, nil
+	}
+
+	if number, err := strconv.Atoi(name); err == nil && number > 0 {
+		return docker.Signal(number), nil
+	}
+
+	if signal, ok := signals[strings.TrimPrefix(strings.ToUpper(name), "SIG")]; ok {
+		return signal, nil
+	}
+
+	return 0, fmt.Errorf("unknown signal %q", name)
+}
@@ -1,6 +1,7 @@
 package interfaces
 
 import (
+	"context"
 	"io"
 	"time"
 )
@@ -9,13 +10,24 @@ type Container struct {
 	CID     string `json:"cid"`
 	Name    string `json:"name,omitempty"`
 	Image   string `json:"image,omitempty"`
+	ImageID string `json:"image_id,omitempty"`
 	Command string `json:"command,omitempty"`
 
 	Config     Config     `json:"config,omitempty"`
 	HostConfig HostConfig `json:"host_config,omitempty"`
 
-	State State  `json:"state,omitempty"`
-	Ports []Port `json:"ports,omitempty"`
+	State    State     `json:"state,omitempty"`
+	Ports    []Port    `json:"ports,omitempty"`
+	Mounts   []Mount   `json:"mounts,omitempty"`
+	Networks []Network `json:"networks,omitempty"`
+
+	Placement Placement `json:"placement,omitempty"`
+
+	// Seconds driver waits for container to exit on stop before it is killed
+	StopTimeout uint `json:"-"`
+
+	// Cancels driver requests creating and starting container, they are not canceled if empty
+	Context context.Context `json:"-"`
 }
 
 type Port struct {
@@ -25,13 +37,27 @@ type Port struct {
 }
 
 type Config struct {
-	Image      string   `json:"image" yaml:"image,omitempty"`
-	Env        []string `json:"env" yaml:"env,omitempty"`
-	Cmd        []string `json:"cmd" yaml:"cmd,omitempty"`
-	Volumes    []string `json:"volumes" yaml:"volumes,omitempty"` // []string{"/data:/data:rw"}
-	Ports      []string `json:"ports" yaml:"ports,omitempty"` // []string{"80:80"}
-	Memory     int64    `json:"memory" yaml:"memory,omitempty"`
-	Entrypoint []string `json:"entrypoint" yaml:"entrypoint,omitempty"`
+	Image            string   `json:"image" yaml:"image,omitempty"`
+	Env              []string `json:"env" yaml:"env,omitempty"`
+	Cmd              []string `json:"cmd" yaml:"cmd,omitempty"`
+	Volumes          []string `json:"volumes" yaml:"volumes,omitempty"` // []string{"/data:/data:rw"}
+	Ports            []string `json:"ports" yaml:"ports,omitempty"`     // []string{"80:80"}
+	Memory           int64    `json:"memory" yaml:"memory,omitempty"`
+	Entrypoint       []string `json:"entrypoint" yaml:"entrypoint,omitempty"`
+	RequiredPlatform string   `json:"required_platform" yaml:"required_platform,omitempty"`
+	Hostname         string   `json:"hostname" yaml:"hostname,omitempty"`
+
+	Labels map[string]string `json:"labels" yaml:"labels,omitempty"`
+
+	// Driver healthcheck from image or container config, nil if none
+	Healthcheck *Healthcheck `json:"healthcheck,omitempty" yaml:"healthcheck,omitempty"`
+}
+
+type Healthcheck struct {
+	Test     []string      `json:"test" yaml:"test,omitempty"`
+	Interval time.Duration `json:"interval,omitempty" yaml:"interval,omitempty"`
+	Timeout  time.Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	Retries  int           `json:"retries,omitempty" yaml:"retries,omitempty"`
 }
 
 type HostConfig struct {
@@ -39,7 +65,32 @@ type HostConfig struct {
 	Ports         []string            `json:"ports" yaml:"ports,omitempty"` // []string{"80:80"}
 	RestartPolicy RestartPolicyConfig `json:"restart" yaml:"restart,omitempty"`
 	Memory        int64               `json:"memory" yaml:"memory,omitempty"`
+	CPUs          float64             `json:"cpus" yaml:"cpus,omitempty"`
 	Privileged    bool                `json:"privileged" yaml:"privileged,omitempty"`
+	CapAdd        []string            `json:"cap_add" yaml:"cap_add,omitempty"`
+	CapDrop       []string            `json:"cap_drop" yaml:"cap_drop,omitempty"`
+	Sysctls       map[string]string   `json:"sysctls" yaml:"sysctls,omitempty"`
+	PidMode       string              `json:"pid_mode" yaml:"pid_mode,omitempty"`
+	IpcMode       string              `json:"ipc_mode" yaml:"ipc_mode,omitempty"`
+	AutoRemove    bool                `json:"auto_remove" yaml:"auto_remove,omitempty"`
+	ShmSize       int64               `json:"shm_size" yaml:"shm_size,omitempty"` // bytes
+	SecurityOpt   []string            `json:"security_opt" yaml:"security_opt,omitempty"`
+	CgroupParent  string              `json:"cgroup_parent" yaml:"cgroup_parent,omitempty"`
+
+	BlkioWeight         int64        `json:"blkio_weight" yaml:"blkio_weight,omitempty"`
+	BlkioDeviceReadBps  []BlockLimit `json:"blkio_device_read_bps" yaml:"blkio_device_read_bps,omitempty"`
+	BlkioDeviceWriteBps []BlockLimit `json:"blkio_device_write_bps" yaml:"blkio_device_write_bps,omitempty"`
+}
+
+// Bytes per second limit of block device
+type BlockLimit struct {
+	Path string `json:"path" yaml:"path"`
+	Rate int64  `json:"rate" yaml:"rate"`
+}
+
+// Placement constraints for scheduler, like "node.labels.zone==eu"
+type Placement struct {
+	Constraints []string `json:"constraints" yaml:"constraints,omitempty"`
 }
 
 type Volume struct {
@@ -48,6 +99,34 @@ type Volume struct {
 	Options   string `json:"options" yaml:"options,omitempty"`
 }
 
+const (
+	MountBind   = `bind`
+	MountVolume = `volume`
+)
+
+// Mount of running container, named volumes have name and driver
+type Mount struct {
+	Type        string `json:"type" yaml:"type,omitempty"`
+	Name        string `json:"name,omitempty" yaml:"name,omitempty"`
+	Driver      string `json:"driver,omitempty" yaml:"driver,omitempty"`
+	Source      string `json:"source" yaml:"source,omitempty"`
+	Destination string `json:"destination" yaml:"destination,omitempty"`
+	Mode        string `json:"mode" yaml:"mode,omitempty"`
+	RW          bool   `json:"rw" yaml:"rw,omitempty"`
+}
+
+// Network container is attached to with addresses assigned in it
+type Network struct {
+	Name        string   `json:"name" yaml:"name,omitempty"`
+	ID          string   `json:"id" yaml:"id,omitempty"`
+	IPAddress   string   `json:"ip_address" yaml:"ip_address,omitempty"`
+	IPPrefixLen int      `json:"ip_prefix_len" yaml:"ip_prefix_len,omitempty"`
+	IPv6Address string   `json:"ipv6_address,omitempty" yaml:"ipv6_address,omitempty"`
+	Gateway     string   `json:"gateway" yaml:"gateway,omitempty"`
+	MacAddress  string   `json:"mac_address" yaml:"mac_address,omitempty"`
+	Aliases     []string `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+}
+
 type RestartPolicyConfig struct {
 	Name    string `json:"name" yaml:"name,omitempty"`
 	Attempt int    `json:"attempt" yaml:"attempt,omitempty"`
@@ -63,11 +142,62 @@ type State struct {
 	Error      string    `json:"error,omitempty" yaml:"error,omitempty"`
 	Started    time.Time `json:"started,omitempty" yaml:"started,omitempty"`
 	Finished   time.Time `json:"finished,omitempty" yaml:"finished,omitempty"`
+
+	// Restarts made by driver restart policy
+	Restarts int `json:"restarts,omitempty" yaml:"restarts,omitempty"`
+
+	// Container exited and was removed by driver
+	Completed bool `json:"completed,omitempty" yaml:"completed,omitempty"`
+
+	// Result of driver healthcheck, nil if container has none
+	Health *HealthState `json:"health,omitempty" yaml:"health,omitempty"`
+}
+
+type HealthState struct {
+	// Starting, healthy or unhealthy
+	Status        string `json:"status" yaml:"status"`
+	FailingStreak int    `json:"failing_streak" yaml:"failing_streak"`
+
+	// Output and exit code of the last probe
+	Output   string    `json:"output,omitempty" yaml:"output,omitempty"`
+	ExitCode int       `json:"exit_code" yaml:"exit_code"`
+	Checked  time.Time `json:"checked,omitempty" yaml:"checked,omitempty"`
 }
 
 type Image struct {
+	ID   string     `json:"id,omitempty" yaml:"id,omitempty"` // filled by image inspection
 	Name string     `json:"name" yaml:"name,omitempty"`
 	Auth AuthConfig `json:"auth,omitempty" yaml:"auth,omitempty"`
+
+	// Platform like linux/arm64 pulled image is checked to be built for, any if empty
+	RequiredPlatform string `json:"required_platform,omitempty" yaml:"required_platform,omitempty"`
+
+	// Receives raw json pull progress stream when set
+	OutputStream io.Writer `json:"-" yaml:"-"`
+
+	// Filled by image inspection
+	Size   int64 `json:"size,omitempty" yaml:"size,omitempty"`
+	Layers int   `json:"layers,omitempty" yaml:"layers,omitempty"`
+}
+
+// Severities of scan findings from lowest to highest
+const (
+	SeverityLow      = `low`
+	SeverityMedium   = `medium`
+	SeverityHigh     = `high`
+	SeverityCritical = `critical`
+)
+
+type ScanResult struct {
+	Findings []Finding `json:"findings"`
+}
+
+// Vulnerability found in image package
+type Finding struct {
+	ID       string `json:"id"`
+	Severity string `json:"severity"`
+	Package  string `json:"package"`
+	Title    string `json:"title"`
 }
 
 type AuthConfig struct {
@@ -81,7 +211,53 @@ type BuildImageOptions struct {
 	Name           string    `json:"name"`
 	RmTmpContainer bool      `json:"rm"`
 	ContextDir     string    `json:"context"`
+	Dockerfile     string    `json:"dockerfile"`
 	RawJSONStream  bool      `json:"raw"`
 	InputStream    io.Reader `json:"-"`
 	OutputStream   io.Writer `json:"-"`
 }
+
+type LogsOptions struct {
+	Since        time.Time `json:"since"`
+	Until        time.Time `json:"until"`
+	Tail         int       `json:"tail"` // all lines if empty
+	Follow       bool      `json:"follow"`
+	Timestamps   bool      `json:"timestamps"`
+	OutputStream io.Writer `json:"-"`
+	ErrorStream  io.Writer `json:"-"`
+
+	// Followed logs are streamed until context is done or container stops
+	Context context.Context `json:"-"`
+}
+
+// Command run in running container, exit code of it is returned
+type ExecOptions struct {
+	Cmd          []string  `json:"cmd"`
+	Env          []string  `json:"env"`
+	User         string    `json:"user"`
+	InputStream  io.Reader `json:"-"`
+	OutputStream io.Writer `json:"-"`
+	ErrorStream  io.Writer `json:"-"`
+}
+
+// Resources usage of container at a moment
+type StatsSample struct {
+	CID         string    `json:"cid"`
+	CPUPercent  float64   `json:"cpu_percent"`
+	MemoryUsage uint64    `json:"memory_usage"`
+	MemoryLimit uint64    `json:"memory_limit"`
+	Time        time.Time `json:"time"`
+}
+
+// Kinds of file changes in container writable layer
+const (
+	ChangeModified = "modified"
+	ChangeAdded    = "added"
+	ChangeDeleted  = "deleted"
+)
+
+// File changed in container relative to its image
+type FileChange struct {
+	Path string `json:"path"`
+	Kind string `json:"kind"`
+}
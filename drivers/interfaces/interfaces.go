@@ -1,6 +1,10 @@
 package interfaces
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"io"
+)
 
 type ILog interface {
 	Debug(...interface{})
@@ -25,21 +29,65 @@ type ILDB interface {
 	Read(key string, i interface{}) error
 	Write(key string, i interface{}) error
 	Remove(key string) error
+	List(prefix string) ([]string, error)
+
+	// CompareAndSwap reads key into stored and writes i only if check passes and key was not
+	// written meanwhile, ErrKeyChanged is returned when key was written by other writer
+	CompareAndSwap(key string, stored interface{}, check func() error, i interface{}) error
 }
 
 type IContainers interface {
 	PullImage(i Image) error
+	InspectImage(*Image) error
 	BuildImage(opts BuildImageOptions) error
+	LoadImage(r io.Reader) error
 
 	StartContainer(*Container) error
 	StopContainer(*Container) error
+	WaitContainer(*Container) (int, error)
+	KillContainer(c *Container, signal string) error
 	RestartContainer(*Container) error
 	RemoveContainer(*Container) error
+	RemoveContainerKeepVolumes(*Container) error
 
 	ListImages() (map[string]Image, error)
+	RemoveImage(name string) error
 	ListContainers() (map[string]Container, error)
 
-	InspectContainers(c *Container) ([]int64, error)
+	InspectContainers(ctx context.Context, c *Container) ([]int64, error)
+	InspectContainer(c *Container) error
+	Logs(c *Container, opts LogsOptions) error
+	Ping() error
+	Stats(c *Container, samples chan<- StatsSample, done <-chan bool) error
+	Changes(c *Container) ([]FileChange, error)
+	Exec(c *Container, opts ExecOptions) (int, error)
+}
+
+// Produces container name of service replica
+type INamer interface {
+	Name(service string, index int) string
+}
+
+// Routes traffic to service replicas, replicas are deregistered before they are stopped
+type IBalancer interface {
+	Register(service, id, address string) error
+	Deregister(service, id string) error
+}
+
+// Keeps DNS records of running services, target is host:port
+type IDNS interface {
+	Upsert(name string, target string) error
+	Delete(name string) error
+}
+
+// Scans image for vulnerabilities before it is deployed
+type IImageScanner interface {
+	Scan(image string) (ScanResult, error)
+}
+
+type IRegistry interface {
+	Digest(image, tag string, auth AuthConfig) (string, error)
+	Exists(image, tag string, auth AuthConfig) (bool, error)
 }
 
 type IPrint interface {
@@ -56,3 +104,12 @@ type IPrint interface {
 }
 
 var ErrBucketNotFound error = errors.New("BUCKET_NOT_FOUND")
+
+// ErrKeyNotFound is returned by ILDB Read of key which is not stored
+var ErrKeyNotFound error = errors.New("KEY_NOT_FOUND")
+
+// ErrKeyChanged is returned by ILDB CompareAndSwap of key written by other writer
+var ErrKeyChanged error = errors.New("KEY_CHANGED")
+
+// ErrImageNotFound is returned by IRegistry for image tag which is not in registry
+var ErrImageNotFound error = errors.New("image not found")
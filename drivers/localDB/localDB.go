@@ -2,12 +2,18 @@ package localDB
 
 import (
 	"fmt"
+	"github.com/deployithq/deployit/drivers/interfaces"
 	"gopkg.in/yaml.v2"
+	"io/ioutil"
 	"os"
 	"path"
-	"io/ioutil"
+	"strings"
+	"syscall"
 )
 
+// Directory of lock files guarding compare and swap of keys, List skips directories
+const locksDir = `.locks`
+
 type LDB struct {
 	path string
 	mode os.FileMode
@@ -33,6 +39,9 @@ func (ldb *LDB) Read(key string, i interface{}) error {
 	}
 
 	source, err := ioutil.ReadFile(fmt.Sprintf("%s/%s", ldb.path, key))
+	if os.IsNotExist(err) {
+		return interfaces.ErrKeyNotFound
+	}
 	if err != nil {
 		return err
 	}
@@ -50,6 +59,10 @@ func (ldb *LDB) Write(key string, i interface{}) error {
 
 	filepath := fmt.Sprintf("%s/%s", ldb.path, key)
 
+	if err := os.MkdirAll(path.Dir(filepath), ldb.mode); err != nil {
+		return err
+	}
+
 	var _, err = os.Stat(filepath)
 
 	if !os.IsNotExist(err) {
@@ -59,7 +72,7 @@ func (ldb *LDB) Write(key string, i interface{}) error {
 		}
 	}
 
-	file, err := os.OpenFile(filepath, os.O_CREATE |os.O_RDWR, 0666)
+	file, err := os.OpenFile(filepath, os.O_CREATE|os.O_RDWR, 0666)
 	if err != nil {
 		return err
 	}
@@ -90,3 +103,75 @@ func (ldb *LDB) Remove(key string) error {
 
 	return nil
 }
+
+// CompareAndSwap holds exclusive file lock of key, so writers of other processes are serialized
+func (ldb *LDB) CompareAndSwap(key string, stored interface{}, check func() error, i interface{}) error {
+
+	dir := fmt.Sprintf("%s/%s", ldb.path, locksDir)
+	if err := os.MkdirAll(dir, ldb.mode); err != nil {
+		return err
+	}
+
+	lock, err := os.OpenFile(fmt.Sprintf("%s/%s", dir, strings.Replace(key, "/", "_", -1)), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+
+	if err := syscall.Flock(int(lock.Fd()), syscall.LOCK_EX); err != nil {
+		return err
+	}
+	defer syscall.Flock(int(lock.Fd()), syscall.LOCK_UN)
+
+	if err := ldb.Read(key, stored); err != nil {
+		return err
+	}
+
+	if err := check(); err != nil {
+		return err
+	}
+
+	payload, err := yaml.Marshal(i)
+	if err != nil {
+		return err
+	}
+
+	// Renamed temporary file replaces record at once, readers never see it missing
+	filepath := fmt.Sprintf("%s/%s", ldb.path, key)
+	tmp := fmt.Sprintf("%s/%s.tmp", dir, strings.Replace(key, "/", "_", -1))
+
+	if err := ioutil.WriteFile(tmp, payload, 0666); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, filepath)
+}
+
+func (ldb *LDB) List(prefix string) ([]string, error) {
+
+	keys := []string{}
+
+	files, err := ioutil.ReadDir(fmt.Sprintf("%s/%s", ldb.path, prefix))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return keys, nil
+		}
+		return keys, err
+	}
+
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		// Keys of root are listed without prefix
+		if prefix == "" {
+			keys = append(keys, file.Name())
+			continue
+		}
+
+		keys = append(keys, fmt.Sprintf("%s/%s", prefix, file.Name()))
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,111 @@
+package localDB
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+type record struct {
+	Version int `yaml:"version"`
+}
+
+var errStale = errors.New("stale")
+
+func TestCompareAndSwap(t *testing.T) {
+
+	ldb, err := Init(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ldb.Write("services/web", &record{Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every writer expects version 1, only the first one may write
+	errs := make([]error, 8)
+
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			stored := new(record)
+			check := func() error {
+				if stored.Version != 1 {
+					return errStale
+				}
+				return nil
+			}
+
+			errs[i] = ldb.CompareAndSwap("services/web", stored, check, &record{Version: 2})
+		}(i)
+	}
+	wg.Wait()
+
+	written := 0
+	for _, err := range errs {
+		switch err {
+		case nil:
+			written++
+		case errStale:
+		default:
+			t.Fatal(err)
+		}
+	}
+
+	if written != 1 {
+		t.Errorf("written = %d, want 1", written)
+	}
+
+	keys, err := ldb.List("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys) != 0 {
+		t.Errorf("List() = %v, lock files are listed", keys)
+	}
+}
+
+func TestList(t *testing.T) {
+
+	ldb, err := Init(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"site", "services/web", "services/db", "logs/web/1"} {
+		if err := ldb.Write(key, &record{Version: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		prefix string
+		keys   []string
+	}{
+		{"", []string{"site"}},
+		{"services", []string{"services/db", "services/web"}},
+		{"logs", []string{}},
+		{"logs/web", []string{"logs/web/1"}},
+		{"missing", []string{}},
+	}
+
+	for _, tt := range tests {
+		keys, err := ldb.List(tt.prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sort.Strings(keys)
+
+		if !reflect.DeepEqual(keys, tt.keys) {
+			t.Errorf("List(%q) = %v, want %v", tt.prefix, keys, tt.keys)
+		}
+	}
+}
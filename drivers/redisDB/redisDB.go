@@ -0,0 +1,221 @@
+package redisDB
+
+import (
+	"fmt"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"github.com/garyburd/redigo/redis"
+	"gopkg.in/yaml.v2"
+	"strings"
+	"time"
+)
+
+const namespace = "deployit"
+
+// Keys hinted to redis per SCAN call of List
+const scanCount = 100
+
+type RDB struct {
+	pool *redis.Pool
+}
+
+func Init(address, password string) (*RDB, error) {
+
+	conn := new(RDB)
+	conn.pool = &redis.Pool{
+		MaxIdle:     3,
+		IdleTimeout: 240 * time.Second,
+		Dial: func() (redis.Conn, error) {
+			c, err := redis.Dial("tcp", address)
+			if err != nil {
+				return nil, err
+			}
+
+			if password != "" {
+				if _, err := c.Do("AUTH", password); err != nil {
+					c.Close()
+					return nil, err
+				}
+			}
+
+			return c, nil
+		},
+	}
+
+	c := conn.pool.Get()
+	defer c.Close()
+
+	if _, err := c.Do("PING"); err != nil {
+		return conn, err
+	}
+
+	return conn, nil
+}
+
+func key(k string) string {
+	return fmt.Sprintf("%s:%s", namespace, k)
+}
+
+func (rdb *RDB) Read(k string, i interface{}) error {
+
+	if k == "" {
+		return nil
+	}
+
+	c := rdb.pool.Get()
+	defer c.Close()
+
+	source, err := redis.Bytes(c.Do("GET", key(k)))
+	if err == redis.ErrNil {
+		return interfaces.ErrKeyNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := yaml.Unmarshal(source, i); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (rdb *RDB) Write(k string, i interface{}) error {
+
+	payload, err := yaml.Marshal(i)
+	if err != nil {
+		return err
+	}
+
+	c := rdb.pool.Get()
+	defer c.Close()
+
+	if _, err := c.Do("SET", key(k), payload); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CompareAndSwap watches key, so transaction is aborted when other daemon writes it meanwhile
+func (rdb *RDB) CompareAndSwap(k string, stored interface{}, check func() error, i interface{}) error {
+
+	c := rdb.pool.Get()
+	defer c.Close()
+
+	if _, err := c.Do("WATCH", key(k)); err != nil {
+		return err
+	}
+	defer c.Do("UNWATCH")
+
+	source, err := redis.Bytes(c.Do("GET", key(k)))
+	if err == redis.ErrNil {
+		return interfaces.ErrKeyNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := yaml.Unmarshal(source, stored); err != nil {
+		return err
+	}
+
+	if err := check(); err != nil {
+		return err
+	}
+
+	payload, err := yaml.Marshal(i)
+	if err != nil {
+		return err
+	}
+
+	if err := c.Send("MULTI"); err != nil {
+		return err
+	}
+
+	if err := c.Send("SET", key(k), payload); err != nil {
+		return err
+	}
+
+	reply, err := c.Do("EXEC")
+	if err != nil {
+		return err
+	}
+
+	// Nil reply of EXEC means watched key was changed
+	if reply == nil {
+		return interfaces.ErrKeyChanged
+	}
+
+	return nil
+}
+
+func (rdb *RDB) Remove(k string) error {
+
+	c := rdb.pool.Get()
+	defer c.Close()
+
+	if _, err := c.Do("DEL", key(k)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (rdb *RDB) List(prefix string) ([]string, error) {
+
+	keys := []string{}
+
+	c := rdb.pool.Get()
+	defer c.Close()
+
+	base := key(prefix) + "/"
+	if prefix == "" {
+		base = key("")
+	}
+
+	// SCAN walks keyspace in batches and does not block redis like KEYS does, it can return key more than once
+	values := []string{}
+	seen := make(map[string]bool)
+
+	cursor := 0
+	for {
+		reply, err := redis.Values(c.Do("SCAN", cursor, "MATCH", base+"*", "COUNT", scanCount))
+		if err != nil {
+			return keys, err
+		}
+
+		var batch []string
+		if _, err := redis.Scan(reply, &cursor, &batch); err != nil {
+			return keys, err
+		}
+
+		for _, value := range batch {
+			if !seen[value] {
+				seen[value] = true
+				values = append(values, value)
+			}
+		}
+
+		if cursor == 0 {
+			break
+		}
+	}
+
+	for _, value := range values {
+		name := strings.TrimPrefix(value, base)
+
+		// Only direct children of prefix like local storage does
+		if strings.Contains(name, "/") {
+			continue
+		}
+
+		if prefix == "" {
+			keys = append(keys, name)
+			continue
+		}
+
+		keys = append(keys, fmt.Sprintf("%s/%s", prefix, name))
+	}
+
+	return keys, nil
+}
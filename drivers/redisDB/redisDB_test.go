@@ -0,0 +1,69 @@
+package redisDB
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// Test runs against redis of DEPLOYIT_TEST_REDIS_ADDRESS and is skipped if it is not set
+func testRDB(t *testing.T) *RDB {
+
+	address := os.Getenv("DEPLOYIT_TEST_REDIS_ADDRESS")
+	if address == "" {
+		t.Skip("DEPLOYIT_TEST_REDIS_ADDRESS is not set")
+	}
+
+	rdb, err := Init(address, os.Getenv("DEPLOYIT_TEST_REDIS_PASSWORD"))
+	if err != nil {
+		t.Skip(err)
+	}
+
+	return rdb
+}
+
+func TestList(t *testing.T) {
+
+	rdb := testRDB(t)
+
+	prefix := fmt.Sprintf("test-%d", time.Now().UnixNano())
+
+	stored := []string{
+		prefix + "/services/web",
+		prefix + "/services/db",
+		prefix + "/services/web/logs",
+		prefix + "/apps/site",
+	}
+
+	for _, k := range stored {
+		if err := rdb.Write(k, map[string]string{"name": k}); err != nil {
+			t.Fatal(err)
+		}
+		defer rdb.Remove(k)
+	}
+
+	tests := []struct {
+		prefix string
+		keys   []string
+	}{
+		{prefix + "/services", []string{prefix + "/services/db", prefix + "/services/web"}},
+		{prefix + "/apps", []string{prefix + "/apps/site"}},
+		{prefix + "/missing", []string{}},
+	}
+
+	for _, tt := range tests {
+		keys, err := rdb.List(tt.prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sort.Strings(keys)
+
+		if !reflect.DeepEqual(keys, tt.keys) {
+			t.Errorf("List(%q) = %v, want %v", tt.prefix, keys, tt.keys)
+		}
+	}
+}
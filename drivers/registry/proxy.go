@@ -0,0 +1,58 @@
+package registry
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Proxy settings of registry digest lookups, empty settings connect directly.
+// Image pulls are made by docker engine and don't use these settings
+type Proxy struct {
+	HTTP    string
+	HTTPS   string
+	NoProxy string // comma separated hosts and domain suffixes, "*" disables proxy
+}
+
+func (p Proxy) proxy(req *http.Request) (*url.URL, error) {
+
+	address := p.HTTP
+	if req.URL.Scheme == "https" {
+		address = p.HTTPS
+	}
+
+	if address == "" || p.bypass(req.URL.Host) {
+		return nil, nil
+	}
+
+	if !strings.Contains(address, "://") {
+		address = "http://" + address
+	}
+
+	return url.Parse(address)
+}
+
+func (p Proxy) bypass(host string) bool {
+
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	for _, item := range strings.Split(p.NoProxy, ",") {
+		item = strings.TrimSpace(item)
+
+		switch {
+		case item == "":
+			continue
+		case item == "*":
+			return true
+		case host == strings.TrimPrefix(item, "."):
+			return true
+		case strings.HasSuffix(host, "."+strings.TrimPrefix(item, ".")):
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,190 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	"github.com/deployithq/deployit/utils"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrImageNotFound is the same error service layer reports for missing images
+var ErrImageNotFound = interfaces.ErrImageNotFound
+
+const manifestTypes = "application/vnd.docker.distribution.manifest.v2+json, " +
+	"application/vnd.docker.distribution.manifest.list.v2+json"
+
+type Registry struct {
+	Proxy Proxy
+}
+
+func (r *Registry) client() *http.Client {
+	return &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: &http.Transport{Proxy: r.Proxy.proxy},
+	}
+}
+
+// Digest resolves image tag to the manifest digest it currently points to
+func (r *Registry) Digest(image, tag string, auth interfaces.AuthConfig) (string, error) {
+
+	manifest := manifestURL(image, tag)
+
+	res, err := r.manifest(manifest, "")
+	if err != nil {
+		return "", err
+	}
+
+	if res.StatusCode == http.StatusUnauthorized {
+		authorization, err := r.authorize(res.Header.Get("Www-Authenticate"), auth)
+		if err != nil {
+			return "", err
+		}
+
+		res, err = r.manifest(manifest, authorization)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return "", ErrImageNotFound
+	default:
+		return "", fmt.Errorf("registry responded with status %d", res.StatusCode)
+	}
+
+	digest := res.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", errors.New("registry did not return image digest")
+	}
+
+	return digest, nil
+}
+
+// Manifest url of image tag, docker hub references are served by registry-1.docker.io
+func manifestURL(image, tag string) string {
+
+	host, repository, t := utils.ParseImage(image)
+
+	if tag == "" {
+		tag = t
+	}
+
+	if tag == "" {
+		tag = "latest"
+	}
+
+	switch host {
+	case "index.docker.io", "docker.io", "registry-1.docker.io":
+		host = "registry-1.docker.io"
+		if !strings.Contains(repository, "/") {
+			repository = "library/" + repository
+		}
+	}
+
+	return fmt.Sprintf("https://%s/v2/%s/manifests/%s", host, repository, tag)
+}
+
+// Exists checks image tag manifest is in registry
+func (r *Registry) Exists(image, tag string, auth interfaces.AuthConfig) (bool, error) {
+
+	_, err := r.Digest(image, tag, auth)
+
+	switch err {
+	case nil:
+		return true, nil
+	case ErrImageNotFound:
+		return false, nil
+	}
+
+	return false, err
+}
+
+func (r *Registry) manifest(manifest, authorization string) (*http.Response, error) {
+
+	req, err := http.NewRequest("HEAD", manifest, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", manifestTypes)
+
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	res, err := r.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	res.Body.Close()
+
+	return res, nil
+}
+
+// Build authorization header for registry challenge
+func (r *Registry) authorize(challenge string, auth interfaces.AuthConfig) (string, error) {
+
+	if strings.HasPrefix(challenge, "Basic") {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.SetBasicAuth(auth.Username, auth.Password)
+		return req.Header.Get("Authorization"), nil
+	}
+
+	if !strings.HasPrefix(challenge, "Bearer ") {
+		return "", fmt.Errorf("unsupported registry auth challenge: %s", challenge)
+	}
+
+	params := make(map[string]string)
+	for _, param := range strings.Split(strings.TrimPrefix(challenge, "Bearer "), ",") {
+		kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+		if len(kv) == 2 {
+			params[kv[0]] = strings.Trim(kv[1], `"`)
+		}
+	}
+
+	query := url.Values{}
+	query.Set("service", params["service"])
+	query.Set("scope", params["scope"])
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s?%s", params["realm"], query.Encode()), nil)
+	if err != nil {
+		return "", err
+	}
+
+	if auth.Username != "" {
+		req.SetBasicAuth(auth.Username, auth.Password)
+	}
+
+	res, err := r.client().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry auth responded with status %d", res.StatusCode)
+	}
+
+	token := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+
+	if err := json.NewDecoder(res.Body).Decode(&token); err != nil {
+		return "", err
+	}
+
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+
+	return "Bearer " + token.Token, nil
+}
@@ -0,0 +1,36 @@
+package registry
+
+import (
+	"testing"
+)
+
+func TestManifestURL(t *testing.T) {
+
+	hub := "https://registry-1.docker.io/v2/"
+
+	tests := []struct {
+		image    string
+		tag      string
+		manifest string
+	}{
+		{"nginx", "", hub + "library/nginx/manifests/latest"},
+		{"nginx:1.25", "", hub + "library/nginx/manifests/1.25"},
+		{"nginx:1.25", "1.27", hub + "library/nginx/manifests/1.27"},
+		{"nginx@sha256:abc", "", hub + "library/nginx/manifests/sha256:abc"},
+		{"library/nginx", "", hub + "library/nginx/manifests/latest"},
+		{"user/app", "", hub + "user/app/manifests/latest"},
+		{"docker.io/library/foo", "", hub + "library/foo/manifests/latest"},
+		{"docker.io/foo", "", hub + "library/foo/manifests/latest"},
+		{"index.docker.io/library/foo", "", hub + "library/foo/manifests/latest"},
+		{"registry-1.docker.io/library/foo", "", hub + "library/foo/manifests/latest"},
+		{"host:5000/x", "", "https://host:5000/v2/x/manifests/latest"},
+		{"host:5000/x:1.0", "", "https://host:5000/v2/x/manifests/1.0"},
+		{"localhost/team/x", "", "https://localhost/v2/team/x/manifests/latest"},
+	}
+
+	for _, test := range tests {
+		if manifest := manifestURL(test.image, test.tag); manifest != test.manifest {
+			t.Errorf("manifestURL(%q, %q) = %q, want %q", test.image, test.tag, manifest, test.manifest)
+		}
+	}
+}
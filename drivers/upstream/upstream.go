@@ -0,0 +1,77 @@
+package upstream
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Files keeps upstream list of every service in <Dir>/<service>.conf
+// as nginx server lines, so proxy including them can be reloaded
+type Files struct {
+	Dir string
+
+	lock     sync.Mutex
+	services map[string]map[string]string
+}
+
+func (f *Files) Register(service, id, address string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.services == nil {
+		f.services = make(map[string]map[string]string)
+	}
+
+	if f.services[service] == nil {
+		f.services[service] = make(map[string]string)
+	}
+
+	f.services[service][id] = address
+
+	return f.write(service)
+}
+
+func (f *Files) Deregister(service, id string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if _, ok := f.services[service][id]; !ok {
+		return nil
+	}
+
+	delete(f.services[service], id)
+
+	return f.write(service)
+}
+
+// Replace upstream file at once, so proxy never reads it half written
+func (f *Files) write(service string) error {
+
+	if err := os.MkdirAll(f.Dir, 0755); err != nil {
+		return err
+	}
+
+	ids := []string{}
+	for id := range f.services[service] {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	content := ""
+	for _, id := range ids {
+		content += fmt.Sprintf("server %s; # %s\n", f.services[service][id], id)
+	}
+
+	path := filepath.Join(f.Dir, service+".conf")
+	tmp := path + ".tmp"
+
+	if err := ioutil.WriteFile(tmp, []byte(content), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
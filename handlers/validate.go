@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"flag"
+	"github.com/deployithq/deployit/daemon/service"
+	"github.com/deployithq/deployit/drivers/interfaces"
+	print_ "github.com/deployithq/deployit/drivers/print"
+)
+
+type ValidateCommand struct {
+	Path  string
+	Print interfaces.IPrint
+}
+
+func (c *ValidateCommand) Run(args []string) int {
+
+	// Initializaing printing module
+	c.Print = print_.Init()
+
+	// Creating flags set
+	cmdFlags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	cmdFlags.Usage = func() {
+		c.Print.WhiteInfo(c.Help())
+	}
+
+	cmdFlags.StringVar(&c.Path, "config", "deployit.yaml", "Path to service config")
+
+	// Parsing flags
+	if err := cmdFlags.Parse(args); err != nil {
+		c.Print.WhiteInfo(c.Help())
+		return 1
+	}
+
+	config, err := service.ReadConfig(c.Path)
+	if err != nil {
+		c.Print.Error(err)
+		return 1
+	}
+
+	errs := config.Validate()
+	if len(errs) > 0 {
+		for _, err := range errs {
+			c.Print.Error(err)
+		}
+		return 1
+	}
+
+	c.Print.Info("Config is valid")
+
+	return 0
+}
+
+func (c *ValidateCommand) Help() string {
+	return "Usage: deploy validate [-config=deployit.yaml]"
+}
+
+func (c *ValidateCommand) Synopsis() string {
+	return "Validate service config"
+}
@@ -21,6 +21,9 @@ func main() {
 		"it": func() (cli.Command, error) {
 			return new(handlers.ItCommand), nil
 		},
+		"validate": func() (cli.Command, error) {
+			return new(handlers.ValidateCommand), nil
+		},
 		"app start": func() (cli.Command, error) {
 			return &handlers.AppCommand{
 				Subcommand: "start",
@@ -297,3 +297,31 @@ func FileLine() string {
 	}
 	return fmt.Sprintf("%s:%d", file, line)
 }
+
+// ParseImage - split image reference to registry host, repository and tag
+func ParseImage(image string) (string, string, string) {
+
+	registry := "index.docker.io"
+	repository := image
+	tag := ""
+
+	if i := strings.Index(repository, "@"); i != -1 {
+		tag = repository[i+1:]
+		repository = repository[:i]
+	} else if i := strings.LastIndex(repository, ":"); i != -1 && !strings.Contains(repository[i+1:], "/") {
+		tag = repository[i+1:]
+		repository = repository[:i]
+	}
+
+	parts := strings.SplitN(repository, "/", 2)
+	if len(parts) == 2 && (parts[0] == "localhost" || strings.ContainsAny(parts[0], ".:")) {
+		registry = parts[0]
+		repository = parts[1]
+	}
+
+	if registry == "index.docker.io" && !strings.Contains(repository, "/") {
+		repository = "library/" + repository
+	}
+
+	return registry, repository, tag
+}